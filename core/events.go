@@ -34,6 +34,14 @@ import (
 // NewTxsEvent is posted when a batch of transactions enter the transaction pool.
 type NewTxsEvent struct{ Txs []*types.Transaction }
 
+// DroppedTxsEvent is posted when a batch of transactions leave the transaction
+// pool, whether they were mined, replaced, underpriced or expired.
+type DroppedTxsEvent struct{ Hashes []common.Hash }
+
+// ReplacedTxsEvent is posted when a batch of transactions are replaced in the
+// transaction pool by transactions with the same nonce and a higher fee.
+type ReplacedTxsEvent struct{ Hashes []common.Hash }
+
 // NewTxPoolHeadEvent is posted when the pool receives a request to update
 // its head to [Block].
 type NewTxPoolHeadEvent struct{ Block *types.Block }
@@ -252,6 +252,8 @@ type TxPool struct {
 	txFeed      event.Feed
 	headFeed    event.Feed
 	reorgFeed   event.Feed
+	dropFeed    event.Feed
+	replaceFeed event.Feed
 	scope       event.SubscriptionScope
 	signer      types.Signer
 	mu          sync.RWMutex
@@ -293,6 +295,8 @@ type TxPool struct {
 	initDoneCh chan struct{}  // is closed once the pool is initialized (for tests)
 
 	changesSinceReorg int // A counter for how many drops we've performed in-between reorg.
+
+	replaced []common.Hash // Hashes of txs replaced since the last reorg
 }
 
 type txpoolResetRequest struct {
@@ -425,6 +429,7 @@ func (pool *TxPool) loop() {
 				}
 			}
 			pool.mu.Unlock()
+			pool.notifyDroppedTxs()
 
 		// Handle local transaction journal rotation
 		case <-journal.C:
@@ -461,6 +466,27 @@ func (pool *TxPool) SubscribeNewTxsEvent(ch chan<- NewTxsEvent) event.Subscripti
 	return pool.scope.Track(pool.txFeed.Subscribe(ch))
 }
 
+// SubscribeDroppedTxsEvent registers a subscription of DroppedTxsEvent and
+// starts sending event to the given channel.
+func (pool *TxPool) SubscribeDroppedTxsEvent(ch chan<- DroppedTxsEvent) event.Subscription {
+	return pool.scope.Track(pool.dropFeed.Subscribe(ch))
+}
+
+// SubscribeReplacedTxsEvent registers a subscription of ReplacedTxsEvent and
+// starts sending event to the given channel.
+func (pool *TxPool) SubscribeReplacedTxsEvent(ch chan<- ReplacedTxsEvent) event.Subscription {
+	return pool.scope.Track(pool.replaceFeed.Subscribe(ch))
+}
+
+// notifyDroppedTxs sends the hashes of all transactions removed from the pool
+// since the last notification to the dropped transaction subscribers. It must
+// not be called while holding the pool lock.
+func (pool *TxPool) notifyDroppedTxs() {
+	if hashes := pool.all.takeDropped(); len(hashes) > 0 {
+		pool.dropFeed.Send(DroppedTxsEvent{hashes})
+	}
+}
+
 // SubscribeNewHeadEvent registers a subscription of NewHeadEvent and
 // starts sending event to the given channel.
 func (pool *TxPool) SubscribeNewHeadEvent(ch chan<- NewTxPoolHeadEvent) event.Subscription {
@@ -485,6 +511,7 @@ func (pool *TxPool) GasPrice() *big.Int {
 // new transaction, and drops all transactions below this threshold.
 func (pool *TxPool) SetGasPrice(price *big.Int) {
 	pool.mu.Lock()
+	defer pool.notifyDroppedTxs()
 	defer pool.mu.Unlock()
 
 	old := pool.gasPrice
@@ -527,6 +554,16 @@ func (pool *TxPool) Stats() (int, int) {
 	return pool.stats()
 }
 
+// HasCapacity returns true if the pool holds fewer transactions than its
+// global pending and queued limits combined.
+func (pool *TxPool) HasCapacity() bool {
+	pool.mu.RLock()
+	defer pool.mu.RUnlock()
+
+	pending, queued := pool.stats()
+	return uint64(pending+queued) < pool.config.GlobalSlots+pool.config.GlobalQueue
+}
+
 // stats retrieves the current pool stats, namely the number of pending and the
 // number of queued (non-executable) transactions.
 func (pool *TxPool) stats() (int, int) {
@@ -788,6 +825,7 @@ func (pool *TxPool) add(tx *types.Transaction, local bool) (replaced bool, err e
 		if old != nil {
 			pool.all.Remove(old.Hash())
 			pool.priced.Removed(1)
+			pool.replaced = append(pool.replaced, old.Hash())
 			pendingReplaceMeter.Mark(1)
 		}
 		pool.all.Add(tx, isLocal)
@@ -839,6 +877,7 @@ func (pool *TxPool) enqueueTx(hash common.Hash, tx *types.Transaction, local boo
 	if old != nil {
 		pool.all.Remove(old.Hash())
 		pool.priced.Removed(1)
+		pool.replaced = append(pool.replaced, old.Hash())
 		queuedReplaceMeter.Mark(1)
 	} else {
 		// Nothing was replaced, bump the queued counter
@@ -895,6 +934,7 @@ func (pool *TxPool) promoteTx(addr common.Address, hash common.Hash, tx *types.T
 	if old != nil {
 		pool.all.Remove(old.Hash())
 		pool.priced.Removed(1)
+		pool.replaced = append(pool.replaced, old.Hash())
 		pendingReplaceMeter.Mark(1)
 	} else {
 		// Nothing was replaced, bump the pending counter
@@ -1273,6 +1313,8 @@ func (pool *TxPool) runReorg(done chan struct{}, reset *txpoolResetRequest, dirt
 
 	dropBetweenReorgHistogram.Update(int64(pool.changesSinceReorg))
 	pool.changesSinceReorg = 0 // Reset change counter
+	replaced := pool.replaced
+	pool.replaced = nil
 	pool.mu.Unlock()
 
 	if reset != nil && reset.newHead != nil {
@@ -1294,6 +1336,10 @@ func (pool *TxPool) runReorg(done chan struct{}, reset *txpoolResetRequest, dirt
 		}
 		pool.txFeed.Send(NewTxsEvent{txs})
 	}
+	if len(replaced) > 0 {
+		pool.replaceFeed.Send(ReplacedTxsEvent{replaced})
+	}
+	pool.notifyDroppedTxs()
 }
 
 // reset retrieves the current state of the blockchain and ensures the content
@@ -1807,6 +1853,8 @@ type txLookup struct {
 	lock    sync.RWMutex
 	locals  map[common.Hash]*types.Transaction
 	remotes map[common.Hash]*types.Transaction
+
+	dropped []common.Hash // Hashes removed since the last takeDropped call
 }
 
 // newTxLookup returns a new txLookup structure.
@@ -1932,6 +1980,18 @@ func (t *txLookup) Remove(hash common.Hash) {
 
 	delete(t.locals, hash)
 	delete(t.remotes, hash)
+	t.dropped = append(t.dropped, hash)
+}
+
+// takeDropped returns the hashes of all transactions removed from the lookup
+// since the last call and resets the list.
+func (t *txLookup) takeDropped() []common.Hash {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	dropped := t.dropped
+	t.dropped = nil
+	return dropped
 }
 
 // RemoteToLocals migrates the transactions belongs to the given locals to locals
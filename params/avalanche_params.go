@@ -4,6 +4,7 @@
 package params
 
 import (
+	"errors"
 	"math/big"
 
 	"github.com/ava-labs/avalanchego/utils/units"
@@ -45,3 +46,43 @@ var (
 	// This value must always remain <= MaxUint64.
 	AtomicGasLimit *big.Int = big.NewInt(100_000)
 )
+
+var (
+	errZeroAtomicTxBytesGas = errors.New("atomic tx bytes gas must be non-zero")
+	errZeroAtomicTxBaseCost = errors.New("atomic tx base cost must be non-zero")
+)
+
+// AtomicTxFeeConfig specifies the gas consumed by atomic transactions.
+type AtomicTxFeeConfig struct {
+	// TxBytesGas is the gas consumed by each byte of an atomic transaction.
+	TxBytesGas uint64 `json:"txBytesGas"`
+	// BaseCost is the gas consumed by every atomic transaction as of
+	// ApricotPhase5.
+	BaseCost uint64 `json:"baseCost"`
+	// ExportedOutputGas is the gas consumed by each output of an export
+	// transaction, which pays for the shared memory write that the output
+	// requires. It is only consumed as of [ExportedOutputGasTimestamp].
+	ExportedOutputGas uint64 `json:"exportedOutputGas,omitempty"`
+	// ExportedOutputGasTimestamp is the block timestamp as of which
+	// [ExportedOutputGas] is consumed (nil = never).
+	ExportedOutputGasTimestamp *big.Int `json:"exportedOutputGasTimestamp,omitempty"`
+}
+
+// DefaultAtomicTxFeeConfig is the atomic tx fee config of the Avalanche
+// C-Chain.
+var DefaultAtomicTxFeeConfig = AtomicTxFeeConfig{
+	TxBytesGas: 1,
+	BaseCost:   AtomicTxBaseCost,
+}
+
+// Verify returns an error if [c] would allow atomic transactions to consume
+// no gas.
+func (c AtomicTxFeeConfig) Verify() error {
+	switch {
+	case c.TxBytesGas == 0:
+		return errZeroAtomicTxBytesGas
+	case c.BaseCost == 0:
+		return errZeroAtomicTxBaseCost
+	}
+	return nil
+}
@@ -8,7 +8,9 @@ import (
 	"net/http"
 
 	"github.com/ava-labs/avalanchego/api"
+	"github.com/ava-labs/avalanchego/ids"
 	"github.com/ava-labs/avalanchego/utils/profiler"
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/log"
 )
 
@@ -75,3 +77,88 @@ func (p *Admin) SetLogLevel(r *http.Request, args *SetLogLevelArgs, reply *api.S
 	reply.Success = true
 	return nil
 }
+
+// NetworkStats returns a snapshot of the gossip state of the VM's network
+func (p *Admin) NetworkStats(r *http.Request, args *struct{}, reply *NetworkStats) error {
+	log.Info("Admin: NetworkStats called")
+
+	*reply = p.vm.network.NetworkStats()
+	return nil
+}
+
+// GossipMessageCountsReply is the response for GossipMessageCounts
+type GossipMessageCountsReply struct {
+	Counts map[string]GossipMessageCount `json:"counts"`
+}
+
+// GossipMessageCounts returns the number of gossip messages received by the
+// VM's network and the last time that one was received, keyed by message type
+func (p *Admin) GossipMessageCounts(r *http.Request, args *struct{}, reply *GossipMessageCountsReply) error {
+	log.Info("Admin: GossipMessageCounts called")
+
+	reply.Counts = p.vm.network.GossipMessageCounts()
+	return nil
+}
+
+// RegossipPendingTxsReply is the response for RegossipPendingTxs
+type RegossipPendingTxsReply struct {
+	EthTxs    int `json:"ethTxs"`
+	AtomicTxs int `json:"atomicTxs"`
+}
+
+// RegossipPendingTxs gossips every pending tx in the mempools again, even if
+// it was recently gossiped
+func (p *Admin) RegossipPendingTxs(r *http.Request, args *struct{}, reply *RegossipPendingTxsReply) error {
+	log.Info("Admin: RegossipPendingTxs called")
+
+	ethTxs, atomicTxs, err := p.vm.network.RegossipPendingTxs()
+	reply.EthTxs = ethTxs
+	reply.AtomicTxs = atomicTxs
+	return err
+}
+
+// CancelAtomicTx removes the specified transaction from the mempool if it is
+// still waiting to be issued into a block
+func (p *Admin) CancelAtomicTx(r *http.Request, args *api.JSONTxID, reply *api.SuccessResponse) error {
+	log.Info("Admin: CancelAtomicTx called", "txID", args.TxID)
+
+	if args.TxID == ids.Empty {
+		return errNilTxID
+	}
+	if err := p.vm.CancelAtomicTx(args.TxID); err != nil {
+		return err
+	}
+	reply.Success = true
+	return nil
+}
+
+// PendingGossipQueueReply is the response for PendingGossipQueue
+type PendingGossipQueueReply struct {
+	EthTxs    []common.Hash `json:"ethTxs"`
+	AtomicTxs []ids.ID      `json:"atomicTxs"`
+}
+
+// PendingGossipQueue returns the txs that are queued to be gossiped once
+// enough peers are connected
+func (p *Admin) PendingGossipQueue(r *http.Request, args *struct{}, reply *PendingGossipQueueReply) error {
+	log.Info("Admin: PendingGossipQueue called")
+
+	reply.EthTxs = p.vm.network.PendingGossipQueue()
+	reply.AtomicTxs = p.vm.network.PendingAtomicGossipQueue()
+	return nil
+}
+
+// AtomicTxSourceReply is the response for AtomicTxSource
+type AtomicTxSourceReply struct {
+	NodeID ids.ShortID `json:"nodeID"`
+	Found  bool        `json:"found"`
+}
+
+// AtomicTxSource returns the peer that gossiped the atomic tx to this node,
+// if the tx was recently issued from gossip
+func (p *Admin) AtomicTxSource(r *http.Request, args *api.JSONTxID, reply *AtomicTxSourceReply) error {
+	log.Info("Admin: AtomicTxSource called", "txID", args.TxID)
+
+	reply.NodeID, reply.Found = p.vm.network.AtomicTxSource(args.TxID)
+	return nil
+}
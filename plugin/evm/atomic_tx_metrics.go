@@ -0,0 +1,33 @@
+// (c) 2021, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package evm
+
+import (
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+// atomicTxMetrics records how long atomic tx verification takes, labelled by
+// tx type. The metrics are registered on [registry], which the VM exposes to
+// ctx.Metrics when metrics are enabled.
+type atomicTxMetrics struct {
+	importSemanticVerify metrics.Timer
+	exportSemanticVerify metrics.Timer
+	importSigRecovery    metrics.Timer
+	exportSigRecovery    metrics.Timer
+}
+
+func newAtomicTxMetrics(registry metrics.Registry) *atomicTxMetrics {
+	return &atomicTxMetrics{
+		importSemanticVerify: metrics.NewRegisteredTimer(atomicTxMetricName("semantic_verify_duration", ImportTxType), registry),
+		exportSemanticVerify: metrics.NewRegisteredTimer(atomicTxMetricName("semantic_verify_duration", ExportTxType), registry),
+		importSigRecovery:    metrics.NewRegisteredTimer(atomicTxMetricName("sig_recovery_duration", ImportTxType), registry),
+		exportSigRecovery:    metrics.NewRegisteredTimer(atomicTxMetricName("sig_recovery_duration", ExportTxType), registry),
+	}
+}
+
+// atomicTxMetricName returns the name of the metric [name] labelled by
+// [txType].
+func atomicTxMetricName(name string, txType AtomicTxType) string {
+	return "atomic/tx/" + name + "/" + txType.String()
+}
@@ -5,7 +5,6 @@ package evm
 
 import (
 	"fmt"
-	"math/big"
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
@@ -138,17 +137,18 @@ func (b *Block) Accept() error {
 		if err := b.vm.atomicTrie.Index(b.Height(), nil); err != nil {
 			return err
 		}
-		return vm.db.Commit()
+		if err := vm.db.Commit(); err != nil {
+			return err
+		}
+		// The block may have advanced the nonces of queued txs' inputs
+		vm.issueFutureTxs()
+		return nil
 	}
 
 	batchChainsAndInputs, err := mergeAtomicOps(b.atomicTxs)
 	if err != nil {
 		return err
 	}
-	for _, tx := range b.atomicTxs {
-		// Remove the accepted transaction from the mempool
-		vm.mempool.RemoveTx(tx.ID())
-	}
 
 	isBonus := bonusBlocks.Contains(b.id)
 	if err := b.indexAtomics(vm, b.Height(), b.atomicTxs, batchChainsAndInputs, isBonus); err != nil {
@@ -158,14 +158,52 @@ func (b *Block) Accept() error {
 	// the atmoic transactions to shared memory.
 	if isBonus {
 		log.Info("skipping atomic tx acceptance on bonus block", "block", b.id)
-		return vm.db.Commit()
+		if err := vm.db.Commit(); err != nil {
+			return err
+		}
+	} else {
+		batch, err := vm.db.CommitBatch()
+		if err != nil {
+			return fmt.Errorf("failed to create commit batch due to: %w", err)
+		}
+		// SharedMemory writes [batch] atomically with the atomic requests, so if
+		// Apply fails neither the requests nor [batch] are persisted and the
+		// deferred Abort discards the pending changes in [vm.db]. Note that
+		// [vm.chain] has already accepted [b.ethBlock] in memory by this point,
+		// so the error is fatal and the on-disk state is only recovered on restart.
+		if err := vm.ctx.SharedMemory.Apply(batchChainsAndInputs, batch); err != nil {
+			return fmt.Errorf("failed to apply atomic ops to shared memory for chains %s: %w", atomicOpsChainIDs(batchChainsAndInputs), err)
+		}
 	}
 
-	batch, err := vm.db.CommitBatch()
-	if err != nil {
-		return fmt.Errorf("failed to create commit batch due to: %w", err)
+	// Remove the accepted transactions from the mempool only once their atomic
+	// operations have been persisted.
+	for _, tx := range b.atomicTxs {
+		vm.mempool.RemoveTx(tx.ID())
+	}
+	vm.notifyAtomicTxsAccepted(b.atomicTxs)
+	// The block may have advanced the nonces of queued txs' inputs
+	vm.issueFutureTxs()
+
+	// Blocks accepted while bootstrapping are not announced, as peers have
+	// already accepted them.
+	if vm.bootstrapped {
+		if err := vm.network.GossipBlockAnnouncement(b.ethBlock.Hash(), b.Height()); err != nil {
+			log.Debug("failed to gossip block announcement", "blkID", b.ID(), "err", err)
+		}
+	}
+	return nil
+}
+
+// atomicOpsChainIDs returns the sorted list of chainIDs that [requests] are
+// applied to.
+func atomicOpsChainIDs(requests map[ids.ID]*atomic.Requests) []ids.ID {
+	chainIDs := make([]ids.ID, 0, len(requests))
+	for chainID := range requests {
+		chainIDs = append(chainIDs, chainID)
 	}
-	return vm.ctx.SharedMemory.Apply(batchChainsAndInputs, batch)
+	ids.SortIDs(chainIDs)
+	return chainIDs
 }
 
 // indexAtomics writes given list of atomic transactions and atomic operations to atomic repository
@@ -228,7 +266,7 @@ func (b *Block) syntacticVerify() (params.Rules, error) {
 	}
 
 	header := b.ethBlock.Header()
-	rules := b.vm.chainConfig.AvalancheRules(header.Number, new(big.Int).SetUint64(header.Time))
+	rules := b.vm.rulesAt(header.Number, header.Time)
 	return rules, b.vm.getBlockValidator(rules).SyntacticVerify(b)
 }
 
@@ -258,38 +296,42 @@ func (b *Block) verifyAtomicTxs(rules params.Rules) error {
 		return errRejectedParent
 	}
 
-	// If the tx is an atomic tx, ensure that it doesn't conflict with any of
-	// its processing ancestry.
+	if len(b.atomicTxs) == 0 {
+		return nil
+	}
+
+	// If the ancestor is unknown, then the parent failed verification when
+	// it was called.
+	// If the ancestor is rejected, then this block shouldn't be inserted
+	// into the canonical chain because the parent will be missing.
+	ancestorInf, err := b.vm.GetBlockInternal(ancestorID)
+	if err != nil {
+		return errRejectedParent
+	}
+	if blkStatus := ancestorInf.Status(); blkStatus == choices.Unknown || blkStatus == choices.Rejected {
+		return errRejectedParent
+	}
+	ancestor, ok := ancestorInf.(*Block)
+	if !ok {
+		return fmt.Errorf("expected %s, parent of %s, to be *Block but is %T", ancestor.ID(), b.ID(), ancestorInf)
+	}
+	if bonusBlocks.Contains(b.id) {
+		log.Info("skipping atomic tx verification on bonus block", "block", b.id)
+		return nil
+	}
+
+	if err := b.vm.VerifyAtomicTxs(b.atomicTxs, ancestor, b.ethBlock.BaseFee(), rules); err != nil {
+		return fmt.Errorf("invalid block due to failed semanatic verify: %w at height %d", err, b.Height())
+	}
+
+	// Ensure that the atomic txs in the block don't conflict with each other.
 	inputs := &ids.Set{}
 	for _, atomicTx := range b.atomicTxs {
-		// If the ancestor is unknown, then the parent failed verification when
-		// it was called.
-		// If the ancestor is rejected, then this block shouldn't be inserted
-		// into the canonical chain because the parent will be missing.
-		ancestorInf, err := b.vm.GetBlockInternal(ancestorID)
-		if err != nil {
-			return errRejectedParent
-		}
-		if blkStatus := ancestorInf.Status(); blkStatus == choices.Unknown || blkStatus == choices.Rejected {
-			return errRejectedParent
-		}
-		ancestor, ok := ancestorInf.(*Block)
-		if !ok {
-			return fmt.Errorf("expected %s, parent of %s, to be *Block but is %T", ancestor.ID(), b.ID(), ancestorInf)
-		}
-		if bonusBlocks.Contains(b.id) {
-			log.Info("skipping atomic tx verification on bonus block", "block", b.id)
-		} else {
-			utx := atomicTx.UnsignedAtomicTx
-			if err := utx.SemanticVerify(b.vm, atomicTx, ancestor, b.ethBlock.BaseFee(), rules); err != nil {
-				return fmt.Errorf("invalid block due to failed semanatic verify: %w at height %d", err, b.Height())
-			}
-			txInputs := utx.InputUTXOs()
-			if inputs.Overlaps(txInputs) {
-				return errConflictingAtomicInputs
-			}
-			inputs.Union(txInputs)
+		txInputs := atomicTx.UnsignedAtomicTx.InputUTXOs()
+		if inputs.Overlaps(txInputs) {
+			return errConflictingAtomicInputs
 		}
+		inputs.Union(txInputs)
 	}
 
 	return nil
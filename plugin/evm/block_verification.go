@@ -452,7 +452,7 @@ func (blockValidatorPhase4) SyntacticVerify(b *Block) error {
 	for _, atomicTx := range b.atomicTxs {
 		// We perform this check manually here to avoid the overhead of having to
 		// reparse the atomicTx in `CalcExtDataGasUsed`.
-		gasUsed, err := atomicTx.GasUsed(false)
+		gasUsed, err := atomicTx.GasUsed(b.vm.chainConfig.AtomicTxFeeConfigAt(new(big.Int).SetUint64(blockTimestamp)), false)
 		if err != nil {
 			return err
 		}
@@ -583,7 +583,7 @@ func (blockValidatorPhase5) SyntacticVerify(b *Block) error {
 	for _, atomicTx := range b.atomicTxs {
 		// We perform this check manually here to avoid the overhead of having to
 		// reparse the atomicTx in `CalcExtDataGasUsed`.
-		gasUsed, err := atomicTx.GasUsed(true)
+		gasUsed, err := atomicTx.GasUsed(b.vm.chainConfig.AtomicTxFeeConfigAt(new(big.Int).SetUint64(blockTimestamp)), true)
 		if err != nil {
 			return err
 		}
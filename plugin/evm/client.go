@@ -23,6 +23,7 @@ type Client interface {
 	IssueTx(ctx context.Context, txBytes []byte) (ids.ID, error)
 	GetAtomicTxStatus(ctx context.Context, txID ids.ID) (Status, error)
 	GetAtomicTx(ctx context.Context, txID ids.ID) ([]byte, error)
+	CancelAtomicTx(ctx context.Context, txID ids.ID) error
 	GetAtomicUTXOs(ctx context.Context, addrs []string, sourceChain string, limit uint32, startAddress, startUTXOID string) ([][]byte, api.Index, error)
 	ListAddresses(ctx context.Context, userPass api.UserPass) ([]string, error)
 	ExportKey(ctx context.Context, userPass api.UserPass, addr string) (string, string, error)
@@ -93,6 +94,14 @@ func (c *client) GetAtomicTx(ctx context.Context, txID ids.ID) ([]byte, error) {
 	return formatting.Decode(formatting.Hex, res.Tx)
 }
 
+// CancelAtomicTx removes [txID] from the mempool if it is still waiting to be
+// issued into a block
+func (c *client) CancelAtomicTx(ctx context.Context, txID ids.ID) error {
+	return c.adminRequester.SendRequest(ctx, "cancelAtomicTx", &api.JSONTxID{
+		TxID: txID,
+	}, &api.SuccessResponse{})
+}
+
 // GetAtomicUTXOs returns the byte representation of the atomic UTXOs controlled by [addresses]
 // from [sourceChain]
 func (c *client) GetAtomicUTXOs(ctx context.Context, addrs []string, sourceChain string, limit uint32, startAddress, startUTXOID string) ([][]byte, api.Index, error) {
@@ -17,6 +17,18 @@ var Codec codec.Manager
 
 func init() {
 	Codec = codec.NewDefaultManager()
+	c, err := newAtomicTxLinearCodec()
+	if err != nil {
+		panic(err)
+	}
+	if err := Codec.RegisterCodec(codecVersion, c); err != nil {
+		panic(err)
+	}
+}
+
+// newAtomicTxLinearCodec returns a linear codec with the types of the atomic
+// txs registered, which may be registered in [Codec] under any codec version.
+func newAtomicTxLinearCodec() (linearcodec.Codec, error) {
 	c := linearcodec.NewDefault()
 
 	errs := wrappers.Errs{}
@@ -33,12 +45,8 @@ func init() {
 		c.RegisterType(&secp256k1fx.Credential{}),
 		c.RegisterType(&secp256k1fx.Input{}),
 		c.RegisterType(&secp256k1fx.OutputOwners{}),
-		Codec.RegisterCodec(codecVersion, c),
 	)
-
-	if errs.Errored() {
-		panic(errs.Err)
-	}
+	return c, errs.Err
 }
 
 // extractAtomicTxs returns the atomic transactions in [atomicTxBytes] if
@@ -5,8 +5,12 @@ package evm
 
 import (
 	"encoding/json"
+	"fmt"
+	"runtime"
 	"time"
 
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/utils/constants"
 	"github.com/ava-labs/coreth/eth"
 	"github.com/spf13/cast"
 )
@@ -26,6 +30,13 @@ const (
 	defaultContinuousProfilerMaxFiles  = 5
 	defaultTxRegossipFrequency         = 1 * time.Minute
 	defaultTxRegossipMaxSize           = 15
+	defaultMaxGossipMsgsPerBlock       = 0 // Default to no maximum on the number of gossip messages sent at once
+	defaultGossipActivationJitter      = 10 * time.Second
+	defaultGossipFanout                = 0 // Default to gossiping txs to every connected peer
+	defaultGossipMode                  = GossipModePush
+	defaultInboundGossipQueueSize      = 1024
+	defaultMinGossipPeersTimeout       = 1 * time.Minute
+	defaultSecpCacheSize               = 1024
 	defaultLogLevel                    = "info"
 )
 
@@ -43,6 +54,30 @@ type Duration struct {
 	time.Duration
 }
 
+// GossipMode is how eth txs are gossiped to peers.
+type GossipMode string
+
+const (
+	// GossipModePush gossips the txs themselves.
+	GossipModePush GossipMode = "push"
+	// GossipModePull gossips only the hashes of the txs, and peers request
+	// the txs that they don't have.
+	GossipModePull GossipMode = "pull"
+	// GossipModeHybrid gossips the txs to a random subset of the peers, sized
+	// as with GossipFanout, and only their hashes to the other peers.
+	GossipModeHybrid GossipMode = "hybrid"
+)
+
+// Valid returns true if [m] is a known gossip mode.
+func (m GossipMode) Valid() bool {
+	switch m {
+	case GossipModePush, GossipModePull, GossipModeHybrid:
+		return true
+	default:
+		return false
+	}
+}
+
 // Config ...
 type Config struct {
 	// Coreth APIs
@@ -91,6 +126,139 @@ type Config struct {
 	RemoteTxGossipOnlyEnabled bool     `json:"remote-tx-gossip-only-enabled"`
 	TxRegossipFrequency       Duration `json:"tx-regossip-frequency"`
 	TxRegossipMaxSize         int      `json:"tx-regossip-max-size"`
+	// MaxGossipMsgsPerBlock is the maximum number of AppGossip messages sent
+	// each time queued eth txs are gossiped. Any txs that do not fit are
+	// gossiped in the next gossip cycle. If 0, no maximum is applied.
+	MaxGossipMsgsPerBlock int `json:"max-gossip-msgs-per-block"`
+	// MempoolBloomEnabled enables periodically gossiping a bloom filter of the
+	// eth txs in the mempool, and sending eth txs only to the peers whose
+	// latest bloom filter does not contain them.
+	MempoolBloomEnabled bool `json:"mempool-bloom-enabled"`
+	// GossipActivationJitter is the size of the window after the gossip
+	// activation time in which this node starts gossiping. The delay is derived
+	// from the node ID so that nodes do not all begin gossiping at once.
+	GossipActivationJitter Duration `json:"gossip-activation-jitter"`
+	// DevModeGossipAlwaysActive ignores the gossip activation time, so that
+	// txs are gossiped before the fork on local networks. It may not be
+	// enabled on mainnet.
+	DevModeGossipAlwaysActive bool `json:"dev-mode-gossip-always-active"`
+	// GossipRecentCacheBytes bounds the caches of recently gossiped txs by the
+	// approximate number of bytes they hold rather than by their number of
+	// entries. If 0, the caches are bounded by their number of entries.
+	GossipRecentCacheBytes int `json:"gossip-recent-cache-bytes"`
+	// GossipTxTTL is how long a gossiped tx is not gossiped again, even if it
+	// was evicted from the caches of recently gossiped txs. If 0, txs may be
+	// gossiped again as soon as they are evicted.
+	GossipTxTTL Duration `json:"gossip-tx-ttl"`
+	// TxReplacedGossipEnabled enables notifying peers of the hashes of eth txs
+	// that were replaced in the mempool by txs with a higher fee.
+	TxReplacedGossipEnabled bool `json:"tx-replaced-gossip-enabled"`
+	// BlockAnnouncementGossipEnabled enables notifying peers of each block
+	// accepted by this node, so that peers that are behind can discover it.
+	BlockAnnouncementGossipEnabled bool `json:"block-announcement-gossip-enabled"`
+	// GossipFanout enables gossiping each batch of txs to a random subset of
+	// the connected peers instead of to all of them. The subset holds the
+	// square root of the number of peers, but never fewer than GossipFanout
+	// peers. If 0, txs are gossiped to every peer.
+	GossipFanout int `json:"gossip-fanout"`
+	// MaxGossipTxSize is the size in bytes of the largest eth tx that is
+	// gossiped. Larger txs are not gossiped, but are still included in blocks
+	// built by this node. If 0, eth txs of any size are gossiped.
+	MaxGossipTxSize int `json:"max-gossip-tx-size"`
+	// MinGossipGasPrice is the gas price in wei below which eth txs are not
+	// gossiped, as they are unlikely to be included in a block soon. The gas
+	// fee cap of dynamic fee txs is compared to it. Txs issued to this node
+	// are gossiped regardless. If 0, txs of any gas price are gossiped.
+	MinGossipGasPrice uint64 `json:"min-gossip-gas-price"`
+	// LowPriorityEthGossip enables gossiping eth txs with a lower priority
+	// than atomic txs, if supported by the AppSender, so that atomic txs are
+	// not delayed behind bulk eth tx gossip.
+	LowPriorityEthGossip bool `json:"low-priority-eth-gossip"`
+	// MaxPendingGossipBytes is the total size of the eth txs buffered to be
+	// gossiped above which they are gossiped right away, rather than waiting
+	// for the next gossip cycle. If 0, the buffer is only bounded by the
+	// gossip cycle.
+	MaxPendingGossipBytes int `json:"max-pending-gossip-bytes"`
+	// EthTxAnnouncementsEnabled enables gossiping only the hashes of eth txs
+	// to peers that support it, which then request the txs that they don't
+	// have, rather than gossiping the txs themselves.
+	//
+	// Deprecated: use GossipMode "pull", which this sets if GossipMode is
+	// "push".
+	EthTxAnnouncementsEnabled bool `json:"eth-tx-announcements-enabled"`
+	// GossipMode is how eth txs are gossiped: "push", "pull", or "hybrid".
+	// Peers that don't support requesting txs are always pushed the txs.
+	GossipMode GossipMode `json:"gossip-mode"`
+	// InboundGossipWorkers is the number of goroutines that handle the gossip
+	// received from peers, so that slow handling does not block the caller.
+	// The gossip of each peer is handled by a single worker, in the order
+	// that it was received. If 0, gossip is handled by the caller.
+	InboundGossipWorkers int `json:"inbound-gossip-workers"`
+	// InboundGossipQueueSize is the number of gossip messages queued for each
+	// of the InboundGossipWorkers, above which the oldest queued message is
+	// dropped.
+	InboundGossipQueueSize int `json:"inbound-gossip-queue-size"`
+	// TxsAckEnabled enables acknowledging the eth txs gossiped by peers that
+	// were added to the mempool, so that those peers do not send them again.
+	TxsAckEnabled bool `json:"txs-ack-enabled"`
+	// MinGossipPeers is the number of peers that must be connected before txs
+	// are gossiped. Until then, txs are queued and gossiped once enough peers
+	// connect. If 0, txs are gossiped whenever any peer is connected.
+	MinGossipPeers int `json:"min-gossip-peers"`
+	// MinGossipPeersTimeout is how long txs are queued waiting for
+	// MinGossipPeers peers to connect, after which they are gossiped to the
+	// peers that are connected. If 0, txs are queued until enough peers
+	// connect.
+	MinGossipPeersTimeout Duration `json:"min-gossip-peers-timeout"`
+	// MaxOutstandingAppRequests is the maximum number of AppRequests this node
+	// may be waiting on a response to. Further requests are refused until a
+	// response is received or a request fails. If 0, there is no maximum.
+	MaxOutstandingAppRequests int `json:"max-outstanding-app-requests"`
+	// AppRequestTimeout is how long this node waits for a response to an
+	// AppRequest before the request is considered failed, freeing it and
+	// resending it to another peer if [ReliableAtomicGossip]. If 0, requests
+	// only fail when the engine reports them as failed.
+	AppRequestTimeout Duration `json:"app-request-timeout"`
+	// ReliableAtomicGossip enables sending atomic txs to a subset of the
+	// connected peers with AppRequests rather than with AppGossip. A request
+	// that fails is resent to another peer while the tx is still pending.
+	ReliableAtomicGossip bool `json:"reliable-atomic-gossip"`
+	// MaxGossipTxsPerSender is the maximum number of eth txs from a single
+	// sender that are added to the mempool from one gossip message. Further
+	// txs from the sender in the message are dropped. If 0, there is no
+	// maximum.
+	MaxGossipTxsPerSender int `json:"max-gossip-txs-per-sender"`
+	// GossipPeerAllowlist is a list of the nodeIDs that this node gossips
+	// with. Gossip from other peers is dropped and nothing is gossiped to
+	// them. If empty, every peer is allowed.
+	GossipPeerAllowlist []string `json:"gossip-peer-allowlist"`
+	// GossipPeerDenylist is a list of the nodeIDs that this node does not
+	// gossip with, even if they are in [GossipPeerAllowlist].
+	GossipPeerDenylist []string `json:"gossip-peer-denylist"`
+	// GossipFromValidatorsOnly makes this node drop gossip from peers that
+	// are not current validators of this chain's subnet.
+	GossipFromValidatorsOnly bool `json:"gossip-from-validators-only"`
+
+	// Atomic Settings
+	//
+	// AllowedImportAssets is a list of the non-AVAX assetIDs that this node
+	// will accept import txs for into its mempool. If empty, any asset may be
+	// imported. This does not affect the verification of blocks.
+	AllowedImportAssets []string `json:"allowed-import-assets"`
+	// SecpCacheSize is the number of public keys recovered from atomic tx
+	// signatures to cache.
+	SecpCacheSize int `json:"secp-cache-size"`
+	// AtomicTxVerifyWorkers is the maximum number of goroutines used to
+	// verify the atomic txs of a block concurrently. Must be at least 1.
+	AtomicTxVerifyWorkers int `json:"atomic-tx-verify-workers"`
+	// VerifyExportTxCredentials enables checking that the credentials of the
+	// export txs built by this node match their inputs before they are issued.
+	VerifyExportTxCredentials bool `json:"verify-export-tx-credentials"`
+	// AllowFutureNonceAtomicInputs queues the atomic txs issued locally to
+	// this node whose EVM inputs have a nonce ahead of their account, rather
+	// than rejecting them. Such txs gossiped by peers are still rejected. Queued txs are issued to the mempool once the nonces of
+	// their inputs are reached.
+	AllowFutureNonceAtomicInputs bool `json:"allow-future-nonce-atomic-inputs"`
 
 	// Log level
 	LogLevel string `json:"log-level"`
@@ -105,6 +273,43 @@ func (c Config) EthBackendSettings() eth.Settings {
 	return eth.Settings{MaxBlocksPerRequest: c.MaxBlocksPerRequest}
 }
 
+// AllowedImportAssetIDs parses [AllowedImportAssets] into a set of assetIDs.
+func (c Config) AllowedImportAssetIDs() (ids.Set, error) {
+	assetIDs := ids.NewSet(len(c.AllowedImportAssets))
+	for _, assetIDStr := range c.AllowedImportAssets {
+		assetID, err := ids.FromString(assetIDStr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse allowed import asset %q: %w", assetIDStr, err)
+		}
+		assetIDs.Add(assetID)
+	}
+	return assetIDs, nil
+}
+
+// GossipPeerAllowlistIDs parses [GossipPeerAllowlist] into a set of nodeIDs.
+func (c Config) GossipPeerAllowlistIDs() (ids.ShortSet, error) {
+	return parseNodeIDs("gossip peer allowlist", c.GossipPeerAllowlist)
+}
+
+// GossipPeerDenylistIDs parses [GossipPeerDenylist] into a set of nodeIDs.
+func (c Config) GossipPeerDenylistIDs() (ids.ShortSet, error) {
+	return parseNodeIDs("gossip peer denylist", c.GossipPeerDenylist)
+}
+
+// parseNodeIDs parses the nodeIDs in [nodeIDStrs], which are read from the
+// config entry [name].
+func parseNodeIDs(name string, nodeIDStrs []string) (ids.ShortSet, error) {
+	nodeIDs := ids.NewShortSet(len(nodeIDStrs))
+	for _, nodeIDStr := range nodeIDStrs {
+		nodeID, err := ids.ShortFromPrefixedString(nodeIDStr, constants.NodeIDPrefix)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s entry %q: %w", name, nodeIDStr, err)
+		}
+		nodeIDs.Add(nodeID)
+	}
+	return nodeIDs, nil
+}
+
 func (c *Config) SetDefaults() {
 	c.EnabledEthAPIs = defaultEnabledAPIs
 	c.RPCGasCap = defaultRpcGasCap
@@ -121,6 +326,14 @@ func (c *Config) SetDefaults() {
 	c.SnapshotAsync = defaultSnapshotAsync
 	c.TxRegossipFrequency.Duration = defaultTxRegossipFrequency
 	c.TxRegossipMaxSize = defaultTxRegossipMaxSize
+	c.MaxGossipMsgsPerBlock = defaultMaxGossipMsgsPerBlock
+	c.GossipActivationJitter.Duration = defaultGossipActivationJitter
+	c.GossipFanout = defaultGossipFanout
+	c.GossipMode = defaultGossipMode
+	c.InboundGossipQueueSize = defaultInboundGossipQueueSize
+	c.MinGossipPeersTimeout.Duration = defaultMinGossipPeersTimeout
+	c.SecpCacheSize = defaultSecpCacheSize
+	c.AtomicTxVerifyWorkers = runtime.NumCPU()
 	c.LogLevel = defaultLogLevel
 }
 
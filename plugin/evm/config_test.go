@@ -10,6 +10,8 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/assert"
+
+	"github.com/ava-labs/avalanchego/ids"
 )
 
 func TestUnmarshalConfig(t *testing.T) {
@@ -37,6 +39,12 @@ func TestUnmarshalConfig(t *testing.T) {
 			Config{APIMaxDuration: Duration{5 * time.Second}, ContinuousProfilerFrequency: Duration{5 * time.Second}},
 			false,
 		},
+		{
+			"gossip mode parsed",
+			[]byte(`{"gossip-mode": "hybrid"}`),
+			Config{GossipMode: GossipModeHybrid},
+			false,
+		},
 		{
 			"bad durations",
 			[]byte(`{"api-max-duration": "bad-duration"}`),
@@ -58,3 +66,16 @@ func TestUnmarshalConfig(t *testing.T) {
 		})
 	}
 }
+
+func TestConfigAllowedImportAssetIDs(t *testing.T) {
+	assetID := ids.GenerateTestID()
+	config := Config{AllowedImportAssets: []string{assetID.String()}}
+	assetIDs, err := config.AllowedImportAssetIDs()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, assetIDs.Len())
+	assert.True(t, assetIDs.Contains(assetID))
+
+	config = Config{AllowedImportAssets: []string{"not an asset ID"}}
+	_, err = config.AllowedImportAssetIDs()
+	assert.Error(t, err)
+}
@@ -4,8 +4,10 @@
 package evm
 
 import (
+	"encoding/json"
 	"fmt"
 	"math/big"
+	"time"
 
 	"github.com/ava-labs/coreth/core/state"
 	"github.com/ava-labs/coreth/params"
@@ -22,6 +24,7 @@ import (
 	"github.com/ava-labs/avalanchego/vms/secp256k1fx"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
 )
 
 // UnsignedExportTx is an unsigned ExportTx
@@ -39,6 +42,48 @@ type UnsignedExportTx struct {
 	ExportedOutputs []*avax.TransferableOutput `serialize:"true" json:"exportedOutputs"`
 }
 
+// Type returns [ExportTxType]
+func (tx *UnsignedExportTx) Type() AtomicTxType { return ExportTxType }
+
+// exportedOutputJSON is the JSON form of an exported output. Exported outputs
+// are always secp256k1fx transfer outputs, which allows [Out] to be decoded
+// into a concrete type.
+type exportedOutputJSON struct {
+	AssetID ids.ID                      `json:"assetID"`
+	FxID    ids.ID                      `json:"fxID"`
+	Out     *secp256k1fx.TransferOutput `json:"output"`
+}
+
+// UnmarshalJSON decodes the JSON form of an export tx, which is produced by
+// the default encoding of its fields: ids are CB58 strings, addresses are hex
+// strings, and amounts and nonces are numbers.
+func (tx *UnsignedExportTx) UnmarshalJSON(b []byte) error {
+	type unsignedExportTx UnsignedExportTx
+	raw := struct {
+		*unsignedExportTx
+		ExportedOutputs []*exportedOutputJSON `json:"exportedOutputs"`
+	}{unsignedExportTx: (*unsignedExportTx)(tx)}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+
+	tx.ExportedOutputs = nil
+	if raw.ExportedOutputs != nil {
+		tx.ExportedOutputs = make([]*avax.TransferableOutput, len(raw.ExportedOutputs))
+	}
+	for i, out := range raw.ExportedOutputs {
+		if out == nil || out.Out == nil {
+			return fmt.Errorf("exported output %d is missing its output", i)
+		}
+		tx.ExportedOutputs[i] = &avax.TransferableOutput{
+			Asset: avax.Asset{ID: out.AssetID},
+			FxID:  out.FxID,
+			Out:   out.Out,
+		}
+	}
+	return nil
+}
+
 // InputUTXOs returns a set of all the hash(address:nonce) exporting funds.
 func (tx *UnsignedExportTx) InputUTXOs() ids.Set {
 	set := ids.NewSet(len(tx.Ins))
@@ -59,15 +104,8 @@ func (tx *UnsignedExportTx) Verify(
 	ctx *snow.Context,
 	rules params.Rules,
 ) error {
-	switch {
-	case tx == nil:
-		return errNilTx
-	case len(tx.ExportedOutputs) == 0:
-		return errNoExportOutputs
-	case tx.NetworkID != ctx.NetworkID:
-		return errWrongNetworkID
-	case ctx.ChainID != tx.BlockchainID:
-		return errWrongBlockchainID
+	if err := tx.verifyChainIDs(ctx.NetworkID, ctx.ChainID); err != nil {
+		return err
 	}
 
 	// Make sure that the tx has a valid peer chain ID
@@ -83,20 +121,93 @@ func (tx *UnsignedExportTx) Verify(
 		}
 	}
 
+	return tx.verifyContents(ctx.AVAXAssetID, rules)
+}
+
+// VerifyStateless runs the checks of Verify that don't require any chain or
+// VM state, along with the signature checks of [creds] against the inputs of
+// the tx. Since whether a peer chain is on the same subnet can't be checked
+// without the P-chain, the tx must instead export to [destChainID].
+//
+// This allows clients to check a tx before issuing it. Passing this check
+// doesn't guarantee that the tx will be accepted, as its inputs are only
+// checked against the chain state in SemanticVerify.
+func (tx *UnsignedExportTx) VerifyStateless(
+	networkID uint32,
+	chainID ids.ID,
+	destChainID ids.ID,
+	avaxAssetID ids.ID,
+	rules params.Rules,
+	creds []verify.Verifiable,
+) error {
+	if err := tx.verifyChainIDs(networkID, chainID); err != nil {
+		return err
+	}
+	if tx.DestinationChain != destChainID {
+		return errWrongChainID
+	}
+	if err := tx.verifyContents(avaxAssetID, rules); err != nil {
+		return err
+	}
+	return tx.verifySignatures(&crypto.FactorySECP256K1R{}, creds, metrics.NilTimer{})
+}
+
+// verifyChainIDs verifies that [tx] is well formed and was issued on the
+// chain [chainID] of the network [networkID].
+func (tx *UnsignedExportTx) verifyChainIDs(networkID uint32, chainID ids.ID) error {
+	switch {
+	case tx == nil:
+		return errNilTx
+	case len(tx.ExportedOutputs) == 0:
+		return errNoExportOutputs
+	// An export tx without inputs could never pay for its outputs and fee, so
+	// it has always failed the flow check in SemanticVerify. Rejecting it here
+	// does not change which txs are valid under any rules.
+	case len(tx.Ins) == 0:
+		return errNoExportInputs
+	// Every chain of a network shares its network ID, so this also ensures
+	// that the tx is for the network of [tx.DestinationChain].
+	case tx.NetworkID != networkID:
+		return fmt.Errorf("%w: expected %d but found %d", errWrongNetworkID, networkID, tx.NetworkID)
+	case chainID != tx.BlockchainID:
+		return errWrongBlockchainID
+	// An export to this chain has always failed the peer chain checks below,
+	// so rejecting it explicitly does not change which txs are valid.
+	case tx.DestinationChain == tx.BlockchainID:
+		return errWrongChainID
+	}
+	return nil
+}
+
+// verifyContents verifies the inputs, outputs, and gas of [tx], where
+// [avaxAssetID] is the ID of this network's AVAX asset.
+func (tx *UnsignedExportTx) verifyContents(avaxAssetID ids.ID, rules params.Rules) error {
 	for _, in := range tx.Ins {
 		if err := in.Verify(); err != nil {
 			return err
 		}
 	}
 
+	// Outputs whose amounts overflow when summed per asset have always failed
+	// the flow check in SemanticVerify, so they are rejected here instead.
+	exported := make(map[ids.ID]uint64, 1)
 	for _, out := range tx.ExportedOutputs {
 		if err := out.Verify(); err != nil {
 			return err
 		}
+		if err := verifyOutputOwnerAddrs(out, rules); err != nil {
+			return err
+		}
+		// The P-chain only supports this network's AVAX asset
 		assetID := out.AssetID()
-		if assetID != ctx.AVAXAssetID && tx.DestinationChain == constants.PlatformChainID {
-			return errWrongChainID
+		if assetID != avaxAssetID && tx.DestinationChain == constants.PlatformChainID {
+			return fmt.Errorf("%w: expected %s but found %s", errWrongAVAXAssetID, avaxAssetID, assetID)
 		}
+		total, err := math.Add64(exported[assetID], out.Output().Amount())
+		if err != nil {
+			return fmt.Errorf("%w of asset %s: %s", errOverflowExportedOutputs, assetID, err)
+		}
+		exported[assetID] = total
 	}
 	if !avax.IsSortedTransferableOutputs(tx.ExportedOutputs, Codec) {
 		return errOutputsNotSorted
@@ -105,11 +216,11 @@ func (tx *UnsignedExportTx) Verify(
 		return errInputsNotSortedUnique
 	}
 
-	return nil
+	return verifyAtomicTxGas(tx, rules)
 }
 
-func (tx *UnsignedExportTx) GasUsed(fixedFee bool) (uint64, error) {
-	byteCost := calcBytesCost(len(tx.UnsignedBytes()))
+func (tx *UnsignedExportTx) GasUsed(fees params.AtomicTxFeeConfig, fixedFee bool) (uint64, error) {
+	byteCost := calcBytesCost(fees, len(tx.UnsignedBytes()))
 	numSigs := uint64(len(tx.Ins))
 	sigCost, err := math.Mul64(numSigs, secp256k1fx.CostPerSignature)
 	if err != nil {
@@ -119,6 +230,14 @@ func (tx *UnsignedExportTx) GasUsed(fixedFee bool) (uint64, error) {
 	if err != nil {
 		return 0, err
 	}
+	outputCost, err := math.Mul64(uint64(len(tx.ExportedOutputs)), fees.ExportedOutputGas)
+	if err != nil {
+		return 0, err
+	}
+	cost, err = math.Add64(cost, outputCost)
+	if err != nil {
+		return 0, err
+	}
 	if fixedFee {
 		cost, err = math.Add64(cost, params.AtomicTxBaseCost)
 		if err != nil {
@@ -158,33 +277,65 @@ func (tx *UnsignedExportTx) Burned(assetID ids.ID) (uint64, error) {
 
 // SemanticVerify this transaction is valid.
 func (tx *UnsignedExportTx) SemanticVerify(
+	vm *VM,
+	stx *Tx,
+	parent *Block,
+	baseFee *big.Int,
+	rules params.Rules,
+) error {
+	return tx.SemanticVerifyWithOptions(vm, stx, parent, baseFee, rules, SemanticVerifyOptions{})
+}
+
+// SemanticVerifyWithOptions verifies this transaction is valid, adjusting the
+// checks performed according to [opts].
+func (tx *UnsignedExportTx) SemanticVerifyWithOptions(
 	vm *VM,
 	stx *Tx,
 	_ *Block,
 	baseFee *big.Int,
 	rules params.Rules,
+	opts SemanticVerifyOptions,
 ) error {
+	defer vm.atomicTxMetrics.exportSemanticVerify.UpdateSince(time.Now())
+
 	if err := tx.Verify(vm.ctx, rules); err != nil {
 		return err
 	}
 
 	// Check the transaction consumes and produces the right amounts
-	fc := avax.NewFlowChecker()
+	fc, err := tx.flowChecker(stx, vm.ctx.AVAXAssetID, baseFee, rules, opts.FeeCache)
+	if err != nil {
+		return err
+	}
+	if !opts.SkipFlowCheck {
+		if err := fc.Verify(); err != nil {
+			return fmt.Errorf("export tx flow check failed due to: %w", err)
+		}
+	}
+
+	return tx.verifyCredentials(vm, stx)
+}
+
+// flowChecker returns a flow checker that consumes the inputs of [tx] and
+// produces its outputs along with the fee that [stx] must pay at [baseFee].
+// The fee is served from [feeCache] if it's cached there.
+func (tx *UnsignedExportTx) flowChecker(stx *Tx, avaxAssetID ids.ID, baseFee *big.Int, rules params.Rules, feeCache *atomicFeeCache) (*assetFlowChecker, error) {
+	fc := newAssetFlowChecker()
 	switch {
 	// Apply dynamic fees to export transactions as of Apricot Phase 3
 	case rules.IsApricotPhase3:
-		gasUsed, err := stx.GasUsed(rules.IsApricotPhase5)
+		gasUsed, err := stx.GasUsed(rules.AtomicTxFeeConfig, rules.IsApricotPhase5)
 		if err != nil {
-			return err
+			return nil, err
 		}
-		txFee, err := calculateDynamicFee(gasUsed, baseFee)
+		txFee, err := feeCache.calculateDynamicFee(gasUsed, baseFee)
 		if err != nil {
-			return err
+			return nil, err
 		}
-		fc.Produce(vm.ctx.AVAXAssetID, txFee)
+		fc.Produce(avaxAssetID, txFee)
 	// Apply fees to export transactions before Apricot Phase 3
 	default:
-		fc.Produce(vm.ctx.AVAXAssetID, params.AvalancheAtomicTxFee)
+		fc.Produce(avaxAssetID, params.AvalancheAtomicTxFee)
 	}
 	for _, out := range tx.ExportedOutputs {
 		fc.Produce(out.AssetID(), out.Output().Amount())
@@ -192,17 +343,29 @@ func (tx *UnsignedExportTx) SemanticVerify(
 	for _, in := range tx.Ins {
 		fc.Consume(in.AssetID, in.Amount)
 	}
+	return fc, nil
+}
 
-	if err := fc.Verify(); err != nil {
-		return fmt.Errorf("export tx flow check failed due to: %w", err)
-	}
+// verifyCredentials verifies that each credential of [stx] is a valid
+// signature of the input it spends by the input's address.
+func (tx *UnsignedExportTx) verifyCredentials(vm *VM, stx *Tx) error {
+	return tx.verifySignatures(&vm.secpFactory, stx.Creds, vm.atomicTxMetrics.exportSigRecovery)
+}
 
-	if len(tx.Ins) != len(stx.Creds) {
-		return fmt.Errorf("export tx contained mismatched number of inputs/credentials (%d vs. %d)", len(tx.Ins), len(stx.Creds))
+// verifySignatures verifies that each of [creds] is a valid signature of the
+// input it spends by the input's address. Public keys are recovered with
+// [factory] and the time spent recovering them is recorded in [sigRecovery].
+func (tx *UnsignedExportTx) verifySignatures(factory *crypto.FactorySECP256K1R, creds []verify.Verifiable, sigRecovery metrics.Timer) error {
+	if len(tx.Ins) != len(creds) {
+		return fmt.Errorf("%w: export tx contained mismatched number of inputs/credentials (%d vs. %d)", errSignatureInputsMismatch, len(tx.Ins), len(creds))
 	}
 
 	for i, input := range tx.Ins {
-		cred, ok := stx.Creds[i].(*secp256k1fx.Credential)
+		// Guard the index even though the lengths were checked above
+		if i >= len(creds) {
+			return fmt.Errorf("%w: export tx input %d has no credential", errSignatureInputsMismatch, i)
+		}
+		cred, ok := creds[i].(*secp256k1fx.Credential)
 		if !ok {
 			return fmt.Errorf("expected *secp256k1fx.Credential but got %T", cred)
 		}
@@ -213,7 +376,12 @@ func (tx *UnsignedExportTx) SemanticVerify(
 		if len(cred.Sigs) != 1 {
 			return fmt.Errorf("expected one signature for EVM Input Credential, but found: %d", len(cred.Sigs))
 		}
-		pubKeyIntf, err := vm.secpFactory.RecoverPublicKey(tx.UnsignedBytes(), cred.Sigs[0][:])
+		if err := verifyLowS(cred.Sigs[0]); err != nil {
+			return fmt.Errorf("export tx input %d: %w", i, err)
+		}
+		recoverStart := time.Now()
+		pubKeyIntf, err := factory.RecoverPublicKey(tx.UnsignedBytes(), cred.Sigs[0][:])
+		sigRecovery.UpdateSince(recoverStart)
 		if err != nil {
 			return err
 		}
@@ -222,8 +390,8 @@ func (tx *UnsignedExportTx) SemanticVerify(
 			// This should never happen
 			return fmt.Errorf("expected *crypto.PublicKeySECP256K1R but got %T", pubKeyIntf)
 		}
-		if input.Address != PublicKeyToEthAddress(pubKey) {
-			return errPublicKeySignatureMismatch
+		if signer := PublicKeyToEthAddress(pubKey); input.Address != signer {
+			return fmt.Errorf("%w: export tx input %d expected a signature from %s but found %s", errPublicKeySignatureMismatch, i, input.Address, signer)
 		}
 	}
 
@@ -232,9 +400,17 @@ func (tx *UnsignedExportTx) SemanticVerify(
 
 // AtomicOps returns the atomic operations for this transaction.
 func (tx *UnsignedExportTx) AtomicOps() (ids.ID, *atomic.Requests, error) {
+	chainID, requests, _, err := tx.AtomicOpsWithUTXOs()
+	return chainID, requests, err
+}
+
+// AtomicOpsWithUTXOs returns the atomic operations for this transaction along
+// with the IDs of the UTXOs it produces, in the order of [ExportedOutputs].
+func (tx *UnsignedExportTx) AtomicOpsWithUTXOs() (ids.ID, *atomic.Requests, []ids.ID, error) {
 	txID := tx.ID()
 
 	elems := make([]*atomic.Element, len(tx.ExportedOutputs))
+	utxoIDs := make([]ids.ID, len(tx.ExportedOutputs))
 	for i, out := range tx.ExportedOutputs {
 		utxo := &avax.UTXO{
 			UTXOID: avax.UTXOID{
@@ -247,7 +423,7 @@ func (tx *UnsignedExportTx) AtomicOps() (ids.ID, *atomic.Requests, error) {
 
 		utxoBytes, err := Codec.Marshal(codecVersion, utxo)
 		if err != nil {
-			return ids.ID{}, nil, err
+			return ids.ID{}, nil, nil, err
 		}
 		utxoID := utxo.InputID()
 		elem := &atomic.Element{
@@ -259,12 +435,38 @@ func (tx *UnsignedExportTx) AtomicOps() (ids.ID, *atomic.Requests, error) {
 		}
 
 		elems[i] = elem
+		utxoIDs[i] = utxoID
 	}
 
-	return tx.DestinationChain, &atomic.Requests{PutRequests: elems}, nil
+	return tx.DestinationChain, &atomic.Requests{PutRequests: elems}, utxoIDs, nil
 }
 
-// newExportTx returns a new ExportTx
+// ExportTxOptions adjusts how an export tx is built. The zero value builds
+// the tx as newExportTx does.
+type ExportTxOptions struct {
+	// LargestFirst spends from the keys with the largest balances first, so
+	// that the tx has as few inputs, and pays as small a fee, as possible.
+	LargestFirst bool
+	// LockDuration locks the exported output until this long after the tx is
+	// built. If 0, the exported output is not locked.
+	LockDuration time.Duration
+}
+
+// relativeLocktime returns the locktime, in unix seconds, that is [duration]
+// after [now]. The locktime must be in the future, so [duration] must be at
+// least a second.
+func relativeLocktime(now time.Time, duration time.Duration) (uint64, error) {
+	nowUnix := now.Unix()
+	locktime := now.Add(duration).Unix()
+	if locktime <= nowUnix {
+		return 0, fmt.Errorf("%w: %s after %d is %d", errLocktimeNotInFuture, duration, nowUnix, locktime)
+	}
+	return uint64(locktime), nil
+}
+
+// newExportTx returns a new ExportTx. The inputs are gathered to provide
+// exactly [amount] and the fee, so the tx has no change: the rest of the
+// balance of each key remains in its EVM account.
 func (vm *VM) newExportTx(
 	assetID ids.ID, // AssetID of the tokens to export
 	amount uint64, // Amount of tokens to export
@@ -273,12 +475,47 @@ func (vm *VM) newExportTx(
 	baseFee *big.Int, // fee to use post-AP3
 	keys []*crypto.PrivateKeySECP256K1R, // Pay the fee and provide the tokens
 ) (*Tx, error) {
+	return vm.newExportTxWithFeePayer(assetID, amount, chainID, to, baseFee, keys, nil, ExportTxOptions{})
+}
+
+// newExportTxWithFeePayer returns a new ExportTx whose exported tokens are
+// provided by [amountKeys] and whose fee is paid by [feeKeys]. If [feeKeys] is
+// nil, [amountKeys] also pay the fee. Because an address may only provide
+// AVAX once per tx, keys in [feeKeys] that provide exported AVAX are not used
+// to pay the fee. The tx is built according to [opts].
+func (vm *VM) newExportTxWithFeePayer(
+	assetID ids.ID, // AssetID of the tokens to export
+	amount uint64, // Amount of tokens to export
+	chainID ids.ID, // Chain to send the UTXOs to
+	to ids.ShortID, // Address of chain recipient
+	baseFee *big.Int, // fee to use post-AP3
+	amountKeys []*crypto.PrivateKeySECP256K1R, // Provide the tokens
+	feeKeys []*crypto.PrivateKeySECP256K1R, // Pay the fee
+	opts ExportTxOptions,
+) (*Tx, error) {
+	// The tx would fail verification if the VM's chain ID is not yet known.
+	if vm.ctx.ChainID == ids.Empty {
+		return nil, errEmptyBlockchainID
+	}
+	if chainID == constants.PlatformChainID && assetID != vm.ctx.AVAXAssetID {
+		return nil, fmt.Errorf("%w: expected %s but found %s", errWrongAVAXAssetID, vm.ctx.AVAXAssetID, assetID)
+	}
+
+	var locktime uint64
+	if opts.LockDuration != 0 {
+		relative, err := relativeLocktime(vm.clock.Time(), opts.LockDuration)
+		if err != nil {
+			return nil, err
+		}
+		locktime = relative
+	}
+
 	outs := []*avax.TransferableOutput{{ // Exported to X-Chain
 		Asset: avax.Asset{ID: assetID},
 		Out: &secp256k1fx.TransferOutput{
 			Amt: amount,
 			OutputOwners: secp256k1fx.OutputOwners{
-				Locktime:  0,
+				Locktime:  locktime,
 				Threshold: 1,
 				Addrs:     []ids.ShortID{to},
 			},
@@ -292,14 +529,36 @@ func (vm *VM) newExportTx(
 		err                  error
 	)
 
-	// consume non-AVAX
-	if assetID != vm.ctx.AVAXAssetID {
-		ins, signers, err = vm.GetSpendableFunds(keys, assetID, amount)
-		if err != nil {
-			return nil, fmt.Errorf("couldn't generate tx inputs/signers: %w", err)
+	if opts.LargestFirst {
+		if amountKeys, err = vm.keysByBalanceDescending(amountKeys, assetID); err != nil {
+			return nil, err
 		}
-	} else {
+	}
+
+	switch {
+	case assetID != vm.ctx.AVAXAssetID:
+		// consume non-AVAX
+		ins, signers, err = vm.GetSpendableFunds(amountKeys, assetID, amount)
+	case feeKeys == nil:
+		// consume the exported AVAX along with the fee
 		avaxNeeded = amount
+	default:
+		// consume the exported AVAX separately from the fee
+		ins, signers, err = vm.GetSpendableFunds(amountKeys, assetID, amount)
+		feeKeys = keysWithoutInputs(feeKeys, ins)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("couldn't generate tx inputs/signers: %w", err)
+	}
+	if feeKeys == nil {
+		feeKeys = amountKeys
+	}
+	if opts.LargestFirst && assetID != vm.ctx.AVAXAssetID {
+		// The fee is paid in AVAX, so the fee keys are ordered by their AVAX
+		// balances
+		if feeKeys, err = vm.keysByBalanceDescending(feeKeys, vm.ctx.AVAXAssetID); err != nil {
+			return nil, err
+		}
 	}
 
 	rules := vm.currentRules()
@@ -318,19 +577,19 @@ func (vm *VM) newExportTx(
 		}
 
 		var cost uint64
-		cost, err = tx.GasUsed(rules.IsApricotPhase5)
+		cost, err = tx.GasUsed(rules.AtomicTxFeeConfig, rules.IsApricotPhase5)
 		if err != nil {
 			return nil, err
 		}
 
-		avaxIns, avaxSigners, err = vm.GetSpendableAVAXWithFee(keys, avaxNeeded, cost, baseFee)
+		avaxIns, avaxSigners, err = vm.GetSpendableAVAXWithFee(feeKeys, avaxNeeded, cost, baseFee)
 	default:
 		var newAvaxNeeded uint64
 		newAvaxNeeded, err = math.Add64(avaxNeeded, params.AvalancheAtomicTxFee)
 		if err != nil {
 			return nil, errOverflowExport
 		}
-		avaxIns, avaxSigners, err = vm.GetSpendableFunds(keys, vm.ctx.AVAXAssetID, newAvaxNeeded)
+		avaxIns, avaxSigners, err = vm.GetSpendableFunds(feeKeys, vm.ctx.AVAXAssetID, newAvaxNeeded)
 	}
 	if err != nil {
 		return nil, fmt.Errorf("couldn't generate tx inputs/signers: %w", err)
@@ -341,6 +600,17 @@ func (vm *VM) newExportTx(
 	avax.SortTransferableOutputs(outs, vm.codec)
 	SortEVMInputsAndSigners(ins, signers)
 
+	// The inputs were gathered from separate reads of the current state, so
+	// make sure that the preferred block did not change in between, as the tx
+	// would then fail EVMStateTransfer.
+	state, err := vm.chain.CurrentState()
+	if err != nil {
+		return nil, err
+	}
+	if err := verifyEVMInputNonces(state, ins); err != nil {
+		return nil, err
+	}
+
 	// Create the transaction
 	utx := &UnsignedExportTx{
 		NetworkID:        vm.ctx.NetworkID,
@@ -349,11 +619,373 @@ func (vm *VM) newExportTx(
 		Ins:              ins,
 		ExportedOutputs:  outs,
 	}
+	return vm.signExportTx(utx, signers)
+}
+
+// newExportTxFromInputs returns a new ExportTx that spends the caller selected
+// [ins], signed by [signers], to export [outs] to [chainID]. [signers] must be
+// ordered as [ins]. The inputs must cover the outputs and the fee at
+// [baseFee]. If [changeAddr] is empty, any amount that they provide beyond
+// that is burned. Otherwise, it is exported to [changeAddr].
+func (vm *VM) newExportTxFromInputs(
+	ins []EVMInput, // Inputs selected by the caller
+	signers [][]*crypto.PrivateKeySECP256K1R, // Sign each input
+	outs []*avax.TransferableOutput, // Outputs to export
+	changeAddr ids.ShortID, // Address of chain recipient of the change
+	chainID ids.ID, // Chain to send the UTXOs to
+	baseFee *big.Int, // fee to use post-AP3
+) (*Tx, error) {
+	// The tx would fail verification if the VM's chain ID is not yet known.
+	if vm.ctx.ChainID == ids.Empty {
+		return nil, errEmptyBlockchainID
+	}
+	if len(ins) != len(signers) {
+		return nil, fmt.Errorf("%w: %d inputs but %d signers", errSignatureInputsMismatch, len(ins), len(signers))
+	}
+
+	// Copy the inputs and outputs so that sorting them doesn't modify the
+	// caller's slices
+	ins = append([]EVMInput(nil), ins...)
+	signers = append([][]*crypto.PrivateKeySECP256K1R(nil), signers...)
+	outs = append([]*avax.TransferableOutput(nil), outs...)
+	if changeAddr != ids.ShortEmpty {
+		changeOuts, err := vm.exportChangeOutputs(ins, signers, outs, changeAddr, chainID, baseFee)
+		if err != nil {
+			return nil, err
+		}
+		outs = append(outs, changeOuts...)
+	}
+	avax.SortTransferableOutputs(outs, vm.codec)
+	SortEVMInputsAndSigners(ins, signers)
+
+	state, err := vm.chain.CurrentState()
+	if err != nil {
+		return nil, err
+	}
+	if err := verifyEVMInputNonces(state, ins); err != nil {
+		return nil, err
+	}
+
+	utx := &UnsignedExportTx{
+		NetworkID:        vm.ctx.NetworkID,
+		BlockchainID:     vm.ctx.ChainID,
+		DestinationChain: chainID,
+		Ins:              ins,
+		ExportedOutputs:  outs,
+	}
+	tx, err := vm.signExportTx(utx, signers)
+	if err != nil {
+		return nil, err
+	}
+	fc, err := utx.flowChecker(tx, vm.ctx.AVAXAssetID, baseFee, vm.currentRules(), nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := fc.Verify(); err != nil {
+		return nil, fmt.Errorf("export tx flow check failed due to: %w", err)
+	}
+	return tx, nil
+}
+
+// exportChangeOutputs returns the outputs that export to [changeAddr] the
+// amount of each asset that [ins] provide beyond [outs]. For AVAX, the fee
+// paid at [baseFee] by the tx with the change outputs is not included. If the
+// AVAX provided beyond [outs] doesn't cover the fee, no AVAX change is
+// returned.
+func (vm *VM) exportChangeOutputs(
+	ins []EVMInput,
+	signers [][]*crypto.PrivateKeySECP256K1R,
+	outs []*avax.TransferableOutput,
+	changeAddr ids.ShortID,
+	chainID ids.ID,
+	baseFee *big.Int,
+) ([]*avax.TransferableOutput, error) {
+	var (
+		consumed = make(map[ids.ID]uint64)
+		produced = make(map[ids.ID]uint64)
+		assetIDs []ids.ID
+		err      error
+	)
+	for _, in := range ins {
+		if _, ok := consumed[in.AssetID]; !ok {
+			assetIDs = append(assetIDs, in.AssetID)
+		}
+		if consumed[in.AssetID], err = math.Add64(consumed[in.AssetID], in.Amount); err != nil {
+			return nil, err
+		}
+	}
+	for _, out := range outs {
+		assetID := out.AssetID()
+		if produced[assetID], err = math.Add64(produced[assetID], out.Output().Amount()); err != nil {
+			return nil, err
+		}
+	}
+
+	var (
+		changeOuts []*avax.TransferableOutput
+		avaxChange *secp256k1fx.TransferOutput
+	)
+	for _, assetID := range assetIDs {
+		if consumed[assetID] <= produced[assetID] {
+			continue
+		}
+		change := &secp256k1fx.TransferOutput{
+			Amt: consumed[assetID] - produced[assetID],
+			OutputOwners: secp256k1fx.OutputOwners{
+				Threshold: 1,
+				Addrs:     []ids.ShortID{changeAddr},
+			},
+		}
+		if assetID == vm.ctx.AVAXAssetID {
+			avaxChange = change
+		}
+		changeOuts = append(changeOuts, &avax.TransferableOutput{
+			Asset: avax.Asset{ID: assetID},
+			Out:   change,
+		})
+	}
+	if avaxChange == nil {
+		return changeOuts, nil
+	}
+
+	// The fee depends on the size of the tx, which does not depend on the
+	// amount of the AVAX change, so the fee is calculated with the change
+	// output included.
+	var fee uint64
+	rules := vm.currentRules()
+	switch {
+	case rules.IsApricotPhase3:
+		allOuts := append(append([]*avax.TransferableOutput(nil), outs...), changeOuts...)
+		tx := &Tx{UnsignedAtomicTx: &UnsignedExportTx{
+			NetworkID:        vm.ctx.NetworkID,
+			BlockchainID:     vm.ctx.ChainID,
+			DestinationChain: chainID,
+			Ins:              ins,
+			ExportedOutputs:  allOuts,
+		}}
+		if err := tx.Sign(vm.codec, signers); err != nil {
+			return nil, err
+		}
+		gasUsed, err := tx.GasUsed(rules.AtomicTxFeeConfig, rules.IsApricotPhase5)
+		if err != nil {
+			return nil, err
+		}
+		if fee, err = calculateDynamicFee(gasUsed, baseFee); err != nil {
+			return nil, err
+		}
+	default:
+		fee = params.AvalancheAtomicTxFee
+	}
+	if avaxChange.Amt <= fee {
+		// The remaining AVAX is burned, as it doesn't cover an output
+		filtered := changeOuts[:0]
+		for _, out := range changeOuts {
+			if out.Out != avaxChange {
+				filtered = append(filtered, out)
+			}
+		}
+		return filtered, nil
+	}
+	avaxChange.Amt -= fee
+	return changeOuts, nil
+}
+
+// signExportTx signs [utx] with [signers], which must be ordered as the inputs
+// of [utx], and verifies the resulting tx. If [VerifyExportTxCredentials] is
+// set, the credentials are also checked against the inputs so that signers in
+// the wrong order are caught before the tx is issued.
+func (vm *VM) signExportTx(utx *UnsignedExportTx, signers [][]*crypto.PrivateKeySECP256K1R) (*Tx, error) {
 	tx := &Tx{UnsignedAtomicTx: utx}
 	if err := tx.Sign(vm.codec, signers); err != nil {
 		return nil, err
 	}
-	return tx, utx.Verify(vm.ctx, vm.currentRules())
+	if err := utx.Verify(vm.ctx, vm.currentRules()); err != nil {
+		return tx, err
+	}
+	if vm.config.VerifyExportTxCredentials {
+		if err := utx.verifyCredentials(vm, tx); err != nil {
+			return tx, fmt.Errorf("export tx signed by mismatched signers: %w", err)
+		}
+	}
+	return tx, nil
+}
+
+// newExportTxAll returns a new ExportTx that exports the entire balance of
+// [assetID] held by [keys]. If [assetID] is AVAX, the fee is deducted from the
+// exported amount. Otherwise, the fee is paid with the AVAX held by [keys].
+func (vm *VM) newExportTxAll(
+	assetID ids.ID, // AssetID of the tokens to export
+	chainID ids.ID, // Chain to send the UTXOs to
+	to ids.ShortID, // Address of chain recipient
+	baseFee *big.Int, // fee to use post-AP3
+	keys []*crypto.PrivateKeySECP256K1R, // Pay the fee and provide the tokens
+) (*Tx, error) {
+	if assetID != vm.ctx.AVAXAssetID {
+		state, err := vm.chain.CurrentState()
+		if err != nil {
+			return nil, err
+		}
+		var amount uint64
+		for _, key := range keys {
+			balance := state.GetBalanceMultiCoin(GetEthAddress(key), common.Hash(assetID))
+			if !balance.IsUint64() {
+				return nil, errOverflowExport
+			}
+			if amount, err = math.Add64(amount, balance.Uint64()); err != nil {
+				return nil, errOverflowExport
+			}
+		}
+		return vm.newExportTx(assetID, amount, chainID, to, baseFee, keys)
+	}
+
+	// The tx would fail verification if the VM's chain ID is not yet known.
+	if vm.ctx.ChainID == ids.Empty {
+		return nil, errEmptyBlockchainID
+	}
+
+	// Note: current state uses the state of the preferred block.
+	state, err := vm.chain.CurrentState()
+	if err != nil {
+		return nil, err
+	}
+	rules := vm.currentRules()
+	// An input is only worth adding if its balance covers the fee of the
+	// input itself.
+	var inputFee uint64
+	if rules.IsApricotPhase3 {
+		inputFee, err = calculateDynamicFee(evmInputGas(rules.AtomicTxFeeConfig), baseFee)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var (
+		ins     []EVMInput
+		signers [][]*crypto.PrivateKeySECP256K1R
+		total   uint64
+	)
+	for _, key := range keys {
+		addr := GetEthAddress(key)
+		balance := weiToExportableAVAX(state.GetBalance(addr))
+		if balance == 0 || balance <= inputFee {
+			continue
+		}
+		ins = append(ins, EVMInput{
+			Address: addr,
+			Amount:  balance,
+			AssetID: assetID,
+			Nonce:   state.GetNonce(addr),
+		})
+		signers = append(signers, []*crypto.PrivateKeySECP256K1R{key})
+		if total, err = math.Add64(total, balance); err != nil {
+			return nil, errOverflowExport
+		}
+	}
+	SortEVMInputsAndSigners(ins, signers)
+
+	out := &secp256k1fx.TransferOutput{
+		OutputOwners: secp256k1fx.OutputOwners{
+			Locktime:  0,
+			Threshold: 1,
+			Addrs:     []ids.ShortID{to},
+		},
+	}
+	utx := &UnsignedExportTx{
+		NetworkID:        vm.ctx.NetworkID,
+		BlockchainID:     vm.ctx.ChainID,
+		DestinationChain: chainID,
+		Ins:              ins,
+		ExportedOutputs: []*avax.TransferableOutput{{
+			Asset: avax.Asset{ID: assetID},
+			Out:   out,
+		}},
+	}
+
+	// The exported amount depends on the fee, which is computed from the tx
+	// including the exported amount, so the fee is recomputed until it
+	// reaches a fixed point.
+	var fee uint64
+	for i := 0; ; i++ {
+		if i == maxExportFeeIterations {
+			return nil, errExportFeeNotConverged
+		}
+		if total <= fee {
+			return nil, fmt.Errorf("%w: balance of %d nAVAX does not cover the fee of %d nAVAX", errInsufficientFunds, total, fee)
+		}
+		out.Amt = total - fee
+
+		newFee := params.AvalancheAtomicTxFee
+		if rules.IsApricotPhase3 {
+			tx := &Tx{UnsignedAtomicTx: utx}
+			if err := tx.Sign(vm.codec, nil); err != nil {
+				return nil, err
+			}
+			gasUsed, err := tx.GasUsed(rules.AtomicTxFeeConfig, rules.IsApricotPhase5)
+			if err != nil {
+				return nil, err
+			}
+			if newFee, err = calculateDynamicFee(gasUsed, baseFee); err != nil {
+				return nil, err
+			}
+		}
+		if newFee == fee {
+			break
+		}
+		fee = newFee
+	}
+	return vm.signExportTx(utx, signers)
+}
+
+// keysWithoutInputs returns the keys of [keys] whose addresses are not spent
+// from by any input in [ins].
+func keysWithoutInputs(keys []*crypto.PrivateKeySECP256K1R, ins []EVMInput) []*crypto.PrivateKeySECP256K1R {
+	spent := make(map[common.Address]struct{}, len(ins))
+	for _, in := range ins {
+		spent[in.Address] = struct{}{}
+	}
+	remaining := make([]*crypto.PrivateKeySECP256K1R, 0, len(keys))
+	for _, key := range keys {
+		if _, ok := spent[GetEthAddress(key)]; !ok {
+			remaining = append(remaining, key)
+		}
+	}
+	return remaining
+}
+
+// verifyEVMInputNonces returns an error if the nonce of any input in [ins] does
+// not match the nonce of its address in [state].
+func verifyEVMInputNonces(state *state.StateDB, ins []EVMInput) error {
+	for _, in := range ins {
+		if nonce := state.GetNonce(in.Address); in.Nonce != nonce {
+			return fmt.Errorf("%w: input from %s has nonce %d, but the account nonce is %d", errInvalidNonce, in.Address, in.Nonce, nonce)
+		}
+	}
+	return nil
+}
+
+// hasFutureEVMInputNonces returns true if the nonce of at least one input in
+// [ins] is ahead of the nonce of its address in [state], and no input has a
+// nonce behind it. Such inputs may become valid once the nonces of their
+// addresses are reached.
+func hasFutureEVMInputNonces(state *state.StateDB, ins []EVMInput) bool {
+	future := false
+	for _, in := range ins {
+		nonce := state.GetNonce(in.Address)
+		if in.Nonce < nonce {
+			return false
+		}
+		if in.Nonce > nonce {
+			future = true
+		}
+	}
+	return future
+}
+
+// getSpendableEVMBalance returns the portion of the AVAX balance of [addr] in
+// [state] that an export tx may spend. No part of an account's balance is
+// locked at the EVM level, so every address can spend its full balance.
+func getSpendableEVMBalance(state *state.StateDB, addr common.Address) *big.Int {
+	return state.GetBalance(addr)
 }
 
 // EVMStateTransfer executes the state update from the atomic export transaction
@@ -366,7 +998,7 @@ func (tx *UnsignedExportTx) EVMStateTransfer(ctx *snow.Context, state *state.Sta
 			// denomination before export.
 			amount := new(big.Int).Mul(
 				new(big.Int).SetUint64(from.Amount), x2cRate)
-			if state.GetBalance(from.Address).Cmp(amount) < 0 {
+			if getSpendableEVMBalance(state, from.Address).Cmp(amount) < 0 {
 				return errInsufficientFunds
 			}
 			state.SubBalance(from.Address, amount)
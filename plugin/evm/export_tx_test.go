@@ -5,19 +5,35 @@ package evm
 
 import (
 	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
 	"math/big"
+	"reflect"
+	"runtime"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/ava-labs/avalanchego/cache"
 	"github.com/ava-labs/avalanchego/chains/atomic"
 	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/snow"
 	engCommon "github.com/ava-labs/avalanchego/snow/engine/common"
 	"github.com/ava-labs/avalanchego/utils/constants"
 	"github.com/ava-labs/avalanchego/utils/crypto"
 	"github.com/ava-labs/avalanchego/utils/units"
 	"github.com/ava-labs/avalanchego/vms/components/avax"
+	"github.com/ava-labs/avalanchego/vms/components/verify"
 	"github.com/ava-labs/avalanchego/vms/secp256k1fx"
+	"github.com/ava-labs/coreth/core/rawdb"
+	"github.com/ava-labs/coreth/core/state"
 	"github.com/ava-labs/coreth/params"
 	"github.com/ethereum/go-ethereum/common"
+	ethcrypto "github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/metrics"
 )
 
 // createExportTxOptions adds funds to shared memory, imports them, and returns a list of export transactions
@@ -313,6 +329,31 @@ func TestExportTxEVMStateTransfer(t *testing.T) {
 			expectedNonce: 1,
 			shouldErr:     true,
 		},
+		{
+			// Inputs are only required to be unique after AP1, so a duplicate
+			// input must still be spent when re-executing earlier blocks
+			name: "spend AVAX twice from the same address before AP1",
+			tx: []EVMInput{
+				{
+					Address: ethAddr,
+					Amount:  avaxAmount / 2,
+					AssetID: testAvaxAssetID,
+					Nonce:   0,
+				},
+				{
+					Address: ethAddr,
+					Amount:  avaxAmount / 2,
+					AssetID: testAvaxAssetID,
+					Nonce:   0,
+				},
+			},
+			avaxBalance: big.NewInt(0),
+			balances: map[ids.ID]*big.Int{
+				customAssetID: big.NewInt(int64(customAmount)),
+			},
+			expectedNonce: 1,
+			shouldErr:     false,
+		},
 	}
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
@@ -920,63 +961,105 @@ func TestExportTxSemanticVerify(t *testing.T) {
 	}
 }
 
-func TestExportTxAccept(t *testing.T) {
-	_, vm, _, sharedMemory, _ := GenesisVM(t, true, genesisJSONApricotPhase0, "", "")
-
-	xChainSharedMemory := sharedMemory.NewSharedMemory(vm.ctx.XChainID)
-
+func TestExportTxSemanticVerifyMalleableSignature(t *testing.T) {
+	_, vm, _, _, _ := GenesisVM(t, true, genesisJSONApricotPhase5, "", "")
 	defer func() {
 		if err := vm.Shutdown(); err != nil {
 			t.Fatal(err)
 		}
 	}()
 
+	parent := vm.LastAcceptedBlockInternal().(*Block)
 	key := testKeys[0]
-	addr := key.PublicKey().Address()
-	ethAddr := testEthAddrs[0]
-
-	var (
-		avaxBalance           = 10 * units.Avax
-		custom0Balance uint64 = 100
-		custom0AssetID        = ids.ID{1, 2, 3, 4, 5}
-	)
-
 	exportTx := &UnsignedExportTx{
 		NetworkID:        vm.ctx.NetworkID,
 		BlockchainID:     vm.ctx.ChainID,
 		DestinationChain: vm.ctx.XChainID,
 		Ins: []EVMInput{
 			{
-				Address: ethAddr,
-				Amount:  avaxBalance,
+				Address: testEthAddrs[0],
+				Amount:  units.Avax,
 				AssetID: vm.ctx.AVAXAssetID,
 				Nonce:   0,
 			},
-			{
-				Address: ethAddr,
-				Amount:  custom0Balance,
-				AssetID: custom0AssetID,
-				Nonce:   0,
-			},
 		},
 		ExportedOutputs: []*avax.TransferableOutput{
 			{
 				Asset: avax.Asset{ID: vm.ctx.AVAXAssetID},
 				Out: &secp256k1fx.TransferOutput{
-					Amt: avaxBalance,
+					Amt: units.Avax / 2,
 					OutputOwners: secp256k1fx.OutputOwners{
 						Threshold: 1,
-						Addrs:     []ids.ShortID{addr},
+						Addrs:     []ids.ShortID{key.PublicKey().Address()},
 					},
 				},
 			},
+		},
+	}
+
+	canonicalTx := &Tx{UnsignedAtomicTx: exportTx}
+	if err := canonicalTx.Sign(vm.codec, [][]*crypto.PrivateKeySECP256K1R{{key}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := exportTx.SemanticVerify(vm, canonicalTx, parent, initialBaseFee, apricotRulesPhase5); err != nil {
+		t.Fatalf("canonical signature failed verification: %s", err)
+	}
+
+	// Malleate the signature by negating S and flipping the recovery ID. The
+	// result is a valid signature over the same message by the same key.
+	sig := canonicalTx.Creds[0].(*secp256k1fx.Credential).Sigs[0]
+	s := new(big.Int).SetBytes(sig[32:64])
+	malleatedS := new(big.Int).Sub(ethcrypto.S256().Params().N, s)
+	var malleatedSig [crypto.SECP256K1RSigLen]byte
+	copy(malleatedSig[:32], sig[:32])
+	malleatedS.FillBytes(malleatedSig[32:64])
+	malleatedSig[64] = sig[64] ^ 1
+
+	malleatedTx := &Tx{
+		UnsignedAtomicTx: exportTx,
+		Creds: []verify.Verifiable{
+			&secp256k1fx.Credential{Sigs: [][crypto.SECP256K1RSigLen]byte{malleatedSig}},
+		},
+	}
+	err := exportTx.SemanticVerify(vm, malleatedTx, parent, initialBaseFee, apricotRulesPhase5)
+	if !errors.Is(err, errHighSSignature) {
+		t.Fatalf("Expected malleated signature to fail with %s, but found %v", errHighSSignature, err)
+	}
+}
+
+// Ensure that an export tx that produces an asset that it never consumes
+// fails the flow check with an error naming that asset.
+func TestExportTxSemanticVerifyUnconsumedAsset(t *testing.T) {
+	_, vm, _, _, _ := GenesisVM(t, true, genesisJSONApricotPhase5, "", "")
+	defer func() {
+		if err := vm.Shutdown(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	parent := vm.LastAcceptedBlockInternal().(*Block)
+	key := testKeys[0]
+	unconsumedAssetID := ids.ID{1, 2, 3}
+	exportTx := &UnsignedExportTx{
+		NetworkID:        vm.ctx.NetworkID,
+		BlockchainID:     vm.ctx.ChainID,
+		DestinationChain: vm.ctx.XChainID,
+		Ins: []EVMInput{
+			{
+				Address: testEthAddrs[0],
+				Amount:  units.Avax,
+				AssetID: vm.ctx.AVAXAssetID,
+				Nonce:   0,
+			},
+		},
+		ExportedOutputs: []*avax.TransferableOutput{
 			{
-				Asset: avax.Asset{ID: custom0AssetID},
+				Asset: avax.Asset{ID: unconsumedAssetID},
 				Out: &secp256k1fx.TransferOutput{
-					Amt: custom0Balance,
+					Amt: 1,
 					OutputOwners: secp256k1fx.OutputOwners{
 						Threshold: 1,
-						Addrs:     []ids.ShortID{addr},
+						Addrs:     []ids.ShortID{key.PublicKey().Address()},
 					},
 				},
 			},
@@ -984,138 +1067,844 @@ func TestExportTxAccept(t *testing.T) {
 	}
 
 	tx := &Tx{UnsignedAtomicTx: exportTx}
-
-	signers := [][]*crypto.PrivateKeySECP256K1R{
-		{key},
-		{key},
-		{key},
-	}
-
-	if err := tx.Sign(vm.codec, signers); err != nil {
+	if err := tx.Sign(vm.codec, [][]*crypto.PrivateKeySECP256K1R{{key}}); err != nil {
 		t.Fatal(err)
 	}
-
-	commitBatch, err := vm.db.CommitBatch()
-	if err != nil {
-		t.Fatalf("Failed to create commit batch for VM due to %s", err)
-	}
-	chainID, atomicRequests, err := tx.AtomicOps()
-	if err != nil {
-		t.Fatalf("Failed to accept export transaction due to: %s", err)
-	}
-
-	if err := vm.ctx.SharedMemory.Apply(map[ids.ID]*atomic.Requests{chainID: {PutRequests: atomicRequests.PutRequests}}, commitBatch); err != nil {
-		t.Fatal(err)
+	err := exportTx.SemanticVerify(vm, tx, parent, initialBaseFee, apricotRulesPhase5)
+	if err == nil {
+		t.Fatal("Expected the flow check to fail")
 	}
-	indexedValues, _, _, err := xChainSharedMemory.Indexed(vm.ctx.ChainID, [][]byte{addr.Bytes()}, nil, nil, 3)
-	if err != nil {
-		t.Fatal(err)
+	if !strings.Contains(err.Error(), unconsumedAssetID.String()) {
+		t.Fatalf("Expected the error to name asset %s, but found: %s", unconsumedAssetID, err)
 	}
+}
 
-	if len(indexedValues) != 2 {
-		t.Fatalf("expected 2 values but got %d", len(indexedValues))
-	}
+func TestExportTxSemanticVerifyMismatchedCredentials(t *testing.T) {
+	_, vm, _, _, _ := GenesisVM(t, true, genesisJSONApricotPhase5, "", "")
+	defer func() {
+		if err := vm.Shutdown(); err != nil {
+			t.Fatal(err)
+		}
+	}()
 
-	avaxUTXOID := avax.UTXOID{
-		TxID:        tx.ID(),
-		OutputIndex: 0,
+	parent := vm.LastAcceptedBlockInternal().(*Block)
+	key := testKeys[0]
+	exportTx := &UnsignedExportTx{
+		NetworkID:        vm.ctx.NetworkID,
+		BlockchainID:     vm.ctx.ChainID,
+		DestinationChain: vm.ctx.XChainID,
+		Ins: []EVMInput{
+			{
+				Address: testEthAddrs[0],
+				Amount:  units.Avax,
+				AssetID: vm.ctx.AVAXAssetID,
+				Nonce:   0,
+			},
+		},
+		ExportedOutputs: []*avax.TransferableOutput{
+			{
+				Asset: avax.Asset{ID: vm.ctx.AVAXAssetID},
+				Out: &secp256k1fx.TransferOutput{
+					Amt: units.Avax / 2,
+					OutputOwners: secp256k1fx.OutputOwners{
+						Threshold: 1,
+						Addrs:     []ids.ShortID{key.PublicKey().Address()},
+					},
+				},
+			},
+		},
 	}
-	avaxInputID := avaxUTXOID.InputID()
 
-	customUTXOID := avax.UTXOID{
-		TxID:        tx.ID(),
-		OutputIndex: 1,
+	for _, signers := range [][][]*crypto.PrivateKeySECP256K1R{
+		{},
+		{{key}, {key}},
+	} {
+		tx := &Tx{UnsignedAtomicTx: exportTx}
+		if err := tx.Sign(vm.codec, signers); err != nil {
+			t.Fatal(err)
+		}
+		err := exportTx.SemanticVerify(vm, tx, parent, initialBaseFee, apricotRulesPhase5)
+		if !errors.Is(err, errSignatureInputsMismatch) {
+			t.Fatalf("Expected %d credentials to fail with %s, but found %v", len(tx.Creds), errSignatureInputsMismatch, err)
+		}
 	}
-	customInputID := customUTXOID.InputID()
+}
 
-	fetchedValues, err := xChainSharedMemory.Get(vm.ctx.ChainID, [][]byte{
-		customInputID[:],
-		avaxInputID[:],
-	})
-	if err != nil {
-		t.Fatal(err)
-	}
+// Ensure that the error of an export tx with a bad signature names the input
+// that it failed for.
+func TestExportTxSemanticVerifyReportsBadSignatureIndex(t *testing.T) {
+	_, vm, _, _, _ := GenesisVM(t, true, genesisJSONApricotPhase5, "", "")
+	defer func() {
+		if err := vm.Shutdown(); err != nil {
+			t.Fatal(err)
+		}
+	}()
 
-	if !bytes.Equal(fetchedValues[0], indexedValues[0]) {
-		t.Fatalf("inconsistent values returned fetched %x indexed %x", fetchedValues[0], indexedValues[0])
-	}
-	if !bytes.Equal(fetchedValues[1], indexedValues[1]) {
-		t.Fatalf("inconsistent values returned fetched %x indexed %x", fetchedValues[1], indexedValues[1])
+	parent := vm.LastAcceptedBlockInternal().(*Block)
+	factory := crypto.FactorySECP256K1R{}
+	ins := make([]EVMInput, 5)
+	signers := make([][]*crypto.PrivateKeySECP256K1R, len(ins))
+	for i := range ins {
+		keyIntf, err := factory.NewPrivateKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		key := keyIntf.(*crypto.PrivateKeySECP256K1R)
+		ins[i] = EVMInput{
+			Address: GetEthAddress(key),
+			Amount:  units.Avax,
+			AssetID: vm.ctx.AVAXAssetID,
+		}
+		signers[i] = []*crypto.PrivateKeySECP256K1R{key}
 	}
+	SortEVMInputsAndSigners(ins, signers)
 
-	customUTXOBytes, err := Codec.Marshal(codecVersion, &avax.UTXO{
-		UTXOID: customUTXOID,
-		Asset:  avax.Asset{ID: custom0AssetID},
-		Out:    exportTx.ExportedOutputs[1].Out,
-	})
-	if err != nil {
-		t.Fatal(err)
+	exportTx := &UnsignedExportTx{
+		NetworkID:        vm.ctx.NetworkID,
+		BlockchainID:     vm.ctx.ChainID,
+		DestinationChain: vm.ctx.XChainID,
+		Ins:              ins,
+		ExportedOutputs: []*avax.TransferableOutput{
+			{
+				Asset: avax.Asset{ID: vm.ctx.AVAXAssetID},
+				Out: &secp256k1fx.TransferOutput{
+					Amt: units.Avax,
+					OutputOwners: secp256k1fx.OutputOwners{
+						Threshold: 1,
+						Addrs:     []ids.ShortID{testShortIDAddrs[0]},
+					},
+				},
+			},
+		},
 	}
 
-	avaxUTXOBytes, err := Codec.Marshal(codecVersion, &avax.UTXO{
-		UTXOID: avaxUTXOID,
-		Asset:  avax.Asset{ID: vm.ctx.AVAXAssetID},
-		Out:    exportTx.ExportedOutputs[0].Out,
-	})
-	if err != nil {
+	// Sign input 3 with the key of input 0
+	signers[3] = signers[0]
+	tx := &Tx{UnsignedAtomicTx: exportTx}
+	if err := tx.Sign(vm.codec, signers); err != nil {
 		t.Fatal(err)
 	}
-
-	if !bytes.Equal(fetchedValues[0], customUTXOBytes) {
-		t.Fatalf("incorrect values returned expected %x got %x", customUTXOBytes, fetchedValues[0])
-	}
-	if !bytes.Equal(fetchedValues[1], avaxUTXOBytes) {
-		t.Fatalf("incorrect values returned expected %x got %x", avaxUTXOBytes, fetchedValues[1])
+	err := exportTx.SemanticVerifyWithOptions(vm, tx, parent, initialBaseFee, apricotRulesPhase5, SemanticVerifyOptions{SkipFlowCheck: true})
+	if !errors.Is(err, errPublicKeySignatureMismatch) {
+		t.Fatalf("Expected the signature of input 3 to fail with %s, but found %v", errPublicKeySignatureMismatch, err)
 	}
-}
-
-func TestExportTxVerifyNil(t *testing.T) {
-	var exportTx *UnsignedExportTx
-	if err := exportTx.Verify(NewContext(), apricotRulesPhase0); err == nil {
-		t.Fatal("Verify should have failed due to nil transaction")
+	if expected := fmt.Sprintf("input 3 expected a signature from %s", ins[3].Address); !strings.Contains(err.Error(), expected) {
+		t.Fatalf("Expected the error to contain %q, but found %q", expected, err)
 	}
 }
 
-func TestExportTxVerify(t *testing.T) {
-	var exportAmount uint64 = 10000000
-	exportTx := &UnsignedExportTx{
+func TestExportTxJSONRoundTrip(t *testing.T) {
+	tx := &UnsignedExportTx{
 		NetworkID:        testNetworkID,
 		BlockchainID:     testCChainID,
 		DestinationChain: testXChainID,
 		Ins: []EVMInput{
 			{
 				Address: testEthAddrs[0],
-				Amount:  exportAmount,
+				Amount:  math.MaxUint64,
 				AssetID: testAvaxAssetID,
-				Nonce:   0,
+				Nonce:   7,
 			},
 			{
-				Address: testEthAddrs[2],
-				Amount:  exportAmount,
-				AssetID: testAvaxAssetID,
+				Address: testEthAddrs[1],
+				Amount:  1,
+				AssetID: ids.GenerateTestID(),
 				Nonce:   0,
 			},
 		},
-		ExportedOutputs: []*avax.TransferableOutput{
-			{
-				Asset: avax.Asset{ID: testAvaxAssetID},
-				Out: &secp256k1fx.TransferOutput{
-					Amt: exportAmount,
-					OutputOwners: secp256k1fx.OutputOwners{
-						Locktime:  0,
-						Threshold: 1,
-						Addrs:     []ids.ShortID{testShortIDAddrs[0]},
-					},
+		ExportedOutputs: []*avax.TransferableOutput{{
+			Asset: avax.Asset{ID: testAvaxAssetID},
+			Out: &secp256k1fx.TransferOutput{
+				Amt: 12345,
+				OutputOwners: secp256k1fx.OutputOwners{
+					Locktime:  10,
+					Threshold: 1,
+					Addrs:     []ids.ShortID{testShortIDAddrs[0], testShortIDAddrs[1]},
 				},
 			},
-			{
+		}},
+	}
+
+	b, err := json.Marshal(tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed := &UnsignedExportTx{}
+	if err := json.Unmarshal(b, parsed); err != nil {
+		t.Fatalf("failed to unmarshal %s: %s", b, err)
+	}
+	if !reflect.DeepEqual(tx, parsed) {
+		t.Fatalf("expected %s to unmarshal to %+v, but found %+v", b, tx, parsed)
+	}
+
+	// The JSON form must be stable across a round trip
+	b2, err := json.Marshal(parsed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(b, b2) {
+		t.Fatalf("expected JSON %s, but found %s", b, b2)
+	}
+
+	if err := json.Unmarshal([]byte(`{"exportedOutputs":[{"assetID":"`+testAvaxAssetID.String()+`"}]}`), &UnsignedExportTx{}); err == nil {
+		t.Fatal("expected an exported output without an output to fail to unmarshal")
+	}
+}
+
+func TestExportTxExportedOutputGas(t *testing.T) {
+	const exportedOutputGas = 1_000
+	activation := big.NewInt(100)
+	config := *params.TestChainConfig
+	config.AtomicTxFeeConfig = &params.AtomicTxFeeConfig{
+		TxBytesGas:                 params.DefaultAtomicTxFeeConfig.TxBytesGas,
+		BaseCost:                   params.DefaultAtomicTxFeeConfig.BaseCost,
+		ExportedOutputGas:          exportedOutputGas,
+		ExportedOutputGasTimestamp: activation,
+	}
+	baseFee := big.NewInt(25 * params.GWei)
+
+	newTx := func(numOutputs int) *UnsignedExportTx {
+		tx := &UnsignedExportTx{
+			NetworkID:        testNetworkID,
+			BlockchainID:     testCChainID,
+			DestinationChain: testXChainID,
+			Ins: []EVMInput{{
+				Address: testEthAddrs[0],
+				Amount:  uint64(numOutputs) * units.Avax,
+				AssetID: testAvaxAssetID,
+			}},
+		}
+		for i := 0; i < numOutputs; i++ {
+			tx.ExportedOutputs = append(tx.ExportedOutputs, &avax.TransferableOutput{
 				Asset: avax.Asset{ID: testAvaxAssetID},
 				Out: &secp256k1fx.TransferOutput{
-					Amt: exportAmount,
+					Amt: units.Avax,
 					OutputOwners: secp256k1fx.OutputOwners{
-						Locktime:  0,
 						Threshold: 1,
-						Addrs:     []ids.ShortID{testShortIDAddrs[1]},
+						Addrs:     []ids.ShortID{testShortIDAddrs[i%len(testShortIDAddrs)]},
+					},
+				},
+			})
+		}
+		unsignedBytes, err := Codec.Marshal(codecVersion, tx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tx.Initialize(unsignedBytes, unsignedBytes)
+		return tx
+	}
+	oneOutputTx, tenOutputsTx := newTx(1), newTx(10)
+
+	fees := func(timestamp *big.Int) (uint64, uint64) {
+		rules := config.AvalancheRules(common.Big0, timestamp)
+		var txFees [2]uint64
+		for i, tx := range []*UnsignedExportTx{oneOutputTx, tenOutputsTx} {
+			gasUsed, err := tx.GasUsed(rules.AtomicTxFeeConfig, true)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if txFees[i], err = calculateDynamicFee(gasUsed, baseFee); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return txFees[0], txFees[1]
+	}
+
+	// Before activation, the extra outputs are only charged for their bytes
+	bytesGas := uint64(len(tenOutputsTx.Bytes())-len(oneOutputTx.Bytes())) * params.DefaultAtomicTxFeeConfig.TxBytesGas
+	bytesFee, err := calculateDynamicFee(bytesGas, baseFee)
+	if err != nil {
+		t.Fatal(err)
+	}
+	oneOutputFee, tenOutputsFee := fees(new(big.Int).Sub(activation, common.Big1))
+	if tenOutputsFee-oneOutputFee != bytesFee {
+		t.Fatalf("expected the 9 extra outputs to cost %d before activation, but found %d", bytesFee, tenOutputsFee-oneOutputFee)
+	}
+
+	// After activation, each extra output is also charged [exportedOutputGas]
+	surchargedFee, err := calculateDynamicFee(bytesGas+9*exportedOutputGas, baseFee)
+	if err != nil {
+		t.Fatal(err)
+	}
+	activeOneOutputFee, activeTenOutputsFee := fees(activation)
+	if activeTenOutputsFee-activeOneOutputFee != surchargedFee {
+		t.Fatalf("expected the 9 extra outputs to cost %d after activation, but found %d", surchargedFee, activeTenOutputsFee-activeOneOutputFee)
+	}
+	if expected := oneOutputFee + exportedOutputGas*25; activeOneOutputFee != expected {
+		t.Fatalf("expected a single output export to cost %d after activation, but found %d", expected, activeOneOutputFee)
+	}
+}
+
+// Ensure that an export tx is verified with the rules of the time passed in,
+// rather than those of the current block.
+func TestExportTxSemanticVerifyRulesAt(t *testing.T) {
+	genesisJSON := strings.Replace(genesisJSONApricotPhase5, `"apricotPhase5BlockTimestamp":0`, `"apricotPhase5BlockTimestamp":1000`, 1)
+	_, vm, _, _, _ := GenesisVM(t, true, genesisJSON, "", "")
+	defer func() {
+		if err := vm.Shutdown(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	parent := vm.LastAcceptedBlockInternal().(*Block)
+	key := testKeys[0]
+	// Exports to the P-chain are only valid after ApricotPhase5
+	exportTx := &UnsignedExportTx{
+		NetworkID:        vm.ctx.NetworkID,
+		BlockchainID:     vm.ctx.ChainID,
+		DestinationChain: constants.PlatformChainID,
+		Ins: []EVMInput{
+			{
+				Address: testEthAddrs[0],
+				Amount:  units.Avax,
+				AssetID: vm.ctx.AVAXAssetID,
+				Nonce:   0,
+			},
+		},
+		ExportedOutputs: []*avax.TransferableOutput{
+			{
+				Asset: avax.Asset{ID: vm.ctx.AVAXAssetID},
+				Out: &secp256k1fx.TransferOutput{
+					Amt: units.Avax / 2,
+					OutputOwners: secp256k1fx.OutputOwners{
+						Threshold: 1,
+						Addrs:     []ids.ShortID{key.PublicKey().Address()},
+					},
+				},
+			},
+		},
+	}
+	tx := &Tx{UnsignedAtomicTx: exportTx}
+	if err := tx.Sign(vm.codec, [][]*crypto.PrivateKeySECP256K1R{{key}}); err != nil {
+		t.Fatal(err)
+	}
+
+	apricotPhase3Rules := vm.rulesAt(common.Big1, 999)
+	if !apricotPhase3Rules.IsApricotPhase3 || apricotPhase3Rules.IsApricotPhase5 {
+		t.Fatal("Expected ApricotPhase3 rules before the ApricotPhase5 activation time")
+	}
+	if err := exportTx.SemanticVerify(vm, tx, parent, initialBaseFee, apricotPhase3Rules); err == nil {
+		t.Fatal("Expected export to the P-chain to fail verification under ApricotPhase3 rules")
+	}
+	if err := exportTx.SemanticVerify(vm, tx, parent, initialBaseFee, vm.rulesAt(common.Big1, 1000)); err != nil {
+		t.Fatalf("Expected export to the P-chain to pass verification under ApricotPhase5 rules: %s", err)
+	}
+}
+
+func TestExportTxSemanticVerifyMetrics(t *testing.T) {
+	_, vm, _, _, _ := GenesisVM(t, true, genesisJSONApricotPhase5, "", "")
+	defer func() {
+		if err := vm.Shutdown(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Metrics are disabled in tests, so replace the VM's metrics with ones
+	// that record observations.
+	metrics.Enabled = true
+	defer func() { metrics.Enabled = false }()
+	vm.atomicTxMetrics = newAtomicTxMetrics(metrics.NewRegistry())
+
+	parent := vm.LastAcceptedBlockInternal().(*Block)
+	key := testKeys[0]
+	tx := &Tx{UnsignedAtomicTx: &UnsignedExportTx{
+		NetworkID:        vm.ctx.NetworkID,
+		BlockchainID:     vm.ctx.ChainID,
+		DestinationChain: vm.ctx.XChainID,
+		Ins: []EVMInput{
+			{
+				Address: testEthAddrs[0],
+				Amount:  units.Avax,
+				AssetID: vm.ctx.AVAXAssetID,
+				Nonce:   0,
+			},
+		},
+		ExportedOutputs: []*avax.TransferableOutput{
+			{
+				Asset: avax.Asset{ID: vm.ctx.AVAXAssetID},
+				Out: &secp256k1fx.TransferOutput{
+					Amt: units.Avax / 2,
+					OutputOwners: secp256k1fx.OutputOwners{
+						Threshold: 1,
+						Addrs:     []ids.ShortID{key.PublicKey().Address()},
+					},
+				},
+			},
+		},
+	}}
+	if err := tx.Sign(vm.codec, [][]*crypto.PrivateKeySECP256K1R{{key}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.UnsignedAtomicTx.SemanticVerify(vm, tx, parent, initialBaseFee, apricotRulesPhase5); err != nil {
+		t.Fatal(err)
+	}
+
+	if count := vm.atomicTxMetrics.exportSemanticVerify.Count(); count != 1 {
+		t.Fatalf("Expected 1 export semantic verify observation, but found %d", count)
+	}
+	if count := vm.atomicTxMetrics.exportSigRecovery.Count(); count != 1 {
+		t.Fatalf("Expected 1 export signature recovery observation, but found %d", count)
+	}
+	if count := vm.atomicTxMetrics.importSemanticVerify.Count(); count != 0 {
+		t.Fatalf("Expected 0 import semantic verify observations, but found %d", count)
+	}
+}
+
+func TestExportTxSemanticVerifySkipFlowCheck(t *testing.T) {
+	_, vm, _, _, _ := GenesisVM(t, true, genesisJSONApricotPhase5, "", "")
+	defer func() {
+		if err := vm.Shutdown(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	parent := vm.LastAcceptedBlockInternal().(*Block)
+	key := testKeys[0]
+	// The exported amount leaves nothing to pay the tx fee.
+	exportTx := &UnsignedExportTx{
+		NetworkID:        vm.ctx.NetworkID,
+		BlockchainID:     vm.ctx.ChainID,
+		DestinationChain: vm.ctx.XChainID,
+		Ins: []EVMInput{
+			{
+				Address: testEthAddrs[0],
+				Amount:  units.Avax,
+				AssetID: vm.ctx.AVAXAssetID,
+				Nonce:   0,
+			},
+		},
+		ExportedOutputs: []*avax.TransferableOutput{
+			{
+				Asset: avax.Asset{ID: vm.ctx.AVAXAssetID},
+				Out: &secp256k1fx.TransferOutput{
+					Amt: units.Avax,
+					OutputOwners: secp256k1fx.OutputOwners{
+						Threshold: 1,
+						Addrs:     []ids.ShortID{key.PublicKey().Address()},
+					},
+				},
+			},
+		},
+	}
+	tx := &Tx{UnsignedAtomicTx: exportTx}
+	if err := tx.Sign(vm.codec, [][]*crypto.PrivateKeySECP256K1R{{key}}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := exportTx.SemanticVerify(vm, tx, parent, initialBaseFee, apricotRulesPhase5); err == nil {
+		t.Fatal("Expected tx with insufficient funds to fail the flow check")
+	}
+	opts := SemanticVerifyOptions{SkipFlowCheck: true}
+	if err := exportTx.SemanticVerifyWithOptions(vm, tx, parent, initialBaseFee, apricotRulesPhase5, opts); err != nil {
+		t.Fatalf("Expected tx with insufficient funds to pass when skipping the flow check, but found %s", err)
+	}
+
+	// Signatures are still checked when skipping the flow check
+	wrongSigTx := &Tx{UnsignedAtomicTx: exportTx}
+	if err := wrongSigTx.Sign(vm.codec, [][]*crypto.PrivateKeySECP256K1R{{testKeys[1]}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := exportTx.SemanticVerifyWithOptions(vm, wrongSigTx, parent, initialBaseFee, apricotRulesPhase5, opts); !errors.Is(err, errPublicKeySignatureMismatch) {
+		t.Fatalf("Expected %s, but found %v", errPublicKeySignatureMismatch, err)
+	}
+}
+
+func TestExportTxCustomAtomicTxFeeConfig(t *testing.T) {
+	_, vm, _, _, _ := GenesisVM(t, true, genesisJSONApricotPhase5, "", "")
+	defer func() {
+		if err := vm.Shutdown(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	customFees := params.AtomicTxFeeConfig{TxBytesGas: 2, BaseCost: 2 * params.AtomicTxBaseCost}
+	if err := customFees.Verify(); err != nil {
+		t.Fatal(err)
+	}
+	for _, fees := range []params.AtomicTxFeeConfig{{TxBytesGas: 0, BaseCost: 1}, {TxBytesGas: 1, BaseCost: 0}} {
+		if err := fees.Verify(); err == nil {
+			t.Fatalf("Expected fee config %+v to fail verification", fees)
+		}
+	}
+	customRules := apricotRulesPhase5
+	customRules.AtomicTxFeeConfig = customFees
+
+	parent := vm.LastAcceptedBlockInternal().(*Block)
+	key := testKeys[0]
+	exportTx := &UnsignedExportTx{
+		NetworkID:        vm.ctx.NetworkID,
+		BlockchainID:     vm.ctx.ChainID,
+		DestinationChain: vm.ctx.XChainID,
+		Ins: []EVMInput{
+			{
+				Address: testEthAddrs[0],
+				Amount:  units.Avax,
+				AssetID: vm.ctx.AVAXAssetID,
+				Nonce:   0,
+			},
+		},
+		ExportedOutputs: []*avax.TransferableOutput{
+			{
+				Asset: avax.Asset{ID: vm.ctx.AVAXAssetID},
+				Out: &secp256k1fx.TransferOutput{
+					Amt: units.Avax,
+					OutputOwners: secp256k1fx.OutputOwners{
+						Threshold: 1,
+						Addrs:     []ids.ShortID{key.PublicKey().Address()},
+					},
+				},
+			},
+		},
+	}
+	tx := &Tx{UnsignedAtomicTx: exportTx}
+	if err := tx.Sign(vm.codec, [][]*crypto.PrivateKeySECP256K1R{{key}}); err != nil {
+		t.Fatal(err)
+	}
+
+	defaultGasUsed, err := exportTx.GasUsed(params.DefaultAtomicTxFeeConfig, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	customGasUsed, err := exportTx.GasUsed(customFees, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	numBytes := uint64(len(exportTx.UnsignedBytes()))
+	if expected := numBytes + secp256k1fx.CostPerSignature + params.AtomicTxBaseCost; defaultGasUsed != expected {
+		t.Fatalf("Expected default gas used to be %d, but found %d", expected, defaultGasUsed)
+	}
+	if expected := 2*numBytes + secp256k1fx.CostPerSignature + 2*params.AtomicTxBaseCost; customGasUsed != expected {
+		t.Fatalf("Expected custom gas used to be %d, but found %d", expected, customGasUsed)
+	}
+
+	// Burn exactly the fee required by the default fee config. The amount is
+	// fixed size, so updating it does not change the gas used.
+	fee, err := calculateDynamicFee(defaultGasUsed, initialBaseFee)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exportTx.Ins[0].Amount += fee
+	tx = &Tx{UnsignedAtomicTx: exportTx}
+	if err := tx.Sign(vm.codec, [][]*crypto.PrivateKeySECP256K1R{{key}}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := exportTx.SemanticVerify(vm, tx, parent, initialBaseFee, apricotRulesPhase5); err != nil {
+		t.Fatalf("Expected tx burning the default fee to pass verification under the default fee config, but failed due to: %s", err)
+	}
+	if err := exportTx.SemanticVerify(vm, tx, parent, initialBaseFee, customRules); err == nil {
+		t.Fatal("Expected tx burning the default fee to fail verification under the custom fee config")
+	}
+}
+
+func TestExportTxAccept(t *testing.T) {
+	_, vm, _, sharedMemory, _ := GenesisVM(t, true, genesisJSONApricotPhase0, "", "")
+
+	xChainSharedMemory := sharedMemory.NewSharedMemory(vm.ctx.XChainID)
+
+	defer func() {
+		if err := vm.Shutdown(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	key := testKeys[0]
+	addr := key.PublicKey().Address()
+	ethAddr := testEthAddrs[0]
+
+	var (
+		avaxBalance           = 10 * units.Avax
+		custom0Balance uint64 = 100
+		custom0AssetID        = ids.ID{1, 2, 3, 4, 5}
+	)
+
+	exportTx := &UnsignedExportTx{
+		NetworkID:        vm.ctx.NetworkID,
+		BlockchainID:     vm.ctx.ChainID,
+		DestinationChain: vm.ctx.XChainID,
+		Ins: []EVMInput{
+			{
+				Address: ethAddr,
+				Amount:  avaxBalance,
+				AssetID: vm.ctx.AVAXAssetID,
+				Nonce:   0,
+			},
+			{
+				Address: ethAddr,
+				Amount:  custom0Balance,
+				AssetID: custom0AssetID,
+				Nonce:   0,
+			},
+		},
+		ExportedOutputs: []*avax.TransferableOutput{
+			{
+				Asset: avax.Asset{ID: vm.ctx.AVAXAssetID},
+				Out: &secp256k1fx.TransferOutput{
+					Amt: avaxBalance,
+					OutputOwners: secp256k1fx.OutputOwners{
+						Threshold: 1,
+						Addrs:     []ids.ShortID{addr},
+					},
+				},
+			},
+			{
+				Asset: avax.Asset{ID: custom0AssetID},
+				Out: &secp256k1fx.TransferOutput{
+					Amt: custom0Balance,
+					OutputOwners: secp256k1fx.OutputOwners{
+						Threshold: 1,
+						Addrs:     []ids.ShortID{addr},
+					},
+				},
+			},
+		},
+	}
+
+	tx := &Tx{UnsignedAtomicTx: exportTx}
+
+	signers := [][]*crypto.PrivateKeySECP256K1R{
+		{key},
+		{key},
+		{key},
+	}
+
+	if err := tx.Sign(vm.codec, signers); err != nil {
+		t.Fatal(err)
+	}
+
+	commitBatch, err := vm.db.CommitBatch()
+	if err != nil {
+		t.Fatalf("Failed to create commit batch for VM due to %s", err)
+	}
+	chainID, atomicRequests, err := tx.AtomicOps()
+	if err != nil {
+		t.Fatalf("Failed to accept export transaction due to: %s", err)
+	}
+
+	if err := vm.ctx.SharedMemory.Apply(map[ids.ID]*atomic.Requests{chainID: {PutRequests: atomicRequests.PutRequests}}, commitBatch); err != nil {
+		t.Fatal(err)
+	}
+	indexedValues, _, _, err := xChainSharedMemory.Indexed(vm.ctx.ChainID, [][]byte{addr.Bytes()}, nil, nil, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(indexedValues) != 2 {
+		t.Fatalf("expected 2 values but got %d", len(indexedValues))
+	}
+
+	avaxUTXOID := avax.UTXOID{
+		TxID:        tx.ID(),
+		OutputIndex: 0,
+	}
+	avaxInputID := avaxUTXOID.InputID()
+
+	customUTXOID := avax.UTXOID{
+		TxID:        tx.ID(),
+		OutputIndex: 1,
+	}
+	customInputID := customUTXOID.InputID()
+
+	fetchedValues, err := xChainSharedMemory.Get(vm.ctx.ChainID, [][]byte{
+		customInputID[:],
+		avaxInputID[:],
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(fetchedValues[0], indexedValues[0]) {
+		t.Fatalf("inconsistent values returned fetched %x indexed %x", fetchedValues[0], indexedValues[0])
+	}
+	if !bytes.Equal(fetchedValues[1], indexedValues[1]) {
+		t.Fatalf("inconsistent values returned fetched %x indexed %x", fetchedValues[1], indexedValues[1])
+	}
+
+	customUTXOBytes, err := Codec.Marshal(codecVersion, &avax.UTXO{
+		UTXOID: customUTXOID,
+		Asset:  avax.Asset{ID: custom0AssetID},
+		Out:    exportTx.ExportedOutputs[1].Out,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	avaxUTXOBytes, err := Codec.Marshal(codecVersion, &avax.UTXO{
+		UTXOID: avaxUTXOID,
+		Asset:  avax.Asset{ID: vm.ctx.AVAXAssetID},
+		Out:    exportTx.ExportedOutputs[0].Out,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(fetchedValues[0], customUTXOBytes) {
+		t.Fatalf("incorrect values returned expected %x got %x", customUTXOBytes, fetchedValues[0])
+	}
+	if !bytes.Equal(fetchedValues[1], avaxUTXOBytes) {
+		t.Fatalf("incorrect values returned expected %x got %x", avaxUTXOBytes, fetchedValues[1])
+	}
+}
+
+func TestExportTxAtomicOpsWithUTXOs(t *testing.T) {
+	addr := testKeys[0].PublicKey().Address()
+	custom0AssetID := ids.ID{1, 2, 3, 4, 5}
+
+	exportTx := &UnsignedExportTx{
+		NetworkID:        testNetworkID,
+		BlockchainID:     testCChainID,
+		DestinationChain: testXChainID,
+		Ins: []EVMInput{
+			{
+				Address: testEthAddrs[0],
+				Amount:  units.Avax,
+				AssetID: testAvaxAssetID,
+				Nonce:   0,
+			},
+		},
+		ExportedOutputs: []*avax.TransferableOutput{
+			{
+				Asset: avax.Asset{ID: testAvaxAssetID},
+				Out: &secp256k1fx.TransferOutput{
+					Amt: units.Avax,
+					OutputOwners: secp256k1fx.OutputOwners{
+						Threshold: 1,
+						Addrs:     []ids.ShortID{addr},
+					},
+				},
+			},
+			{
+				Asset: avax.Asset{ID: custom0AssetID},
+				Out: &secp256k1fx.TransferOutput{
+					Amt: 100,
+					OutputOwners: secp256k1fx.OutputOwners{
+						Threshold: 1,
+						Addrs:     []ids.ShortID{addr},
+					},
+				},
+			},
+		},
+	}
+	tx := &Tx{UnsignedAtomicTx: exportTx}
+	if err := tx.Sign(Codec, [][]*crypto.PrivateKeySECP256K1R{{testKeys[0]}}); err != nil {
+		t.Fatal(err)
+	}
+
+	chainID, atomicRequests, utxoIDs, err := exportTx.AtomicOpsWithUTXOs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if chainID != testXChainID {
+		t.Fatalf("expected chainID %s but got %s", testXChainID, chainID)
+	}
+	if len(utxoIDs) != len(exportTx.ExportedOutputs) {
+		t.Fatalf("expected %d UTXO IDs but got %d", len(exportTx.ExportedOutputs), len(utxoIDs))
+	}
+	for i, utxoID := range utxoIDs {
+		expectedUTXOID := avax.UTXOID{
+			TxID:        tx.ID(),
+			OutputIndex: uint32(i),
+		}
+		if expected := expectedUTXOID.InputID(); utxoID != expected {
+			t.Fatalf("expected UTXO ID %s at index %d but got %s", expected, i, utxoID)
+		}
+		if !bytes.Equal(atomicRequests.PutRequests[i].Key, utxoID[:]) {
+			t.Fatalf("expected put request key at index %d to match UTXO ID %s", i, utxoID)
+		}
+	}
+}
+
+// Identical outputs are still exported as distinct UTXOs, so that shared
+// memory keeps every one of them.
+func TestExportTxAtomicOpsIdenticalOutputs(t *testing.T) {
+	addr := testKeys[0].PublicKey().Address()
+	out := &avax.TransferableOutput{
+		Asset: avax.Asset{ID: testAvaxAssetID},
+		Out: &secp256k1fx.TransferOutput{
+			Amt: units.Avax,
+			OutputOwners: secp256k1fx.OutputOwners{
+				Threshold: 1,
+				Addrs:     []ids.ShortID{addr},
+			},
+		},
+	}
+	exportTx := &UnsignedExportTx{
+		NetworkID:        testNetworkID,
+		BlockchainID:     testCChainID,
+		DestinationChain: testXChainID,
+		Ins: []EVMInput{
+			{
+				Address: testEthAddrs[0],
+				Amount:  2 * units.Avax,
+				AssetID: testAvaxAssetID,
+				Nonce:   0,
+			},
+		},
+		ExportedOutputs: []*avax.TransferableOutput{out, out},
+	}
+	tx := &Tx{UnsignedAtomicTx: exportTx}
+	if err := tx.Sign(Codec, [][]*crypto.PrivateKeySECP256K1R{{testKeys[0]}}); err != nil {
+		t.Fatal(err)
+	}
+	_, atomicRequests, err := exportTx.AtomicOps()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(atomicRequests.PutRequests) != 2 {
+		t.Fatalf("expected 2 put requests but got %d", len(atomicRequests.PutRequests))
+	}
+	if bytes.Equal(atomicRequests.PutRequests[0].Key, atomicRequests.PutRequests[1].Key) {
+		t.Fatal("expected identical outputs to be exported with distinct UTXO IDs")
+	}
+}
+
+func TestExportTxVerifyNil(t *testing.T) {
+	var exportTx *UnsignedExportTx
+	if err := exportTx.Verify(NewContext(), apricotRulesPhase0); err == nil {
+		t.Fatal("Verify should have failed due to nil transaction")
+	}
+}
+
+func TestExportTxVerify(t *testing.T) {
+	var exportAmount uint64 = 10000000
+	exportTx := &UnsignedExportTx{
+		NetworkID:        testNetworkID,
+		BlockchainID:     testCChainID,
+		DestinationChain: testXChainID,
+		Ins: []EVMInput{
+			{
+				Address: testEthAddrs[0],
+				Amount:  exportAmount,
+				AssetID: testAvaxAssetID,
+				Nonce:   0,
+			},
+			{
+				Address: testEthAddrs[2],
+				Amount:  exportAmount,
+				AssetID: testAvaxAssetID,
+				Nonce:   0,
+			},
+		},
+		ExportedOutputs: []*avax.TransferableOutput{
+			{
+				Asset: avax.Asset{ID: testAvaxAssetID},
+				Out: &secp256k1fx.TransferOutput{
+					Amt: exportAmount,
+					OutputOwners: secp256k1fx.OutputOwners{
+						Locktime:  0,
+						Threshold: 1,
+						Addrs:     []ids.ShortID{testShortIDAddrs[0]},
+					},
+				},
+			},
+			{
+				Asset: avax.Asset{ID: testAvaxAssetID},
+				Out: &secp256k1fx.TransferOutput{
+					Amt: exportAmount,
+					OutputOwners: secp256k1fx.OutputOwners{
+						Locktime:  0,
+						Threshold: 1,
+						Addrs:     []ids.ShortID{testShortIDAddrs[1]},
 					},
 				},
 			},
@@ -1197,6 +1986,20 @@ func TestExportTxVerify(t *testing.T) {
 	if err := exportTx.Verify(ctx, apricotRulesPhase1); err == nil {
 		t.Fatal("ExportTx should have failed verification due to 0 value amount")
 	}
+	zeroInput := evmInputs[1]
+	zeroInput.Amount = 0
+	exportTx.Ins = []EVMInput{evmInputs[0], zeroInput}
+	// Test a 0 value EVM Input mixed with valid inputs fails verification
+	// regardless of the rules, and before the inputs are checked for sorting
+	for _, rules := range []params.Rules{apricotRulesPhase0, apricotRulesPhase1} {
+		if err := exportTx.Verify(ctx, rules); err != errNoValueInput {
+			t.Fatalf("ExportTx should have failed verification due to %s, but found: %v", errNoValueInput, err)
+		}
+	}
+	exportTx.Ins = []EVMInput{zeroInput, evmInputs[0]}
+	if err := exportTx.Verify(ctx, apricotRulesPhase1); err != errNoValueInput {
+		t.Fatalf("ExportTx should have failed verification due to %s, but found: %v", errNoValueInput, err)
+	}
 	exportTx.Ins = []EVMInput{evmInputs[0], evmInputs[0]}
 	// Test non-unique EVM Inputs passes verification before AP1
 	if err := exportTx.Verify(ctx, apricotRulesPhase0); err != nil {
@@ -1207,6 +2010,155 @@ func TestExportTxVerify(t *testing.T) {
 	if err := exportTx.Verify(ctx, apricotRulesPhase1); err == nil {
 		t.Fatal("ExportTx should have failed verification due to non-unique inputs")
 	}
+	exportTx.Ins = nil
+	// Test ExportTx with exported outputs but no EVM Inputs fails verification
+	// regardless of the rules
+	for _, rules := range []params.Rules{apricotRulesPhase0, apricotRulesPhase5} {
+		if err := exportTx.Verify(ctx, rules); err != errNoExportInputs {
+			t.Fatalf("ExportTx should have failed verification due to %s, but found: %v", errNoExportInputs, err)
+		}
+	}
+	exportTx.Ins = evmInputs
+	overflowOuts := []*avax.TransferableOutput{exportedOuts[0], exportedOuts[1]}
+	for i, out := range overflowOuts {
+		overflowOut := *out
+		overflowOut.Out = &secp256k1fx.TransferOutput{
+			Amt:          math.MaxUint64/2 + 1,
+			OutputOwners: out.Out.(*secp256k1fx.TransferOutput).OutputOwners,
+		}
+		overflowOuts[i] = &overflowOut
+	}
+	exportTx.ExportedOutputs = overflowOuts
+	// Test ExportTx with outputs of the same asset summing past MaxUint64
+	// fails verification regardless of the rules
+	for _, rules := range []params.Rules{apricotRulesPhase0, apricotRulesPhase5} {
+		if err := exportTx.Verify(ctx, rules); !errors.Is(err, errOverflowExportedOutputs) {
+			t.Fatalf("ExportTx should have failed verification due to %s, but found: %v", errOverflowExportedOutputs, err)
+		}
+	}
+	maxAddrs := maxOutputOwnerAddrs(params.DefaultAtomicTxFeeConfig)
+	manyAddrs := make([]ids.ShortID, maxAddrs+1)
+	for i := range manyAddrs {
+		manyAddrs[i] = ids.ShortID{byte(i >> 24), byte(i >> 16), byte(i >> 8), byte(i)}
+	}
+	ids.SortShortIDs(manyAddrs)
+	manyAddrsOut := *exportedOuts[0]
+	manyAddrsOut.Out = &secp256k1fx.TransferOutput{
+		Amt: exportedOuts[0].Out.Amount(),
+		OutputOwners: secp256k1fx.OutputOwners{
+			Threshold: 1,
+			Addrs:     manyAddrs,
+		},
+	}
+	exportTx.ExportedOutputs = []*avax.TransferableOutput{&manyAddrsOut}
+	// Test ExportTx with an output owner listing too many addresses fails
+	// verification after AP5
+	if err := exportTx.Verify(ctx, apricotRulesPhase5); !errors.Is(err, errTooManyOwnerAddrs) {
+		t.Fatalf("ExportTx should have failed verification due to %s, but found: %v", errTooManyOwnerAddrs, err)
+	}
+	if err := exportTx.Verify(ctx, apricotRulesPhase4); errors.Is(err, errTooManyOwnerAddrs) {
+		t.Fatalf("ExportTx should not have failed verification due to %s before AP5", errTooManyOwnerAddrs)
+	}
+	manyAddrsOut.Out.(*secp256k1fx.TransferOutput).Addrs = manyAddrs[:maxAddrs]
+	if err := exportTx.Verify(ctx, apricotRulesPhase5); errors.Is(err, errTooManyOwnerAddrs) {
+		t.Fatalf("ExportTx should not have failed verification due to %s at the cap", errTooManyOwnerAddrs)
+	}
+	exportTx.ExportedOutputs = exportedOuts
+	exportTx.DestinationChain = exportTx.BlockchainID
+	// Test ExportTx to this chain fails verification regardless of the rules
+	for _, rules := range []params.Rules{apricotRulesPhase0, apricotRulesPhase5} {
+		if err := exportTx.Verify(ctx, rules); err != errWrongChainID {
+			t.Fatalf("ExportTx should have failed verification due to %s, but found: %v", errWrongChainID, err)
+		}
+	}
+}
+
+// Ensure that an export tx can be verified without a VM or snow context
+func TestExportTxVerifyStateless(t *testing.T) {
+	key := testKeys[0]
+	newTx := func() *UnsignedExportTx {
+		return &UnsignedExportTx{
+			NetworkID:        testNetworkID,
+			BlockchainID:     testCChainID,
+			DestinationChain: testXChainID,
+			Ins: []EVMInput{
+				{
+					Address: testEthAddrs[0],
+					Amount:  units.Avax,
+					AssetID: testAvaxAssetID,
+					Nonce:   0,
+				},
+			},
+			ExportedOutputs: []*avax.TransferableOutput{
+				{
+					Asset: avax.Asset{ID: testAvaxAssetID},
+					Out: &secp256k1fx.TransferOutput{
+						Amt: units.Avax / 2,
+						OutputOwners: secp256k1fx.OutputOwners{
+							Threshold: 1,
+							Addrs:     []ids.ShortID{testShortIDAddrs[0]},
+						},
+					},
+				},
+			},
+		}
+	}
+
+	tests := map[string]struct {
+		modify      func(utx *UnsignedExportTx)
+		signers     [][]*crypto.PrivateKeySECP256K1R
+		destChainID ids.ID
+		expectedErr error
+	}{
+		"valid": {
+			signers:     [][]*crypto.PrivateKeySECP256K1R{{key}},
+			destChainID: testXChainID,
+		},
+		"wrong network ID": {
+			modify:      func(utx *UnsignedExportTx) { utx.NetworkID++ },
+			signers:     [][]*crypto.PrivateKeySECP256K1R{{key}},
+			destChainID: testXChainID,
+			expectedErr: errWrongNetworkID,
+		},
+		"unexpected destination chain": {
+			signers:     [][]*crypto.PrivateKeySECP256K1R{{key}},
+			destChainID: constants.PlatformChainID,
+			expectedErr: errWrongChainID,
+		},
+		"no outputs": {
+			modify:      func(utx *UnsignedExportTx) { utx.ExportedOutputs = nil },
+			signers:     [][]*crypto.PrivateKeySECP256K1R{{key}},
+			destChainID: testXChainID,
+			expectedErr: errNoExportOutputs,
+		},
+		"missing credential": {
+			signers:     [][]*crypto.PrivateKeySECP256K1R{},
+			destChainID: testXChainID,
+			expectedErr: errSignatureInputsMismatch,
+		},
+		"wrong signer": {
+			signers:     [][]*crypto.PrivateKeySECP256K1R{{testKeys[1]}},
+			destChainID: testXChainID,
+			expectedErr: errPublicKeySignatureMismatch,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			utx := newTx()
+			if test.modify != nil {
+				test.modify(utx)
+			}
+			tx := &Tx{UnsignedAtomicTx: utx}
+			if err := tx.Sign(Codec, test.signers); err != nil {
+				t.Fatal(err)
+			}
+
+			err := utx.VerifyStateless(testNetworkID, testCChainID, test.destChainID, testAvaxAssetID, apricotRulesPhase5, tx.Creds)
+			if !errors.Is(err, test.expectedErr) {
+				t.Fatalf("expected %v but found %v", test.expectedErr, err)
+			}
+		})
+	}
 }
 
 // Note: this is a brittle test to ensure that the gas cost of a transaction does
@@ -1455,7 +2407,7 @@ func TestExportTxGasCost(t *testing.T) {
 				t.Fatal(err)
 			}
 
-			gasUsed, err := tx.GasUsed(test.FixedFee)
+			gasUsed, err := tx.GasUsed(params.DefaultAtomicTxFeeConfig, test.FixedFee)
 			if err != nil {
 				t.Fatal(err)
 			}
@@ -1550,101 +2502,642 @@ func TestNewExportTx(t *testing.T) {
 					},
 				},
 			}
-			utxoBytes, err := vm.codec.Marshal(codecVersion, utxo)
+			utxoBytes, err := vm.codec.Marshal(codecVersion, utxo)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			xChainSharedMemory := sharedMemory.NewSharedMemory(vm.ctx.XChainID)
+			inputID := utxo.InputID()
+			if err := xChainSharedMemory.Apply(map[ids.ID]*atomic.Requests{vm.ctx.ChainID: {PutRequests: []*atomic.Element{{
+				Key:   inputID[:],
+				Value: utxoBytes,
+				Traits: [][]byte{
+					testKeys[0].PublicKey().Address().Bytes(),
+				},
+			}}}}); err != nil {
+				t.Fatal(err)
+			}
+
+			tx, err := vm.newImportTx(vm.ctx.XChainID, testEthAddrs[0], initialBaseFee, []*crypto.PrivateKeySECP256K1R{testKeys[0]})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if err := vm.issueTx(tx, true /*=local*/); err != nil {
+				t.Fatal(err)
+			}
+
+			<-issuer
+
+			blk, err := vm.BuildBlock()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if err := blk.Verify(); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := vm.SetPreference(blk.ID()); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := blk.Accept(); err != nil {
+				t.Fatal(err)
+			}
+
+			parent = vm.LastAcceptedBlockInternal().(*Block)
+			exportAmount := uint64(5000000)
+
+			tx, err = vm.newExportTx(vm.ctx.AVAXAssetID, exportAmount, vm.ctx.XChainID, testShortIDAddrs[0], initialBaseFee, []*crypto.PrivateKeySECP256K1R{testKeys[0]})
 			if err != nil {
 				t.Fatal(err)
 			}
 
-			xChainSharedMemory := sharedMemory.NewSharedMemory(vm.ctx.XChainID)
-			inputID := utxo.InputID()
-			if err := xChainSharedMemory.Apply(map[ids.ID]*atomic.Requests{vm.ctx.ChainID: {PutRequests: []*atomic.Element{{
-				Key:   inputID[:],
-				Value: utxoBytes,
-				Traits: [][]byte{
-					testKeys[0].PublicKey().Address().Bytes(),
-				},
-			}}}}); err != nil {
+			exportTx := tx.UnsignedAtomicTx
+
+			if err := exportTx.SemanticVerify(vm, tx, parent, parent.ethBlock.BaseFee(), test.rules); err != nil {
+				t.Fatal("newExportTx created an invalid transaction", err)
+			}
+
+			burnedAVAX, err := exportTx.Burned(vm.ctx.AVAXAssetID)
+			if err != nil {
 				t.Fatal(err)
 			}
+			if burnedAVAX != test.expectedBurnedAVAX {
+				t.Fatalf("burned wrong amount of AVAX - expected %d burned %d", test.expectedBurnedAVAX, burnedAVAX)
+			}
+
+			commitBatch, err := vm.db.CommitBatch()
+			if err != nil {
+				t.Fatalf("Failed to create commit batch for VM due to %s", err)
+			}
+			chainID, atomicRequests, err := exportTx.AtomicOps()
 
-			tx, err := vm.newImportTx(vm.ctx.XChainID, testEthAddrs[0], initialBaseFee, []*crypto.PrivateKeySECP256K1R{testKeys[0]})
 			if err != nil {
+				t.Fatalf("Failed to accept export transaction due to: %s", err)
+			}
+
+			if err := vm.ctx.SharedMemory.Apply(map[ids.ID]*atomic.Requests{chainID: {PutRequests: atomicRequests.PutRequests}}, commitBatch); err != nil {
 				t.Fatal(err)
 			}
 
-			if err := vm.issueTx(tx, true /*=local*/); err != nil {
+			sdb, err := vm.chain.CurrentState()
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = exportTx.EVMStateTransfer(vm.ctx, sdb)
+			if err != nil {
 				t.Fatal(err)
 			}
 
-			<-issuer
+			addr := GetEthAddress(testKeys[0])
+			if sdb.GetBalance(addr).Cmp(new(big.Int).SetUint64(test.bal*units.Avax)) != 0 {
+				t.Fatalf("address balance %s equal %s not %s", addr.String(), sdb.GetBalance(addr), new(big.Int).SetUint64(test.bal*units.Avax))
+			}
+		})
+	}
+}
 
-			blk, err := vm.BuildBlock()
+// Ensure that an export tx can take the exported AVAX from one key and the fee
+// from another.
+func TestNewExportTxWithFeePayer(t *testing.T) {
+	genesisJSON, err := fundAddressByGenesis([]common.Address{testEthAddrs[0], testEthAddrs[1]})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, vm, _, _, _ := GenesisVM(t, true, genesisJSON, "", "")
+	defer func() {
+		if err := vm.Shutdown(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	parent := vm.LastAcceptedBlockInternal().(*Block)
+	amountKey, feeKey := testKeys[1], testKeys[0]
+	exportAmount := uint64(10000000)
+	tx, err := vm.newExportTxWithFeePayer(
+		vm.ctx.AVAXAssetID,
+		exportAmount,
+		vm.ctx.XChainID,
+		testShortIDAddrs[0],
+		initialBaseFee,
+		[]*crypto.PrivateKeySECP256K1R{amountKey},
+		// The amount key is not used to pay the fee, as it already provides
+		// the exported AVAX
+		[]*crypto.PrivateKeySECP256K1R{amountKey, feeKey},
+		ExportTxOptions{},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	exportTx := tx.UnsignedAtomicTx.(*UnsignedExportTx)
+	if len(exportTx.Ins) != 2 {
+		t.Fatalf("Expected 2 inputs, but found %d", len(exportTx.Ins))
+	}
+	for _, in := range exportTx.Ins {
+		switch in.Address {
+		case testEthAddrs[1]:
+			if in.Amount != exportAmount {
+				t.Fatalf("Expected the amount key to provide %d, but found %d", exportAmount, in.Amount)
+			}
+		case testEthAddrs[0]:
+			burned, err := exportTx.Burned(vm.ctx.AVAXAssetID)
 			if err != nil {
 				t.Fatal(err)
 			}
+			if in.Amount != burned {
+				t.Fatalf("Expected the fee key to provide the fee %d, but found %d", burned, in.Amount)
+			}
+		default:
+			t.Fatalf("Unexpected input address %s", in.Address)
+		}
+	}
 
-			if err := blk.Verify(); err != nil {
+	if err := exportTx.SemanticVerify(vm, tx, parent, initialBaseFee, vm.currentRules()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSignExportTxMismatchedSigners(t *testing.T) {
+	genesisJSON, err := fundAddressByGenesis([]common.Address{testEthAddrs[0], testEthAddrs[1]})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, vm, _, _, _ := GenesisVM(t, true, genesisJSON, `{"verify-export-tx-credentials": true}`, "")
+	defer func() {
+		if err := vm.Shutdown(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	tx, err := vm.newExportTxWithFeePayer(
+		vm.ctx.AVAXAssetID,
+		uint64(10000000),
+		vm.ctx.XChainID,
+		testShortIDAddrs[0],
+		initialBaseFee,
+		[]*crypto.PrivateKeySECP256K1R{testKeys[1]},
+		[]*crypto.PrivateKeySECP256K1R{testKeys[0]},
+		ExportTxOptions{},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	utx := tx.UnsignedAtomicTx.(*UnsignedExportTx)
+	if len(utx.Ins) != 2 {
+		t.Fatalf("Expected 2 inputs, but found %d", len(utx.Ins))
+	}
+
+	keys := map[common.Address]*crypto.PrivateKeySECP256K1R{
+		testEthAddrs[0]: testKeys[0],
+		testEthAddrs[1]: testKeys[1],
+	}
+	signers := make([][]*crypto.PrivateKeySECP256K1R, len(utx.Ins))
+	for i, in := range utx.Ins {
+		signers[i] = []*crypto.PrivateKeySECP256K1R{keys[in.Address]}
+	}
+	if _, err := vm.signExportTx(utx, signers); err != nil {
+		t.Fatalf("Expected signers ordered as the inputs to be accepted, but found %s", err)
+	}
+
+	// Signing with the signers out of order passes syntactic verification, but
+	// not the credentials check
+	signers[0], signers[1] = signers[1], signers[0]
+	if _, err := vm.signExportTx(utx, signers); !errors.Is(err, errPublicKeySignatureMismatch) {
+		t.Fatalf("Expected mis-ordered signers to fail with %s, but found %v", errPublicKeySignatureMismatch, err)
+	}
+
+	// The check is only performed if it is enabled
+	vm.config.VerifyExportTxCredentials = false
+	if _, err := vm.signExportTx(utx, signers); err != nil {
+		t.Fatalf("Expected mis-ordered signers to be accepted without the credentials check, but found %s", err)
+	}
+}
+
+func TestNewExportTxEmptyBlockchainID(t *testing.T) {
+	_, vm, _, _, _ := GenesisVM(t, true, genesisJSONApricotPhase0, "", "")
+	defer func() {
+		if err := vm.Shutdown(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	vm.ctx.ChainID = ids.Empty
+	_, err := vm.newExportTx(vm.ctx.AVAXAssetID, units.Avax, vm.ctx.XChainID, testShortIDAddrs[0], initialBaseFee, []*crypto.PrivateKeySECP256K1R{testKeys[0]})
+	if !errors.Is(err, errEmptyBlockchainID) {
+		t.Fatalf("Expected building an export tx with an empty chain ID to fail with %s, but found %v", errEmptyBlockchainID, err)
+	}
+}
+
+func TestNewExportTxLargestFirst(t *testing.T) {
+	factory := crypto.FactorySECP256K1R{}
+	keys := make([]*crypto.PrivateKeySECP256K1R, 5)
+	balances := make(map[common.Address]*big.Int, len(keys))
+	for i := range keys {
+		keyIntf, err := factory.NewPrivateKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		keys[i] = keyIntf.(*crypto.PrivateKeySECP256K1R)
+		// Every key holds 1 AVAX, except for the last one which holds 100 AVAX
+		balance := new(big.Int).Mul(big.NewInt(units.Avax), x2cRate)
+		if i == len(keys)-1 {
+			balance.Mul(balance, big.NewInt(100))
+		}
+		balances[GetEthAddress(keys[i])] = balance
+	}
+	largeAddr := GetEthAddress(keys[len(keys)-1])
+
+	genesisJSON, err := fundAddressesByGenesis(balances)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, vm, _, _, _ := GenesisVM(t, true, genesisJSON, "", "")
+	defer func() {
+		if err := vm.Shutdown(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	exportAmount := 3 * units.Avax
+	ins, _, err := vm.GetSpendableFundsLargestFirst(keys, vm.ctx.AVAXAssetID, exportAmount)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ins) != 1 || ins[0].Address != largeAddr {
+		t.Fatalf("Expected only the largest balance to be spent, but found %+v", ins)
+	}
+
+	// By default, the small balances are spent in the order of the keys
+	tx, err := vm.newExportTx(vm.ctx.AVAXAssetID, exportAmount, vm.ctx.XChainID, testShortIDAddrs[0], initialBaseFee, keys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if numIns := len(tx.UnsignedAtomicTx.(*UnsignedExportTx).Ins); numIns <= 1 {
+		t.Fatalf("Expected the small balances to be spent, but found %d inputs", numIns)
+	}
+
+	tx, err = vm.newExportTxWithFeePayer(vm.ctx.AVAXAssetID, exportAmount, vm.ctx.XChainID, testShortIDAddrs[0], initialBaseFee, keys, nil, ExportTxOptions{LargestFirst: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	exportTx := tx.UnsignedAtomicTx.(*UnsignedExportTx)
+	if len(exportTx.Ins) != 1 || exportTx.Ins[0].Address != largeAddr {
+		t.Fatalf("Expected only the largest balance to be spent, but found %+v", exportTx.Ins)
+	}
+}
+
+func TestNewExportTxRelativeLocktime(t *testing.T) {
+	genesisJSON, err := fundAddressByGenesis([]common.Address{testEthAddrs[0]})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, vm, _, _, _ := GenesisVM(t, true, genesisJSON, "", "")
+	defer func() {
+		if err := vm.Shutdown(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	now := time.Unix(1_000_000, 0)
+	vm.clock.Set(now)
+	lockDuration := 7 * 24 * time.Hour
+	tx, err := vm.newExportTxWithFeePayer(vm.ctx.AVAXAssetID, units.MilliAvax, vm.ctx.XChainID, testShortIDAddrs[0], initialBaseFee, []*crypto.PrivateKeySECP256K1R{testKeys[0]}, nil, ExportTxOptions{LockDuration: lockDuration})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := tx.UnsignedAtomicTx.(*UnsignedExportTx).ExportedOutputs[0].Out.(*secp256k1fx.TransferOutput)
+	if expected := uint64(now.Add(lockDuration).Unix()); out.Locktime != expected {
+		t.Fatalf("expected locktime %d, but found %d", expected, out.Locktime)
+	}
+
+	// A locktime that is not in the future is refused
+	for _, lockDuration := range []time.Duration{-time.Hour, time.Millisecond} {
+		_, err := vm.newExportTxWithFeePayer(vm.ctx.AVAXAssetID, units.MilliAvax, vm.ctx.XChainID, testShortIDAddrs[0], initialBaseFee, []*crypto.PrivateKeySECP256K1R{testKeys[0]}, nil, ExportTxOptions{LockDuration: lockDuration})
+		if !errors.Is(err, errLocktimeNotInFuture) {
+			t.Fatalf("expected %s for a lock duration of %s, but found: %v", errLocktimeNotInFuture, lockDuration, err)
+		}
+	}
+}
+
+func TestNewExportTxFromInputs(t *testing.T) {
+	genesisJSON, err := fundAddressByGenesis([]common.Address{testEthAddrs[0]})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, vm, _, _, _ := GenesisVM(t, true, genesisJSON, "", "")
+	defer func() {
+		if err := vm.Shutdown(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	newInputs := func(amount uint64) []EVMInput {
+		return []EVMInput{{
+			Address: testEthAddrs[0],
+			Amount:  amount,
+			AssetID: vm.ctx.AVAXAssetID,
+			Nonce:   0,
+		}}
+	}
+	outs := []*avax.TransferableOutput{{
+		Asset: avax.Asset{ID: vm.ctx.AVAXAssetID},
+		Out: &secp256k1fx.TransferOutput{
+			Amt: units.MilliAvax,
+			OutputOwners: secp256k1fx.OutputOwners{
+				Threshold: 1,
+				Addrs:     []ids.ShortID{testShortIDAddrs[0]},
+			},
+		},
+	}}
+	signers := [][]*crypto.PrivateKeySECP256K1R{{testKeys[0]}}
+
+	tx, err := vm.newExportTxFromInputs(newInputs(10*units.MilliAvax), signers, outs, ids.ShortEmpty, vm.ctx.XChainID, initialBaseFee)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parent := vm.LastAcceptedBlockInternal().(*Block)
+	if err := tx.UnsignedAtomicTx.SemanticVerify(vm, tx, parent, initialBaseFee, vm.currentRules()); err != nil {
+		t.Fatalf("export tx built from inputs failed verification: %s", err)
+	}
+
+	// Inputs that don't cover the fee are refused
+	if _, err := vm.newExportTxFromInputs(newInputs(units.MilliAvax), signers, outs, ids.ShortEmpty, vm.ctx.XChainID, initialBaseFee); err == nil {
+		t.Fatal("expected inputs that don't cover the fee to be refused")
+	}
+	// Every input must have a signer
+	if _, err := vm.newExportTxFromInputs(newInputs(10*units.MilliAvax), nil, outs, ids.ShortEmpty, vm.ctx.XChainID, initialBaseFee); !errors.Is(err, errSignatureInputsMismatch) {
+		t.Fatalf("expected %s, but found: %v", errSignatureInputsMismatch, err)
+	}
+
+	// The inputs provide more than needed, so the change is exported to the
+	// change address rather than burned
+	changeAddr := testShortIDAddrs[1]
+	tx, err = vm.newExportTxFromInputs(newInputs(10*units.MilliAvax), signers, outs, changeAddr, vm.ctx.XChainID, initialBaseFee)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.UnsignedAtomicTx.SemanticVerify(vm, tx, parent, initialBaseFee, vm.currentRules()); err != nil {
+		t.Fatalf("export tx with change failed verification: %s", err)
+	}
+	rules := vm.currentRules()
+	gasUsed, err := tx.GasUsed(rules.AtomicTxFeeConfig, rules.IsApricotPhase5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fee, err := calculateDynamicFee(gasUsed, initialBaseFee)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var change uint64
+	for _, out := range tx.UnsignedAtomicTx.(*UnsignedExportTx).ExportedOutputs {
+		owners := out.Out.(*secp256k1fx.TransferOutput).OutputOwners
+		if len(owners.Addrs) == 1 && owners.Addrs[0] == changeAddr {
+			change += out.Out.Amount()
+		}
+	}
+	if expected := 10*units.MilliAvax - units.MilliAvax - fee; change != expected {
+		t.Fatalf("Expected change of %d, but found %d", expected, change)
+	}
+	if burned, err := tx.Burned(vm.ctx.AVAXAssetID); err != nil {
+		t.Fatal(err)
+	} else if burned != fee {
+		t.Fatalf("Expected only the fee %d to be burned, but found %d", fee, burned)
+	}
+}
+
+// Ensure that an export tx whose input has a nonce ahead of its account is
+// rejected by default, and is queued until the nonce is reached when
+// [AllowFutureNonceAtomicInputs] is enabled.
+func TestIssueExportTxFutureNonce(t *testing.T) {
+	genesisJSON, err := fundAddressByGenesis([]common.Address{testEthAddrs[0]})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	outs := []*avax.TransferableOutput{{
+		Asset: avax.Asset{ID: testAvaxAssetID},
+		Out: &secp256k1fx.TransferOutput{
+			Amt: units.MilliAvax,
+			OutputOwners: secp256k1fx.OutputOwners{
+				Threshold: 1,
+				Addrs:     []ids.ShortID{testShortIDAddrs[0]},
+			},
+		},
+	}}
+	newFutureNonceTx := func(vm *VM) *Tx {
+		tx := &Tx{UnsignedAtomicTx: &UnsignedExportTx{
+			NetworkID:        vm.ctx.NetworkID,
+			BlockchainID:     vm.ctx.ChainID,
+			DestinationChain: vm.ctx.XChainID,
+			Ins: []EVMInput{{
+				Address: testEthAddrs[0],
+				Amount:  10 * units.MilliAvax,
+				AssetID: vm.ctx.AVAXAssetID,
+				Nonce:   1,
+			}},
+			ExportedOutputs: outs,
+		}}
+		if err := tx.Sign(Codec, [][]*crypto.PrivateKeySECP256K1R{{testKeys[0]}}); err != nil {
+			t.Fatal(err)
+		}
+		return tx
+	}
+
+	t.Run("rejected", func(t *testing.T) {
+		_, vm, _, _, _ := GenesisVM(t, true, genesisJSON, "", "")
+		defer func() {
+			if err := vm.Shutdown(); err != nil {
 				t.Fatal(err)
 			}
+		}()
 
-			if err := vm.SetPreference(blk.ID()); err != nil {
+		if err := vm.issueTx(newFutureNonceTx(vm), true /*=local*/); !errors.Is(err, errInvalidNonce) {
+			t.Fatalf("Expected %s, but found: %v", errInvalidNonce, err)
+		}
+		if txs := vm.mempool.PopFutureTxs(); len(txs) != 0 {
+			t.Fatalf("Expected no queued txs, but found %d", len(txs))
+		}
+	})
+
+	t.Run("remote not queued", func(t *testing.T) {
+		_, vm, _, _, _ := GenesisVM(t, true, genesisJSON, `{"allow-future-nonce-atomic-inputs": true}`, "")
+		defer func() {
+			if err := vm.Shutdown(); err != nil {
 				t.Fatal(err)
 			}
+		}()
 
-			if err := blk.Accept(); err != nil {
+		futureTx := newFutureNonceTx(vm)
+		if err := vm.issueTx(futureTx, false /*=local*/); err != nil {
+			t.Fatal(err)
+		}
+		if txs := vm.mempool.PopFutureTxs(); len(txs) != 0 {
+			t.Fatalf("Expected no queued txs, but found %d", len(txs))
+		}
+		if _, discarded := vm.mempool.discardedTxs.Get(futureTx.ID()); !discarded {
+			t.Fatal("Expected the remote tx with a future nonce to be discarded")
+		}
+	})
+
+	t.Run("queued", func(t *testing.T) {
+		issuer, vm, _, _, _ := GenesisVM(t, true, genesisJSON, `{"allow-future-nonce-atomic-inputs": true}`, "")
+		defer func() {
+			if err := vm.Shutdown(); err != nil {
 				t.Fatal(err)
 			}
+		}()
 
-			parent = vm.LastAcceptedBlockInternal().(*Block)
-			exportAmount := uint64(5000000)
+		futureTx := newFutureNonceTx(vm)
+		if err := vm.issueTx(futureTx, true /*=local*/); err != nil {
+			t.Fatal(err)
+		}
+		if _, pending := vm.mempool.GetPendingTx(futureTx.ID()); pending {
+			t.Fatal("Expected the tx with a future nonce not to be pending")
+		}
+
+		// Accepting a tx with the current nonce closes the gap
+		ins := []EVMInput{{
+			Address: testEthAddrs[0],
+			Amount:  10 * units.MilliAvax,
+			AssetID: vm.ctx.AVAXAssetID,
+			Nonce:   0,
+		}}
+		tx, err := vm.newExportTxFromInputs(ins, [][]*crypto.PrivateKeySECP256K1R{{testKeys[0]}}, outs, ids.ShortEmpty, vm.ctx.XChainID, initialBaseFee)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := vm.issueTx(tx, true /*=local*/); err != nil {
+			t.Fatal(err)
+		}
+
+		<-issuer
+
+		blk, err := vm.BuildBlock()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := blk.Verify(); err != nil {
+			t.Fatal(err)
+		}
+		if err := vm.SetPreference(blk.ID()); err != nil {
+			t.Fatal(err)
+		}
+		if err := blk.Accept(); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, pending := vm.mempool.GetPendingTx(futureTx.ID()); !pending {
+			t.Fatal("Expected the tx to be pending once its nonce was reached")
+		}
+	})
+}
 
-			tx, err = vm.newExportTx(vm.ctx.AVAXAssetID, exportAmount, vm.ctx.XChainID, testShortIDAddrs[0], initialBaseFee, []*crypto.PrivateKeySECP256K1R{testKeys[0]})
-			if err != nil {
-				t.Fatal(err)
-			}
+// Ensure that the AVAX balance of an account that is not a multiple of 1
+// nAVAX is rounded down when exported, leaving the remainder in the account.
+func TestExportTxSubNAVAXRemainder(t *testing.T) {
+	key := testKeys[0]
+	addr := testEthAddrs[0]
+	remainder := big.NewInt(123)
+	balance := new(big.Int).Mul(big.NewInt(units.Avax), x2cRate)
+	balance.Add(balance, remainder)
+	genesisJSON, err := fundAddressesByGenesis(map[common.Address]*big.Int{addr: balance})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, vm, _, _, _ := GenesisVM(t, true, genesisJSON, "", "")
+	defer func() {
+		if err := vm.Shutdown(); err != nil {
+			t.Fatal(err)
+		}
+	}()
 
-			exportTx := tx.UnsignedAtomicTx
+	if exportable := weiToExportableAVAX(balance); exportable != units.Avax {
+		t.Fatalf("Expected %d nAVAX to be exportable, but found %d", units.Avax, exportable)
+	}
+	keys := []*crypto.PrivateKeySECP256K1R{key}
+	if _, _, err := vm.GetSpendableFunds(keys, vm.ctx.AVAXAssetID, units.Avax+1); !errors.Is(err, errInsufficientFunds) {
+		t.Fatalf("Expected spending the remainder to fail with %s, but found %v", errInsufficientFunds, err)
+	}
+	ins, _, err := vm.GetSpendableFunds(keys, vm.ctx.AVAXAssetID, units.Avax)
+	if err != nil {
+		t.Fatal(err)
+	}
 
-			if err := exportTx.SemanticVerify(vm, tx, parent, parent.ethBlock.BaseFee(), test.rules); err != nil {
-				t.Fatal("newExportTx created an invalid transaction", err)
-			}
+	exportTx := &UnsignedExportTx{
+		NetworkID:        vm.ctx.NetworkID,
+		BlockchainID:     vm.ctx.ChainID,
+		DestinationChain: vm.ctx.XChainID,
+		Ins:              ins,
+	}
+	stateDB, err := vm.chain.CurrentState()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := exportTx.EVMStateTransfer(vm.ctx, stateDB); err != nil {
+		t.Fatal(err)
+	}
+	if remaining := stateDB.GetBalance(addr); remaining.Cmp(remainder) != 0 {
+		t.Fatalf("Expected the remainder of %s wei to stay in the account, but found %s", remainder, remaining)
+	}
+}
 
-			burnedAVAX, err := exportTx.Burned(vm.ctx.AVAXAssetID)
-			if err != nil {
-				t.Fatal(err)
-			}
-			if burnedAVAX != test.expectedBurnedAVAX {
-				t.Fatalf("burned wrong amount of AVAX - expected %d burned %d", test.expectedBurnedAVAX, burnedAVAX)
-			}
+func TestNewExportTxAll(t *testing.T) {
+	genesisJSON, err := fundAddressByGenesis([]common.Address{testEthAddrs[0], testEthAddrs[1]})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, vm, _, _, _ := GenesisVM(t, true, genesisJSON, "", "")
+	defer func() {
+		if err := vm.Shutdown(); err != nil {
+			t.Fatal(err)
+		}
+	}()
 
-			commitBatch, err := vm.db.CommitBatch()
-			if err != nil {
-				t.Fatalf("Failed to create commit batch for VM due to %s", err)
-			}
-			chainID, atomicRequests, err := exportTx.AtomicOps()
+	keys := []*crypto.PrivateKeySECP256K1R{testKeys[0], testKeys[1], testKeys[2]}
+	stateDB, err := vm.chain.CurrentState()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var total uint64
+	for _, key := range keys {
+		total += weiToExportableAVAX(stateDB.GetBalance(GetEthAddress(key)))
+	}
 
-			if err != nil {
-				t.Fatalf("Failed to accept export transaction due to: %s", err)
-			}
+	tx, err := vm.newExportTxAll(vm.ctx.AVAXAssetID, vm.ctx.XChainID, testShortIDAddrs[0], initialBaseFee, keys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exportTx := tx.UnsignedAtomicTx.(*UnsignedExportTx)
+	// The unfunded key is not spent
+	if len(exportTx.Ins) != 2 {
+		t.Fatalf("Expected 2 inputs, but found %d", len(exportTx.Ins))
+	}
 
-			if err := vm.ctx.SharedMemory.Apply(map[ids.ID]*atomic.Requests{chainID: {PutRequests: atomicRequests.PutRequests}}, commitBatch); err != nil {
-				t.Fatal(err)
-			}
+	rules := vm.currentRules()
+	gasUsed, err := tx.GasUsed(rules.AtomicTxFeeConfig, rules.IsApricotPhase5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fee, err := calculateDynamicFee(gasUsed, initialBaseFee)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exported := exportTx.ExportedOutputs[0].Out.Amount(); exported != total-fee {
+		t.Fatalf("Expected to export %d nAVAX, but found %d", total-fee, exported)
+	}
 
-			sdb, err := vm.chain.CurrentState()
-			if err != nil {
-				t.Fatal(err)
-			}
-			err = exportTx.EVMStateTransfer(vm.ctx, sdb)
-			if err != nil {
-				t.Fatal(err)
-			}
+	parent := vm.LastAcceptedBlockInternal().(*Block)
+	if err := exportTx.SemanticVerify(vm, tx, parent, initialBaseFee, rules); err != nil {
+		t.Fatal(err)
+	}
 
-			addr := GetEthAddress(testKeys[0])
-			if sdb.GetBalance(addr).Cmp(new(big.Int).SetUint64(test.bal*units.Avax)) != 0 {
-				t.Fatalf("address balance %s equal %s not %s", addr.String(), sdb.GetBalance(addr), new(big.Int).SetUint64(test.bal*units.Avax))
-			}
-		})
+	// Keys without any balance can not pay the fee
+	if _, err := vm.newExportTxAll(vm.ctx.AVAXAssetID, vm.ctx.XChainID, testShortIDAddrs[0], initialBaseFee, keys[2:]); !errors.Is(err, errInsufficientFunds) {
+		t.Fatalf("Expected exporting from an unfunded key to fail with %s, but found %v", errInsufficientFunds, err)
 	}
 }
 
@@ -1838,3 +3331,381 @@ func TestNewExportTxMulticoin(t *testing.T) {
 		})
 	}
 }
+
+// Ensure that exporting a non-AVAX asset to the P-chain fails with
+// [errWrongAVAXAssetID].
+func TestExportTxWrongAVAXAssetID(t *testing.T) {
+	_, vm, _, _, _ := GenesisVM(t, true, genesisJSONApricotPhase5, "", "")
+	defer func() {
+		if err := vm.Shutdown(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	wrongAVAXAssetID := ids.GenerateTestID()
+	key := testKeys[0]
+	_, err := vm.newExportTx(wrongAVAXAssetID, units.Avax, constants.PlatformChainID, key.PublicKey().Address(), initialBaseFee, []*crypto.PrivateKeySECP256K1R{key})
+	if !errors.Is(err, errWrongAVAXAssetID) {
+		t.Fatalf("Expected newExportTx to fail with %s, but found %v", errWrongAVAXAssetID, err)
+	}
+
+	exportTx := &UnsignedExportTx{
+		NetworkID:        vm.ctx.NetworkID,
+		BlockchainID:     vm.ctx.ChainID,
+		DestinationChain: constants.PlatformChainID,
+		Ins: []EVMInput{
+			{
+				Address: testEthAddrs[0],
+				Amount:  units.Avax,
+				AssetID: wrongAVAXAssetID,
+				Nonce:   0,
+			},
+		},
+		ExportedOutputs: []*avax.TransferableOutput{
+			{
+				Asset: avax.Asset{ID: wrongAVAXAssetID},
+				Out: &secp256k1fx.TransferOutput{
+					Amt: units.Avax,
+					OutputOwners: secp256k1fx.OutputOwners{
+						Threshold: 1,
+						Addrs:     []ids.ShortID{key.PublicKey().Address()},
+					},
+				},
+			},
+		},
+	}
+	tx := &Tx{UnsignedAtomicTx: exportTx}
+	if err := tx.Sign(vm.codec, [][]*crypto.PrivateKeySECP256K1R{{key}}); err != nil {
+		t.Fatal(err)
+	}
+	parent := vm.LastAcceptedBlockInternal().(*Block)
+	if err := exportTx.SemanticVerify(vm, tx, parent, initialBaseFee, apricotRulesPhase5); !errors.Is(err, errWrongAVAXAssetID) {
+		t.Fatalf("Expected SemanticVerify to fail with %s, but found %v", errWrongAVAXAssetID, err)
+	}
+}
+
+// Ensure that an export tx can spend the full balance of an address, but no
+// more.
+func TestExportTxSpendableEVMBalance(t *testing.T) {
+	ctx := NewContext()
+	addr := testEthAddrs[0]
+	sdb, err := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	balance := new(big.Int).Mul(big.NewInt(10), x2cRate)
+	sdb.SetBalance(addr, balance)
+	if spendable := getSpendableEVMBalance(sdb, addr); spendable.Cmp(balance) != 0 {
+		t.Fatalf("Expected the spendable balance to be %s, but found %s", balance, spendable)
+	}
+
+	newExportTx := func(amount uint64) *UnsignedExportTx {
+		return &UnsignedExportTx{
+			NetworkID:        ctx.NetworkID,
+			BlockchainID:     ctx.ChainID,
+			DestinationChain: ctx.XChainID,
+			Ins: []EVMInput{{
+				Address: addr,
+				Amount:  amount,
+				AssetID: ctx.AVAXAssetID,
+				Nonce:   0,
+			}},
+		}
+	}
+	if err := newExportTx(11).EVMStateTransfer(ctx, sdb); err != errInsufficientFunds {
+		t.Fatalf("Expected EVMStateTransfer to fail with %s, but found %v", errInsufficientFunds, err)
+	}
+	if err := newExportTx(10).EVMStateTransfer(ctx, sdb); err != nil {
+		t.Fatalf("Expected EVMStateTransfer of the spendable balance to succeed, but found %s", err)
+	}
+	if balance := sdb.GetBalance(addr); balance.Sign() != 0 {
+		t.Fatalf("Expected the full balance to be spent, but found %s", balance)
+	}
+}
+
+// Ensure that building an export tx fails fast if the nonce of an input is
+// stale.
+func TestVerifyEVMInputNonces(t *testing.T) {
+	sdb, err := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sdb.SetNonce(testEthAddrs[0], 1)
+	sdb.SetNonce(testEthAddrs[1], 5)
+
+	ins := []EVMInput{
+		{Address: testEthAddrs[0], Amount: 1, AssetID: testAvaxAssetID, Nonce: 1},
+		{Address: testEthAddrs[1], Amount: 1, AssetID: testAvaxAssetID, Nonce: 5},
+	}
+	if err := verifyEVMInputNonces(sdb, ins); err != nil {
+		t.Fatalf("Expected inputs with the current nonces to pass, but found %s", err)
+	}
+
+	// Another tx from [testEthAddrs[1]] is accepted after the inputs were
+	// gathered
+	sdb.SetNonce(testEthAddrs[1], 6)
+	if err := verifyEVMInputNonces(sdb, ins); !errors.Is(err, errInvalidNonce) {
+		t.Fatalf("Expected inputs with a stale nonce to fail with %s, but found %v", errInvalidNonce, err)
+	}
+}
+
+// newSemanticVerifyTestVM returns a VM with just enough state to semantically
+// verify export txs. The secp cache is too small to hold more than a single
+// public key, so that verifying different txs always recovers signatures.
+func newSemanticVerifyTestVM() *VM {
+	return &VM{
+		ctx:             NewContext(),
+		config:          Config{AtomicTxVerifyWorkers: runtime.NumCPU()},
+		atomicTxMetrics: newAtomicTxMetrics(metrics.NewRegistry()),
+		secpFactory:     crypto.FactorySECP256K1R{Cache: cache.LRU{Size: 1}},
+	}
+}
+
+// newTestExportTxs returns [numTxs] distinct export txs to the X-chain, each
+// signed by [key].
+func newTestExportTxs(tb testing.TB, ctx *snow.Context, key *crypto.PrivateKeySECP256K1R, numTxs int) []*Tx {
+	txs := make([]*Tx, numTxs)
+	for i := range txs {
+		tx := &Tx{UnsignedAtomicTx: &UnsignedExportTx{
+			NetworkID:        ctx.NetworkID,
+			BlockchainID:     ctx.ChainID,
+			DestinationChain: ctx.XChainID,
+			Ins: []EVMInput{
+				{
+					Address: PublicKeyToEthAddress(key.PublicKey().(*crypto.PublicKeySECP256K1R)),
+					Amount:  units.Avax,
+					AssetID: ctx.AVAXAssetID,
+					Nonce:   uint64(i),
+				},
+			},
+			ExportedOutputs: []*avax.TransferableOutput{
+				{
+					Asset: avax.Asset{ID: ctx.AVAXAssetID},
+					Out: &secp256k1fx.TransferOutput{
+						Amt: units.Avax / 2,
+						OutputOwners: secp256k1fx.OutputOwners{
+							Threshold: 1,
+							Addrs:     []ids.ShortID{key.PublicKey().Address()},
+						},
+					},
+				},
+			},
+		}}
+		if err := tx.Sign(Codec, [][]*crypto.PrivateKeySECP256K1R{{key}}); err != nil {
+			tb.Fatal(err)
+		}
+		txs[i] = tx
+	}
+	return txs
+}
+
+func TestVerifyAtomicTxs(t *testing.T) {
+	vm := newSemanticVerifyTestVM()
+	txs := newTestExportTxs(t, vm.ctx, testKeys[0], 20)
+	if err := vm.VerifyAtomicTxs(txs, nil, initialBaseFee, apricotRulesPhase3); err != nil {
+		t.Fatal(err)
+	}
+
+	// Make txs[5] fail signature verification and txs[10] fail the flow check.
+	// The error of the first invalid tx must always be returned.
+	wrongSigTx := &Tx{UnsignedAtomicTx: txs[5].UnsignedAtomicTx}
+	if err := wrongSigTx.Sign(Codec, [][]*crypto.PrivateKeySECP256K1R{{testKeys[1]}}); err != nil {
+		t.Fatal(err)
+	}
+	txs[5] = wrongSigTx
+	overspendTx := newTestExportTxs(t, vm.ctx, testKeys[0], 1)[0]
+	overspendTx.UnsignedAtomicTx.(*UnsignedExportTx).Ins[0].Amount = 1
+	if err := overspendTx.Sign(Codec, nil); err != nil {
+		t.Fatal(err)
+	}
+	txs[10] = overspendTx
+
+	for i := 0; i < 10; i++ {
+		err := vm.VerifyAtomicTxs(txs, nil, initialBaseFee, apricotRulesPhase3)
+		if !errors.Is(err, errPublicKeySignatureMismatch) {
+			t.Fatalf("Expected %s, but found %v", errPublicKeySignatureMismatch, err)
+		}
+		if !strings.Contains(err.Error(), "index 5") {
+			t.Fatalf("Expected error for tx at index 5, but found %s", err)
+		}
+	}
+}
+
+// concurrencyTestTx records the peak number of txs being semantically
+// verified at once.
+type concurrencyTestTx struct {
+	TestTx
+
+	lock         *sync.Mutex
+	active, peak *int
+}
+
+func (t *concurrencyTestTx) SemanticVerifyWithOptions(vm *VM, stx *Tx, parent *Block, baseFee *big.Int, rules params.Rules, _ SemanticVerifyOptions) error {
+	return t.SemanticVerify(vm, stx, parent, baseFee, rules)
+}
+
+func (t *concurrencyTestTx) SemanticVerify(*VM, *Tx, *Block, *big.Int, params.Rules) error {
+	t.lock.Lock()
+	*t.active++
+	if *t.active > *t.peak {
+		*t.peak = *t.active
+	}
+	t.lock.Unlock()
+
+	time.Sleep(10 * time.Millisecond)
+
+	t.lock.Lock()
+	*t.active--
+	t.lock.Unlock()
+	return nil
+}
+
+func TestVerifyAtomicTxsWorkers(t *testing.T) {
+	vm := newSemanticVerifyTestVM()
+	vm.config.AtomicTxVerifyWorkers = 3
+
+	var (
+		lock         sync.Mutex
+		active, peak int
+	)
+	txs := make([]*Tx, 20)
+	for i := range txs {
+		txs[i] = &Tx{UnsignedAtomicTx: &concurrencyTestTx{
+			lock:   &lock,
+			active: &active,
+			peak:   &peak,
+		}}
+	}
+	if err := vm.VerifyAtomicTxs(txs, nil, initialBaseFee, apricotRulesPhase3); err != nil {
+		t.Fatal(err)
+	}
+	if peak != vm.config.AtomicTxVerifyWorkers {
+		t.Fatalf("Expected peak concurrency of %d, but found %d", vm.config.AtomicTxVerifyWorkers, peak)
+	}
+}
+
+func BenchmarkVerifyAtomicTxs(b *testing.B) {
+	vm := newSemanticVerifyTestVM()
+	txs := newTestExportTxs(b, vm.ctx, testKeys[0], 20)
+
+	b.Run("serial", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			for _, tx := range txs {
+				if err := tx.UnsignedAtomicTx.SemanticVerify(vm, tx, nil, initialBaseFee, apricotRulesPhase3); err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+	})
+	b.Run("batched", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if err := vm.VerifyAtomicTxs(txs, nil, initialBaseFee, apricotRulesPhase3); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+// BenchmarkVerifyAtomicTxsFeeCache reports the number of fees calculated to
+// verify a block of many atomic txs that use the same amount of gas.
+func BenchmarkVerifyAtomicTxsFeeCache(b *testing.B) {
+	vm := newSemanticVerifyTestVM()
+	txs := newTestExportTxs(b, vm.ctx, testKeys[0], 100)
+
+	b.Run("uncached", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			for _, tx := range txs {
+				if err := tx.UnsignedAtomicTx.SemanticVerify(vm, tx, nil, initialBaseFee, apricotRulesPhase3); err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+		b.ReportMetric(float64(len(txs)), "fees/op")
+	})
+	b.Run("cached", func(b *testing.B) {
+		calculated := 0
+		for i := 0; i < b.N; i++ {
+			opts := SemanticVerifyOptions{FeeCache: newAtomicFeeCache()}
+			for _, tx := range txs {
+				if err := tx.UnsignedAtomicTx.SemanticVerifyWithOptions(vm, tx, nil, initialBaseFee, apricotRulesPhase3, opts); err != nil {
+					b.Fatal(err)
+				}
+			}
+			calculated += opts.FeeCache.calculated
+		}
+		b.ReportMetric(float64(calculated)/float64(b.N), "fees/op")
+	})
+}
+
+// Ensure that public keys served from the VM's signature cache match the
+// public keys recovered without a cache.
+func TestSecpCacheRecoverPublicKey(t *testing.T) {
+	_, vm, _, _, _ := GenesisVM(t, true, genesisJSONApricotPhase0, `{"secp-cache-size": 2}`, "")
+	defer func() {
+		if err := vm.Shutdown(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	if vm.secpFactory.Cache.Size != 2 {
+		t.Fatalf("Expected secp cache size to be 2, but found %d", vm.secpFactory.Cache.Size)
+	}
+
+	freshFactory := crypto.FactorySECP256K1R{}
+	for _, key := range testKeys {
+		msg := []byte(key.PublicKey().Address().String())
+		sig, err := key.Sign(msg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expectedPubKey, err := freshFactory.RecoverPublicKey(msg, sig)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// The second recovery is served from the cache
+		for i := 0; i < 2; i++ {
+			pubKey, err := vm.secpFactory.RecoverPublicKey(msg, sig)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if pubKey.Address() != expectedPubKey.Address() || pubKey.Address() != key.PublicKey().Address() {
+				t.Fatalf("Expected recovered address %s, but found %s", key.PublicKey().Address(), pubKey.Address())
+			}
+		}
+	}
+}
+
+func BenchmarkSecpCacheRecoverPublicKey(b *testing.B) {
+	// Sign more messages than fit in the cache, so that cycling through them
+	// never hits the cache.
+	const numMsgs = 2 * defaultSecpCacheSize
+	msgs := make([][]byte, numMsgs)
+	sigs := make([][]byte, numMsgs)
+	for i := range msgs {
+		msgs[i] = []byte(fmt.Sprintf("message %d", i))
+		sig, err := testKeys[0].Sign(msgs[i])
+		if err != nil {
+			b.Fatal(err)
+		}
+		sigs[i] = sig
+	}
+
+	b.Run("hit", func(b *testing.B) {
+		factory := crypto.FactorySECP256K1R{Cache: cache.LRU{Size: defaultSecpCacheSize}}
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			if _, err := factory.RecoverPublicKey(msgs[0], sigs[0]); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("miss", func(b *testing.B) {
+		factory := crypto.FactorySECP256K1R{Cache: cache.LRU{Size: defaultSecpCacheSize}}
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			if _, err := factory.RecoverPublicKey(msgs[i%numMsgs], sigs[i%numMsgs]); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
@@ -3,10 +3,20 @@
 
 package evm
 
+import "strconv"
+
 // Health returns nil if this chain is healthy.
 // Also returns details, which should be one of:
 // string, []byte, map[string]string
 func (vm *VM) HealthCheck() (interface{}, error) {
 	// TODO perform actual health check
-	return nil, nil
+	// Gossip backpressure and the number of blocks that peers announced above
+	// the last accepted block are reported so that they can be alerted on,
+	// but do not make the chain unhealthy.
+	blocksBehindPeers := vm.blocksBehind(vm.chain.LastAcceptedBlock().NumberU64())
+	vm.blocksBehindPeers.Update(int64(blocksBehindPeers))
+	return map[string]string{
+		"gossipBackpressure": strconv.FormatBool(vm.network.GossipBackpressure()),
+		"blocksBehindPeers":  strconv.FormatUint(blocksBehindPeers, 10),
+	}, nil
 }
@@ -6,6 +6,7 @@ package evm
 import (
 	"fmt"
 	"math/big"
+	"time"
 
 	"github.com/ava-labs/coreth/core/state"
 	"github.com/ava-labs/coreth/params"
@@ -37,6 +38,9 @@ type UnsignedImportTx struct {
 	Outs []EVMOutput `serialize:"true" json:"outputs"`
 }
 
+// Type returns [ImportTxType]
+func (tx *UnsignedImportTx) Type() AtomicTxType { return ImportTxType }
+
 // InputUTXOs returns the UTXOIDs of the imported funds
 func (tx *UnsignedImportTx) InputUTXOs() ids.Set {
 	set := ids.NewSet(len(tx.ImportedInputs))
@@ -56,8 +60,10 @@ func (tx *UnsignedImportTx) Verify(
 		return errNilTx
 	case len(tx.ImportedInputs) == 0:
 		return errNoImportInputs
+	// Every chain of a network shares its network ID, so this also ensures
+	// that the tx is for the network of [tx.SourceChain].
 	case tx.NetworkID != ctx.NetworkID:
-		return errWrongNetworkID
+		return fmt.Errorf("%w: expected %d but found %d", errWrongNetworkID, ctx.NetworkID, tx.NetworkID)
 	case ctx.ChainID != tx.BlockchainID:
 		return errWrongBlockchainID
 	case rules.IsApricotPhase3 && len(tx.Outs) == 0:
@@ -102,12 +108,12 @@ func (tx *UnsignedImportTx) Verify(
 		}
 	}
 
-	return nil
+	return verifyAtomicTxGas(tx, rules)
 }
 
-func (tx *UnsignedImportTx) GasUsed(fixedFee bool) (uint64, error) {
+func (tx *UnsignedImportTx) GasUsed(fees params.AtomicTxFeeConfig, fixedFee bool) (uint64, error) {
 	var (
-		cost = calcBytesCost(len(tx.UnsignedBytes()))
+		cost = calcBytesCost(fees, len(tx.UnsignedBytes()))
 		err  error
 	)
 	for _, in := range tx.ImportedInputs {
@@ -164,20 +170,35 @@ func (tx *UnsignedImportTx) SemanticVerify(
 	baseFee *big.Int,
 	rules params.Rules,
 ) error {
+	return tx.SemanticVerifyWithOptions(vm, stx, parent, baseFee, rules, SemanticVerifyOptions{})
+}
+
+// SemanticVerifyWithOptions verifies this transaction is valid, adjusting the
+// checks performed according to [opts].
+func (tx *UnsignedImportTx) SemanticVerifyWithOptions(
+	vm *VM,
+	stx *Tx,
+	parent *Block,
+	baseFee *big.Int,
+	rules params.Rules,
+	opts SemanticVerifyOptions,
+) error {
+	defer vm.atomicTxMetrics.importSemanticVerify.UpdateSince(time.Now())
+
 	if err := tx.Verify(vm.ctx, rules); err != nil {
 		return err
 	}
 
 	// Check the transaction consumes and produces the right amounts
-	fc := avax.NewFlowChecker()
+	fc := newAssetFlowChecker()
 	switch {
 	// Apply dynamic fees to import transactions as of Apricot Phase 3
 	case rules.IsApricotPhase3:
-		gasUsed, err := stx.GasUsed(rules.IsApricotPhase5)
+		gasUsed, err := stx.GasUsed(rules.AtomicTxFeeConfig, rules.IsApricotPhase5)
 		if err != nil {
 			return err
 		}
-		txFee, err := calculateDynamicFee(gasUsed, baseFee)
+		txFee, err := opts.FeeCache.calculateDynamicFee(gasUsed, baseFee)
 		if err != nil {
 			return err
 		}
@@ -194,12 +215,14 @@ func (tx *UnsignedImportTx) SemanticVerify(
 		fc.Consume(in.AssetID(), in.Input().Amount())
 	}
 
-	if err := fc.Verify(); err != nil {
-		return fmt.Errorf("import tx flow check failed due to: %w", err)
+	if !opts.SkipFlowCheck {
+		if err := fc.Verify(); err != nil {
+			return fmt.Errorf("import tx flow check failed due to: %w", err)
+		}
 	}
 
 	if len(stx.Creds) != len(tx.ImportedInputs) {
-		return fmt.Errorf("import tx contained mismatched number of inputs/credentials (%d vs. %d)", len(tx.ImportedInputs), len(stx.Creds))
+		return fmt.Errorf("%w: import tx contained mismatched number of inputs/credentials (%d vs. %d)", errSignatureInputsMismatch, len(tx.ImportedInputs), len(stx.Creds))
 	}
 
 	if !vm.bootstrapped {
@@ -226,6 +249,10 @@ func (tx *UnsignedImportTx) SemanticVerify(
 			return fmt.Errorf("failed to unmarshal UTXO: %w", err)
 		}
 
+		// Guard the index even though the lengths were checked above
+		if i >= len(stx.Creds) {
+			return fmt.Errorf("%w: import tx input %d has no credential", errSignatureInputsMismatch, i)
+		}
 		cred := stx.Creds[i]
 
 		utxoAssetID := utxo.AssetID()
@@ -234,8 +261,23 @@ func (tx *UnsignedImportTx) SemanticVerify(
 			return errAssetIDMismatch
 		}
 
-		if err := vm.fx.VerifyTransfer(tx, in.In, cred, utxo.Out); err != nil {
-			return fmt.Errorf("import tx transfer failed verification: %w", err)
+		if secpCred, ok := cred.(*secp256k1fx.Credential); ok {
+			for _, sig := range secpCred.Sigs {
+				if err := verifyLowS(sig); err != nil {
+					return fmt.Errorf("import tx input %d: %w", i, err)
+				}
+			}
+		}
+
+		// VerifyTransfer recovers the public keys from the credential
+		recoverStart := time.Now()
+		err := vm.fx.VerifyTransfer(tx, in.In, cred, utxo.Out)
+		vm.atomicTxMetrics.importSigRecovery.UpdateSince(recoverStart)
+		if err != nil {
+			if out, ok := utxo.Out.(*secp256k1fx.TransferOutput); ok {
+				return fmt.Errorf("import tx input %d expected a signature from %v, but transfer failed verification: %w", i, out.Addrs, err)
+			}
+			return fmt.Errorf("import tx input %d transfer failed verification: %w", i, err)
 		}
 	}
 
@@ -352,11 +394,11 @@ func (vm *VM) newImportTxWithUTXOs(
 			return nil, err
 		}
 
-		gasUsedWithoutChange, err := tx.GasUsed(rules.IsApricotPhase5)
+		gasUsedWithoutChange, err := tx.GasUsed(rules.AtomicTxFeeConfig, rules.IsApricotPhase5)
 		if err != nil {
 			return nil, err
 		}
-		gasUsedWithChange := gasUsedWithoutChange + EVMOutputGas
+		gasUsedWithChange := gasUsedWithoutChange + evmOutputGas(rules.AtomicTxFeeConfig)
 
 		txFeeWithoutChange, err = calculateDynamicFee(gasUsedWithoutChange, baseFee)
 		if err != nil {
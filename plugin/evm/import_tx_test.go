@@ -4,6 +4,8 @@
 package evm
 
 import (
+	"errors"
+	"fmt"
 	"math/big"
 	"testing"
 
@@ -144,6 +146,16 @@ func TestImportTxVerify(t *testing.T) {
 			rules:       apricotRulesPhase0,
 			expectedErr: errWrongNetworkID.Error(),
 		},
+		"network ID of another network": {
+			generate: func(t *testing.T) UnsignedAtomicTx {
+				tx := *importTx
+				tx.NetworkID = constants.MainnetID
+				return &tx
+			},
+			ctx:         ctx,
+			rules:       apricotRulesPhase0,
+			expectedErr: fmt.Sprintf("%s: expected %d but found %d", errWrongNetworkID, testNetworkID, constants.MainnetID),
+		},
 		"invalid blockchain ID": {
 			generate: func(t *testing.T) UnsignedAtomicTx {
 				tx := *importTx
@@ -355,7 +367,7 @@ func TestNewImportTx(t *testing.T) {
 		rules := vm.currentRules()
 		switch {
 		case rules.IsApricotPhase3:
-			actualCost, err := importTx.GasUsed(rules.IsApricotPhase5)
+			actualCost, err := importTx.GasUsed(rules.AtomicTxFeeConfig, rules.IsApricotPhase5)
 			if err != nil {
 				t.Fatal(err)
 			}
@@ -756,7 +768,7 @@ func TestImportTxGasCost(t *testing.T) {
 				t.Fatal(err)
 			}
 
-			gasUsed, err := tx.GasUsed(test.FixedFee)
+			gasUsed, err := tx.GasUsed(params.DefaultAtomicTxFeeConfig, test.FixedFee)
 			if err != nil {
 				t.Fatal(err)
 			}
@@ -1041,7 +1053,7 @@ func TestImportTxSemanticVerify(t *testing.T) {
 				}
 				return tx
 			},
-			semanticVerifyErr: "import tx transfer failed verification",
+			semanticVerifyErr: "import tx input 0 expected a signature from",
 		},
 		"non-unique EVM Outputs": {
 			setup: func(t *testing.T, vm *VM, sharedMemory *atomic.Memory) *Tx {
@@ -1093,6 +1105,99 @@ func TestImportTxSemanticVerify(t *testing.T) {
 	}
 }
 
+// Ensure that the allowed-import-assets config only restricts which import txs
+// this node admits into its mempool and does not affect SemanticVerify.
+func TestImportTxAssetNotAllowed(t *testing.T) {
+	allowedAssetID := ids.GenerateTestID()
+	_, vm, _, sharedMemory, _ := GenesisVM(t, true, genesisJSONApricotPhase0, fmt.Sprintf(`{"allowed-import-assets": [%q]}`, allowedAssetID), "")
+	defer func() {
+		if err := vm.Shutdown(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	assetID := ids.GenerateTestID()
+	utxo, err := addUTXO(sharedMemory, vm.ctx, ids.GenerateTestID(), 0, assetID, 1, testShortIDAddrs[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx := &Tx{UnsignedAtomicTx: &UnsignedImportTx{
+		NetworkID:    vm.ctx.NetworkID,
+		BlockchainID: vm.ctx.ChainID,
+		SourceChain:  vm.ctx.XChainID,
+		ImportedInputs: []*avax.TransferableInput{{
+			UTXOID: utxo.UTXOID,
+			Asset:  avax.Asset{ID: assetID},
+			In: &secp256k1fx.TransferInput{
+				Amt:   1,
+				Input: secp256k1fx.Input{SigIndices: []uint32{0}},
+			},
+		}},
+		Outs: []EVMOutput{{
+			Address: testEthAddrs[0],
+			Amount:  1,
+			AssetID: assetID,
+		}},
+	}}
+	if err := tx.Sign(vm.codec, [][]*crypto.PrivateKeySECP256K1R{{testKeys[0]}}); err != nil {
+		t.Fatal(err)
+	}
+
+	lastAcceptedBlock := vm.LastAcceptedBlockInternal().(*Block)
+	if err := tx.UnsignedAtomicTx.SemanticVerify(vm, tx, lastAcceptedBlock, nil, vm.currentRules()); err != nil {
+		t.Fatalf("SemanticVerify failed unexpectedly due to: %s", err)
+	}
+
+	if err := vm.issueTx(tx, true /*=local*/); !errors.Is(err, errImportAssetNotAllowed) {
+		t.Fatalf("Expected local issuance to fail with %s, but found: %v", errImportAssetNotAllowed, err)
+	}
+	if err := vm.issueTx(tx, false /*=local*/); err != nil {
+		t.Fatal(err)
+	}
+	if vm.mempool.has(tx.ID()) {
+		t.Fatal("Expected remote tx importing a disallowed asset not to be added to the mempool")
+	}
+}
+
+// Accepting an import tx removes its UTXOs from shared memory, so importing
+// them again is rejected.
+func TestImportTxConsumedUTXO(t *testing.T) {
+	issuer, vm, _, sharedMemory, _ := GenesisVM(t, true, genesisJSONApricotPhase2, "", "")
+	defer func() {
+		if err := vm.Shutdown(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	importTx := createImportTxOptions(t, vm, sharedMemory)[0]
+	if err := vm.issueTx(importTx, true /*=local*/); err != nil {
+		t.Fatal(err)
+	}
+
+	<-issuer
+
+	blk, err := vm.BuildBlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := blk.Verify(); err != nil {
+		t.Fatal(err)
+	}
+	if err := vm.SetPreference(blk.ID()); err != nil {
+		t.Fatal(err)
+	}
+	if err := blk.Accept(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Importing the same UTXO again must be rejected
+	lastAcceptedBlock := vm.LastAcceptedBlockInternal().(*Block)
+	err = importTx.UnsignedAtomicTx.SemanticVerify(vm, importTx, lastAcceptedBlock, initialBaseFee, vm.currentRules())
+	if err == nil {
+		t.Fatal("Expected re-import of a consumed UTXO to fail")
+	}
+}
+
 func TestImportTxEVMStateTransfer(t *testing.T) {
 	assetID := ids.GenerateTestID()
 	tests := map[string]atomicTxTest{
@@ -1190,6 +1295,52 @@ func TestImportTxEVMStateTransfer(t *testing.T) {
 				}
 			},
 		},
+		"allowed non-AVAX UTXO": {
+			setup: func(t *testing.T, vm *VM, sharedMemory *atomic.Memory) *Tx {
+				txID := ids.GenerateTestID()
+				utxo, err := addUTXO(sharedMemory, vm.ctx, txID, 0, assetID, 1, testShortIDAddrs[0])
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				tx := &Tx{UnsignedAtomicTx: &UnsignedImportTx{
+					NetworkID:    vm.ctx.NetworkID,
+					BlockchainID: vm.ctx.ChainID,
+					SourceChain:  vm.ctx.XChainID,
+					ImportedInputs: []*avax.TransferableInput{{
+						UTXOID: utxo.UTXOID,
+						Asset:  avax.Asset{ID: assetID},
+						In: &secp256k1fx.TransferInput{
+							Amt:   1,
+							Input: secp256k1fx.Input{SigIndices: []uint32{0}},
+						},
+					}},
+					Outs: []EVMOutput{{
+						Address: testEthAddrs[0],
+						Amount:  1,
+						AssetID: assetID,
+					}},
+				}}
+				if err := tx.Sign(vm.codec, [][]*crypto.PrivateKeySECP256K1R{{testKeys[0]}}); err != nil {
+					t.Fatal(err)
+				}
+				return tx
+			},
+			configJSON: fmt.Sprintf(`{"allowed-import-assets": [%q]}`, assetID),
+			checkState: func(t *testing.T, vm *VM) {
+				lastAcceptedBlock := vm.LastAcceptedBlockInternal().(*Block)
+
+				sdb, err := vm.chain.BlockState(lastAcceptedBlock.ethBlock)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				assetBalance := sdb.GetBalanceMultiCoin(testEthAddrs[0], common.Hash(assetID))
+				if assetBalance.Cmp(common.Big1) != 0 {
+					t.Fatalf("Expected asset balance to be %d, found balance: %d", common.Big1, assetBalance)
+				}
+			},
+		},
 	}
 
 	for name, test := range tests {
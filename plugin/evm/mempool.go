@@ -6,10 +6,12 @@ package evm
 import (
 	"errors"
 	"fmt"
+	"sort"
 	"sync"
 
 	"github.com/ava-labs/avalanchego/cache"
 	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/coreth/params"
 	"github.com/ethereum/go-ethereum/log"
 )
 
@@ -25,6 +27,8 @@ type Mempool struct {
 
 	// AVAXAssetID is the fee paying currency of any atomic transaction
 	AVAXAssetID ids.ID
+	// atomicTxFeeConfig is used to calculate the gas consumed by transactions
+	atomicTxFeeConfig params.AtomicTxFeeConfig
 	// maxSize is the maximum number of transactions allowed to be kept in mempool
 	maxSize int
 	// currentTxs is the set of transactions about to be added to a block.
@@ -44,19 +48,26 @@ type Mempool struct {
 	// txHeap is a sorted record of all txs in the mempool by [gasPrice]
 	// NOTE: [txHeap] ONLY contains pending txs
 	txHeap *txHeap
+	// futureTxs is the set of transactions whose EVM inputs have a nonce
+	// ahead of their account, which are queued until the nonce is reached.
+	// NOTE: [futureTxs] are not part of [utxoSet] and don't count towards
+	// [maxSize]
+	futureTxs map[ids.ID]*Tx
 }
 
 // NewMempool returns a Mempool with [maxSize]
-func NewMempool(AVAXAssetID ids.ID, maxSize int) *Mempool {
+func NewMempool(AVAXAssetID ids.ID, maxSize int, atomicTxFeeConfig params.AtomicTxFeeConfig) *Mempool {
 	return &Mempool{
-		AVAXAssetID:  AVAXAssetID,
-		issuedTxs:    make(map[ids.ID]*Tx),
-		discardedTxs: &cache.LRU{Size: discardedTxsCacheSize},
-		currentTxs:   make(map[ids.ID]*Tx),
-		Pending:      make(chan struct{}, 1),
-		utxoSet:      ids.NewSet(maxSize),
-		txHeap:       newTxHeap(maxSize),
-		maxSize:      maxSize,
+		AVAXAssetID:       AVAXAssetID,
+		atomicTxFeeConfig: atomicTxFeeConfig,
+		issuedTxs:         make(map[ids.ID]*Tx),
+		discardedTxs:      &cache.LRU{Size: discardedTxsCacheSize},
+		currentTxs:        make(map[ids.ID]*Tx),
+		Pending:           make(chan struct{}, 1),
+		utxoSet:           ids.NewSet(maxSize),
+		txHeap:            newTxHeap(maxSize),
+		maxSize:           maxSize,
+		futureTxs:         make(map[ids.ID]*Tx),
 	}
 }
 
@@ -68,6 +79,15 @@ func (m *Mempool) Len() int {
 	return m.length()
 }
 
+// HasCapacity returns true if a tx can be added to the mempool without
+// evicting a lower priced tx.
+func (m *Mempool) HasCapacity() bool {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	return m.length() < m.maxSize
+}
+
 // assumes the lock is held
 func (m *Mempool) length() int {
 	return m.txHeap.Len() + len(m.issuedTxs)
@@ -83,7 +103,7 @@ func (m *Mempool) has(txID ids.ID) bool {
 // atomicTxGasPrice is the [gasPrice] paid by a transaction to burn a given
 // amount of [AVAXAssetID] given the value of [gasUsed].
 func (m *Mempool) atomicTxGasPrice(tx *Tx) (uint64, error) {
-	gasUsed, err := tx.GasUsed(true)
+	gasUsed, err := tx.GasUsed(m.atomicTxFeeConfig, true)
 	if err != nil {
 		return 0, err
 	}
@@ -219,6 +239,24 @@ func (m *Mempool) GetPendingTx(txID ids.ID) (*Tx, bool) {
 	return m.txHeap.Get(txID)
 }
 
+// PendingTxs returns the transactions in the [txHeap] that are waiting to be
+// issued into a block, ordered from the highest to the lowest [gasPrice].
+func (m *Mempool) PendingTxs() []*Tx {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	entries := make([]*txEntry, len(m.txHeap.maxHeap.items))
+	copy(entries, m.txHeap.maxHeap.items)
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].gasPrice > entries[j].gasPrice
+	})
+	txs := make([]*Tx, len(entries))
+	for i, entry := range entries {
+		txs[i] = entry.tx
+	}
+	return txs
+}
+
 // GetTx returns the transaction [txID] if it was issued
 // by this node and returns whether it was dropped and whether
 // it exists.
@@ -342,6 +380,33 @@ func (m *Mempool) discardCurrentTx(tx *Tx) {
 	delete(m.currentTxs, tx.ID())
 }
 
+// RemovePendingTx removes [txID] from the mempool if it is in the [txHeap]
+// waiting to be issued into a block, and returns true if it was removed. The
+// removed tx is recorded as discarded, so that it is not added back when it
+// is gossiped by a peer.
+func (m *Mempool) RemovePendingTx(txID ids.ID) bool {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	tx, ok := m.txHeap.Get(txID)
+	if !ok {
+		return false
+	}
+	m.txHeap.Remove(txID)
+	m.utxoSet.Remove(tx.InputUTXOs().List()...)
+	m.discardedTxs.Put(txID, tx)
+
+	// Don't gossip the removed tx if it was not gossiped yet
+	newTxs := m.newTxs[:0]
+	for _, newTx := range m.newTxs {
+		if newTx.ID() != txID {
+			newTxs = append(newTxs, newTx)
+		}
+	}
+	m.newTxs = newTxs
+	return true
+}
+
 // RemoveTx removes [txID] from the mempool completely.
 func (m *Mempool) RemoveTx(txID ids.ID) {
 	m.lock.Lock()
@@ -366,6 +431,36 @@ func (m *Mempool) RemoveTx(txID ids.ID) {
 	m.discardedTxs.Evict(txID)
 }
 
+// AddFutureTx queues [tx], whose EVM inputs have a nonce ahead of their
+// account, until PopFutureTxs is called. At most [maxSize] txs are queued.
+func (m *Mempool) AddFutureTx(tx *Tx) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	txID := tx.ID()
+	if _, exists := m.futureTxs[txID]; exists {
+		return nil
+	}
+	if len(m.futureTxs) >= m.maxSize {
+		return errTooManyAtomicTx
+	}
+	m.futureTxs[txID] = tx
+	return nil
+}
+
+// PopFutureTxs removes and returns the txs queued by AddFutureTx.
+func (m *Mempool) PopFutureTxs() []*Tx {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	txs := make([]*Tx, 0, len(m.futureTxs))
+	for _, tx := range m.futureTxs {
+		txs = append(txs, tx)
+	}
+	m.futureTxs = make(map[ids.ID]*Tx)
+	return txs
+}
+
 // addPending makes sure that an item is in the Pending channel.
 func (m *Mempool) addPending() {
 	select {
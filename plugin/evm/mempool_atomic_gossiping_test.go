@@ -7,6 +7,7 @@ import (
 	"testing"
 
 	"github.com/ava-labs/coreth/params"
+	"github.com/ava-labs/coreth/plugin/evm/message"
 
 	"github.com/ava-labs/avalanchego/ids"
 	"github.com/ava-labs/avalanchego/utils/crypto"
@@ -88,6 +89,54 @@ func TestMempoolAddLocallyCreateAtomicTx(t *testing.T) {
 	}
 }
 
+// a pending tx should be removed from the mempool when it is canceled, and a
+// tx that is being issued or was accepted can't be canceled
+func TestMempoolCancelAtomicTx(t *testing.T) {
+	assert := assert.New(t)
+
+	// we use AP3 genesis here to not trip any block fees
+	issuer, vm, _, sharedMemory, _ := GenesisVM(t, true, genesisJSONApricotPhase3, "", "")
+	defer func() {
+		err := vm.Shutdown()
+		assert.NoError(err)
+	}()
+	mempool := vm.mempool
+
+	importTxs := createImportTxOptions(t, vm, sharedMemory)
+	tx, conflictingTx := importTxs[0], importTxs[1]
+	txID := tx.ID()
+
+	assert.NoError(vm.issueTx(tx, true /*=local*/))
+	assert.True(mempool.has(txID))
+
+	assert.NoError(vm.CancelAtomicTx(txID))
+	assert.False(mempool.has(txID), "canceled tx should be removed from the mempool")
+	_, status, _, err := vm.getAtomicTx(txID)
+	assert.NoError(err)
+	assert.Equal(Dropped, status)
+	assert.ErrorIs(vm.CancelAtomicTx(txID), errCancelNonPendingTx)
+
+	// The canceled tx should not be added back when a peer gossips it
+	msgBytes, err := message.Build(&message.AtomicTx{Tx: tx.Bytes()})
+	assert.NoError(err)
+	assert.NoError(vm.AppGossip(ids.GenerateTestShortID(), msgBytes))
+	assert.False(mempool.has(txID), "canceled tx should not be added back by gossip")
+
+	// The UTXOs spent by the canceled tx may be spent by another tx
+	conflictingTxID := conflictingTx.ID()
+	assert.NoError(vm.issueTx(conflictingTx, true /*=local*/))
+
+	<-issuer
+
+	blk, err := vm.BuildBlock()
+	assert.NoError(err)
+	assert.ErrorIs(vm.CancelAtomicTx(conflictingTxID), errCancelNonPendingTx, "tx being issued should not be canceled")
+
+	assert.NoError(blk.Verify())
+	assert.NoError(blk.Accept())
+	assert.ErrorIs(vm.CancelAtomicTx(conflictingTxID), errCancelNonPendingTx, "accepted tx should not be canceled")
+}
+
 // a valid tx shouldn't be added to the mempool if this would exceed the
 // mempool's max size
 func TestMempoolMaxMempoolSizeHandling(t *testing.T) {
@@ -0,0 +1,51 @@
+// (c) 2021, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package evm
+
+import (
+	"encoding/binary"
+
+	"github.com/ava-labs/avalanchego/utils/units"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+const (
+	// [mempoolBloomSize] is the size in bytes of the bloom filters exchanged
+	// with peers. With [mempoolBloomHashes] hash functions, a filter of 4096
+	// txs has a false positive rate of ~0.25%.
+	mempoolBloomSize   = 8 * units.KiB
+	mempoolBloomHashes = common.HashLength / 8
+)
+
+// mempoolBloom is a bloom filter of tx hashes. Because tx hashes are uniformly
+// distributed, the bits set for a hash are read directly from its bytes
+// rather than being derived from additional hash functions.
+type mempoolBloom []byte
+
+func newMempoolBloom() mempoolBloom {
+	return make(mempoolBloom, mempoolBloomSize)
+}
+
+// bit returns the index of the [i]th bit set for [hash].
+func (b mempoolBloom) bit(hash common.Hash, i int) uint64 {
+	return binary.BigEndian.Uint64(hash[i*8:]) % uint64(len(b)*8)
+}
+
+func (b mempoolBloom) add(hash common.Hash) {
+	for i := 0; i < mempoolBloomHashes; i++ {
+		bit := b.bit(hash, i)
+		b[bit/8] |= 1 << (bit % 8)
+	}
+}
+
+// contains returns true if [hash] may have been added to [b].
+func (b mempoolBloom) contains(hash common.Hash) bool {
+	for i := 0; i < mempoolBloomHashes; i++ {
+		bit := b.bit(hash, i)
+		if b[bit/8]&(1<<(bit%8)) == 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -12,9 +12,8 @@ import (
 )
 
 const (
-	codecVersion   uint16 = 0
-	maxMessageSize        = 512 * units.KiB
-	maxSliceLen           = maxMessageSize
+	maxMessageSize = 512 * units.KiB
+	maxSliceLen    = maxMessageSize
 )
 
 // Codec does serialization and deserialization
@@ -22,14 +21,26 @@ var c codec.Manager
 
 func init() {
 	c = codec.NewManager(maxMessageSize)
-	lc := linearcodec.New(reflectcodec.DefaultTagName, maxSliceLen)
 
 	errs := wrappers.Errs{}
-	errs.Add(
-		lc.RegisterType(&AtomicTx{}),
-		lc.RegisterType(&EthTxs{}),
-		c.RegisterCodec(codecVersion, lc),
-	)
+	for version := Version0; version <= CurrentVersion; version++ {
+		lc := linearcodec.New(reflectcodec.DefaultTagName, maxSliceLen)
+		errs.Add(
+			lc.RegisterType(&AtomicTx{}),
+			lc.RegisterType(&EthTxs{}),
+		)
+		if version >= Version1 {
+			errs.Add(
+				lc.RegisterType(&MempoolBloom{}),
+				lc.RegisterType(&TxReplaced{}),
+				lc.RegisterType(&TxsAck{}),
+				lc.RegisterType(&BlockAnnouncement{}),
+				lc.RegisterType(&EthTxHashes{}),
+				lc.RegisterType(&EthTxsRequest{}),
+			)
+		}
+		errs.Add(c.RegisterCodec(uint16(version), lc))
+	}
 	if errs.Errored() {
 		panic(errs.Err)
 	}
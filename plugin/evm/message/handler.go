@@ -14,6 +14,12 @@ var _ Handler = NoopHandler{}
 type Handler interface {
 	HandleAtomicTx(nodeID ids.ShortID, requestID uint32, msg *AtomicTx) error
 	HandleEthTxs(nodeID ids.ShortID, requestID uint32, msg *EthTxs) error
+	HandleMempoolBloom(nodeID ids.ShortID, requestID uint32, msg *MempoolBloom) error
+	HandleTxReplaced(nodeID ids.ShortID, requestID uint32, msg *TxReplaced) error
+	HandleTxsAck(nodeID ids.ShortID, requestID uint32, msg *TxsAck) error
+	HandleBlockAnnouncement(nodeID ids.ShortID, requestID uint32, msg *BlockAnnouncement) error
+	HandleEthTxHashes(nodeID ids.ShortID, requestID uint32, msg *EthTxHashes) error
+	HandleEthTxsRequest(nodeID ids.ShortID, requestID uint32, msg *EthTxsRequest) error
 }
 
 type NoopHandler struct{}
@@ -27,3 +33,33 @@ func (NoopHandler) HandleEthTxs(nodeID ids.ShortID, requestID uint32, _ *EthTxs)
 	log.Debug("dropping unexpected EthTxs message", "peerID", nodeID, "requestID", requestID)
 	return nil
 }
+
+func (NoopHandler) HandleMempoolBloom(nodeID ids.ShortID, requestID uint32, _ *MempoolBloom) error {
+	log.Debug("dropping unexpected MempoolBloom message", "peerID", nodeID, "requestID", requestID)
+	return nil
+}
+
+func (NoopHandler) HandleTxReplaced(nodeID ids.ShortID, requestID uint32, _ *TxReplaced) error {
+	log.Debug("dropping unexpected TxReplaced message", "peerID", nodeID, "requestID", requestID)
+	return nil
+}
+
+func (NoopHandler) HandleTxsAck(nodeID ids.ShortID, requestID uint32, _ *TxsAck) error {
+	log.Debug("dropping unexpected TxsAck message", "peerID", nodeID, "requestID", requestID)
+	return nil
+}
+
+func (NoopHandler) HandleBlockAnnouncement(nodeID ids.ShortID, requestID uint32, _ *BlockAnnouncement) error {
+	log.Debug("dropping unexpected BlockAnnouncement message", "peerID", nodeID, "requestID", requestID)
+	return nil
+}
+
+func (NoopHandler) HandleEthTxHashes(nodeID ids.ShortID, requestID uint32, _ *EthTxHashes) error {
+	log.Debug("dropping unexpected EthTxHashes message", "peerID", nodeID, "requestID", requestID)
+	return nil
+}
+
+func (NoopHandler) HandleEthTxsRequest(nodeID ids.ShortID, requestID uint32, _ *EthTxsRequest) error {
+	log.Debug("dropping unexpected EthTxsRequest message", "peerID", nodeID, "requestID", requestID)
+	return nil
+}
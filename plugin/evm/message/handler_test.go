@@ -12,7 +12,7 @@ import (
 )
 
 type CounterHandler struct {
-	AtomicTx, EthTxs int
+	AtomicTx, EthTxs, MempoolBloom, TxReplaced, TxsAck, BlockAnnouncement, EthTxHashes, EthTxsRequest int
 }
 
 func (h *CounterHandler) HandleAtomicTx(ids.ShortID, uint32, *AtomicTx) error {
@@ -25,6 +25,36 @@ func (h *CounterHandler) HandleEthTxs(ids.ShortID, uint32, *EthTxs) error {
 	return nil
 }
 
+func (h *CounterHandler) HandleMempoolBloom(ids.ShortID, uint32, *MempoolBloom) error {
+	h.MempoolBloom++
+	return nil
+}
+
+func (h *CounterHandler) HandleTxReplaced(ids.ShortID, uint32, *TxReplaced) error {
+	h.TxReplaced++
+	return nil
+}
+
+func (h *CounterHandler) HandleTxsAck(ids.ShortID, uint32, *TxsAck) error {
+	h.TxsAck++
+	return nil
+}
+
+func (h *CounterHandler) HandleBlockAnnouncement(ids.ShortID, uint32, *BlockAnnouncement) error {
+	h.BlockAnnouncement++
+	return nil
+}
+
+func (h *CounterHandler) HandleEthTxHashes(ids.ShortID, uint32, *EthTxHashes) error {
+	h.EthTxHashes++
+	return nil
+}
+
+func (h *CounterHandler) HandleEthTxsRequest(ids.ShortID, uint32, *EthTxsRequest) error {
+	h.EthTxsRequest++
+	return nil
+}
+
 func TestHandleAtomicTx(t *testing.T) {
 	assert := assert.New(t)
 
@@ -49,6 +79,90 @@ func TestHandleEthTxs(t *testing.T) {
 	assert.Equal(1, handler.EthTxs)
 }
 
+func TestHandleMempoolBloom(t *testing.T) {
+	assert := assert.New(t)
+
+	handler := CounterHandler{}
+	msg := MempoolBloom{}
+
+	err := msg.Handle(&handler, ids.ShortEmpty, 0)
+	assert.NoError(err)
+	assert.Zero(handler.AtomicTx)
+	assert.Zero(handler.EthTxs)
+	assert.Equal(1, handler.MempoolBloom)
+}
+
+func TestHandleTxReplaced(t *testing.T) {
+	assert := assert.New(t)
+
+	handler := CounterHandler{}
+	msg := TxReplaced{}
+
+	err := msg.Handle(&handler, ids.ShortEmpty, 0)
+	assert.NoError(err)
+	assert.Zero(handler.AtomicTx)
+	assert.Zero(handler.EthTxs)
+	assert.Zero(handler.MempoolBloom)
+	assert.Equal(1, handler.TxReplaced)
+}
+
+func TestHandleTxsAck(t *testing.T) {
+	assert := assert.New(t)
+
+	handler := CounterHandler{}
+	msg := TxsAck{}
+
+	err := msg.Handle(&handler, ids.ShortEmpty, 0)
+	assert.NoError(err)
+	assert.Zero(handler.AtomicTx)
+	assert.Zero(handler.EthTxs)
+	assert.Zero(handler.MempoolBloom)
+	assert.Zero(handler.TxReplaced)
+	assert.Equal(1, handler.TxsAck)
+}
+
+func TestHandleBlockAnnouncement(t *testing.T) {
+	assert := assert.New(t)
+
+	handler := CounterHandler{}
+	msg := BlockAnnouncement{}
+
+	err := msg.Handle(&handler, ids.ShortEmpty, 0)
+	assert.NoError(err)
+	assert.Zero(handler.AtomicTx)
+	assert.Zero(handler.EthTxs)
+	assert.Zero(handler.MempoolBloom)
+	assert.Zero(handler.TxReplaced)
+	assert.Zero(handler.TxsAck)
+	assert.Equal(1, handler.BlockAnnouncement)
+}
+
+func TestHandleEthTxHashes(t *testing.T) {
+	assert := assert.New(t)
+
+	handler := CounterHandler{}
+	msg := EthTxHashes{}
+
+	err := msg.Handle(&handler, ids.ShortEmpty, 0)
+	assert.NoError(err)
+	assert.Zero(handler.EthTxs)
+	assert.Zero(handler.EthTxsRequest)
+	assert.Equal(1, handler.EthTxHashes)
+}
+
+func TestHandleEthTxsRequest(t *testing.T) {
+	assert := assert.New(t)
+
+	handler := CounterHandler{}
+	msg := EthTxsRequest{}
+
+	err := msg.Handle(&handler, ids.ShortEmpty, 0)
+	assert.NoError(err)
+	assert.Zero(handler.EthTxs)
+	assert.Zero(handler.EthTxHashes)
+	assert.Equal(1, handler.EthTxsRequest)
+}
+
 func TestNoopHandler(t *testing.T) {
 	assert := assert.New(t)
 
@@ -59,4 +173,22 @@ func TestNoopHandler(t *testing.T) {
 
 	err = handler.HandleEthTxs(ids.ShortEmpty, 0, nil)
 	assert.NoError(err)
+
+	err = handler.HandleMempoolBloom(ids.ShortEmpty, 0, nil)
+	assert.NoError(err)
+
+	err = handler.HandleTxReplaced(ids.ShortEmpty, 0, nil)
+	assert.NoError(err)
+
+	err = handler.HandleTxsAck(ids.ShortEmpty, 0, nil)
+	assert.NoError(err)
+
+	err = handler.HandleBlockAnnouncement(ids.ShortEmpty, 0, nil)
+	assert.NoError(err)
+
+	err = handler.HandleEthTxHashes(ids.ShortEmpty, 0, nil)
+	assert.NoError(err)
+
+	err = handler.HandleEthTxsRequest(ids.ShortEmpty, 0, nil)
+	assert.NoError(err)
 }
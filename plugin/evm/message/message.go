@@ -5,11 +5,13 @@ package message
 
 import (
 	"errors"
+	"fmt"
 
 	"github.com/ethereum/go-ethereum/common"
 
 	"github.com/ava-labs/avalanchego/ids"
 	"github.com/ava-labs/avalanchego/utils/units"
+	"github.com/ava-labs/avalanchego/utils/wrappers"
 )
 
 const (
@@ -20,11 +22,33 @@ const (
 	EthMsgSoftCapSize = common.StorageSize(64 * units.KiB)
 )
 
+// Version is the format version of a message. It is encoded as the codec
+// version prefix of the message.
+type Version uint16
+
+const (
+	// Version0 is the original message format.
+	Version0 Version = 0
+	// Version1 currently has the same format as [Version0]. Message types added
+	// after [Version0] should only be registered as of [Version1], so that
+	// they are never sent to peers that can not parse them.
+	Version1 Version = 1
+
+	// CurrentVersion is the latest message version supported by this node.
+	CurrentVersion = Version1
+)
+
 var (
 	_ Message = &AtomicTx{}
 	_ Message = &EthTxs{}
-
-	errUnexpectedCodecVersion = errors.New("unexpected codec version")
+	_ Message = &MempoolBloom{}
+	_ Message = &TxReplaced{}
+	_ Message = &TxsAck{}
+	_ Message = &BlockAnnouncement{}
+	_ Message = &EthTxHashes{}
+	_ Message = &EthTxsRequest{}
+
+	ErrUnknownVersion = errors.New("unknown message version")
 )
 
 type Message interface {
@@ -65,21 +89,121 @@ func (msg *EthTxs) Handle(handler Handler, nodeID ids.ShortID, requestID uint32)
 	return handler.HandleEthTxs(nodeID, requestID, msg)
 }
 
+// MempoolBloom is a bloom filter of the hashes of the eth txs in the sender's
+// mempool. It is only supported as of [Version1].
+type MempoolBloom struct {
+	message
+
+	Bloom []byte `serialize:"true"`
+}
+
+func (msg *MempoolBloom) Handle(handler Handler, nodeID ids.ShortID, requestID uint32) error {
+	return handler.HandleMempoolBloom(nodeID, requestID, msg)
+}
+
+// TxReplaced notifies peers that the eth txs with [Hashes] were replaced in
+// the sender's mempool by txs with the same nonce and a higher fee. It is only
+// supported as of [Version1].
+type TxReplaced struct {
+	message
+
+	Hashes []common.Hash `serialize:"true"`
+}
+
+func (msg *TxReplaced) Handle(handler Handler, nodeID ids.ShortID, requestID uint32) error {
+	return handler.HandleTxReplaced(nodeID, requestID, msg)
+}
+
+// TxsAck acknowledges that the sender added the eth txs with [Hashes], which
+// were gossiped to it by the recipient, to its mempool. It is only supported
+// as of [Version1].
+type TxsAck struct {
+	message
+
+	Hashes []common.Hash `serialize:"true"`
+}
+
+func (msg *TxsAck) Handle(handler Handler, nodeID ids.ShortID, requestID uint32) error {
+	return handler.HandleTxsAck(nodeID, requestID, msg)
+}
+
+// BlockAnnouncement notifies peers that the sender accepted the block [Hash]
+// at [Height], so that peers that are behind can discover it. It is only
+// supported as of [Version1].
+type BlockAnnouncement struct {
+	message
+
+	Hash   common.Hash `serialize:"true"`
+	Height uint64      `serialize:"true"`
+}
+
+func (msg *BlockAnnouncement) Handle(handler Handler, nodeID ids.ShortID, requestID uint32) error {
+	return handler.HandleBlockAnnouncement(nodeID, requestID, msg)
+}
+
+// EthTxHashes announces the hashes of eth txs in the sender's mempool, so that
+// peers can request only the txs that they don't have with [EthTxsRequest].
+// It is only supported as of [Version1].
+type EthTxHashes struct {
+	message
+
+	Hashes []common.Hash `serialize:"true"`
+}
+
+func (msg *EthTxHashes) Handle(handler Handler, nodeID ids.ShortID, requestID uint32) error {
+	return handler.HandleEthTxHashes(nodeID, requestID, msg)
+}
+
+// EthTxsRequest requests the eth txs with [Hashes], which were announced by
+// the recipient with [EthTxHashes]. The txs are sent in the response as an
+// [EthTxs] message. It is only supported as of [Version1].
+type EthTxsRequest struct {
+	message
+
+	Hashes []common.Hash `serialize:"true"`
+}
+
+func (msg *EthTxsRequest) Handle(handler Handler, nodeID ids.ShortID, requestID uint32) error {
+	return handler.HandleEthTxsRequest(nodeID, requestID, msg)
+}
+
 func Parse(bytes []byte) (Message, error) {
-	var msg Message
-	version, err := c.Unmarshal(bytes, &msg)
-	if err != nil {
-		return nil, err
+	msg, _, err := ParseWithVersion(bytes)
+	return msg, err
+}
+
+// ParseWithVersion parses [bytes] into a message and returns the version the
+// message was encoded with. Returns [ErrUnknownVersion] if the message was
+// encoded with a version later than [CurrentVersion].
+func ParseWithVersion(bytes []byte) (Message, Version, error) {
+	p := wrappers.Packer{Bytes: bytes}
+	version := Version(p.UnpackShort())
+	if p.Errored() {
+		return nil, 0, p.Err
 	}
-	if version != codecVersion {
-		return nil, errUnexpectedCodecVersion
+	if version > CurrentVersion {
+		return nil, version, fmt.Errorf("%w: %d", ErrUnknownVersion, version)
+	}
+
+	var msg Message
+	if _, err := c.Unmarshal(bytes, &msg); err != nil {
+		return nil, version, err
 	}
 	msg.initialize(bytes)
-	return msg, nil
+	return msg, version, nil
 }
 
+// Build encodes [msg] using [CurrentVersion].
 func Build(msg Message) ([]byte, error) {
-	bytes, err := c.Marshal(codecVersion, &msg)
+	return BuildWithVersion(msg, CurrentVersion)
+}
+
+// BuildWithVersion encodes [msg] using [version].
+func BuildWithVersion(msg Message, version Version) ([]byte, error) {
+	if version > CurrentVersion {
+		return nil, fmt.Errorf("%w: %d", ErrUnknownVersion, version)
+	}
+	bytes, err := c.Marshal(uint16(version), &msg)
 	msg.initialize(bytes)
 	return bytes, err
 }
@@ -4,11 +4,14 @@
 package message
 
 import (
+	"encoding/binary"
 	"testing"
 
 	"github.com/ava-labs/avalanchego/utils"
 	"github.com/ava-labs/avalanchego/utils/units"
 
+	"github.com/ethereum/go-ethereum/common"
+
 	"github.com/stretchr/testify/assert"
 )
 
@@ -54,6 +57,137 @@ func TestEthTxs(t *testing.T) {
 	assert.Equal(msg, parsedMsg.Txs)
 }
 
+func TestMempoolBloom(t *testing.T) {
+	assert := assert.New(t)
+
+	bloom := []byte("blah")
+	builtMsg := MempoolBloom{
+		Bloom: bloom,
+	}
+	builtMsgBytes, err := Build(&builtMsg)
+	assert.NoError(err)
+	assert.Equal(builtMsgBytes, builtMsg.Bytes())
+
+	parsedMsgIntf, err := Parse(builtMsgBytes)
+	assert.NoError(err)
+	assert.Equal(builtMsgBytes, parsedMsgIntf.Bytes())
+
+	parsedMsg, ok := parsedMsgIntf.(*MempoolBloom)
+	assert.True(ok)
+
+	assert.Equal(bloom, parsedMsg.Bloom)
+
+	// [MempoolBloom] can not be sent to peers that only support [Version0]
+	_, err = BuildWithVersion(&MempoolBloom{Bloom: bloom}, Version0)
+	assert.Error(err)
+}
+
+func TestTxReplaced(t *testing.T) {
+	assert := assert.New(t)
+
+	hashes := []common.Hash{{1}, {2}}
+	builtMsg := TxReplaced{
+		Hashes: hashes,
+	}
+	builtMsgBytes, err := Build(&builtMsg)
+	assert.NoError(err)
+	assert.Equal(builtMsgBytes, builtMsg.Bytes())
+
+	parsedMsgIntf, err := Parse(builtMsgBytes)
+	assert.NoError(err)
+	assert.Equal(builtMsgBytes, parsedMsgIntf.Bytes())
+
+	parsedMsg, ok := parsedMsgIntf.(*TxReplaced)
+	assert.True(ok)
+
+	assert.Equal(hashes, parsedMsg.Hashes)
+
+	// [TxReplaced] can not be sent to peers that only support [Version0]
+	_, err = BuildWithVersion(&TxReplaced{Hashes: hashes}, Version0)
+	assert.Error(err)
+}
+
+func TestTxsAck(t *testing.T) {
+	assert := assert.New(t)
+
+	hashes := []common.Hash{{1}, {2}}
+	builtMsg := TxsAck{
+		Hashes: hashes,
+	}
+	builtMsgBytes, err := Build(&builtMsg)
+	assert.NoError(err)
+	assert.Equal(builtMsgBytes, builtMsg.Bytes())
+
+	parsedMsgIntf, err := Parse(builtMsgBytes)
+	assert.NoError(err)
+	assert.Equal(builtMsgBytes, parsedMsgIntf.Bytes())
+
+	parsedMsg, ok := parsedMsgIntf.(*TxsAck)
+	assert.True(ok)
+
+	assert.Equal(hashes, parsedMsg.Hashes)
+
+	// [TxsAck] can not be sent to peers that only support [Version0]
+	_, err = BuildWithVersion(&TxsAck{Hashes: hashes}, Version0)
+	assert.Error(err)
+}
+
+func TestBlockAnnouncement(t *testing.T) {
+	assert := assert.New(t)
+
+	hash := common.Hash{1}
+	builtMsg := BlockAnnouncement{
+		Hash:   hash,
+		Height: 1234,
+	}
+	builtMsgBytes, err := Build(&builtMsg)
+	assert.NoError(err)
+	assert.Equal(builtMsgBytes, builtMsg.Bytes())
+
+	parsedMsgIntf, err := Parse(builtMsgBytes)
+	assert.NoError(err)
+	assert.Equal(builtMsgBytes, parsedMsgIntf.Bytes())
+
+	parsedMsg, ok := parsedMsgIntf.(*BlockAnnouncement)
+	assert.True(ok)
+
+	assert.Equal(hash, parsedMsg.Hash)
+	assert.EqualValues(1234, parsedMsg.Height)
+
+	// [BlockAnnouncement] can not be sent to peers that only support [Version0]
+	_, err = BuildWithVersion(&BlockAnnouncement{Hash: hash, Height: 1234}, Version0)
+	assert.Error(err)
+}
+
+func TestEthTxHashesAndRequest(t *testing.T) {
+	assert := assert.New(t)
+
+	hashes := []common.Hash{{1}, {2}}
+	for _, builtMsg := range []Message{
+		&EthTxHashes{Hashes: hashes},
+		&EthTxsRequest{Hashes: hashes},
+	} {
+		builtMsgBytes, err := Build(builtMsg)
+		assert.NoError(err)
+		assert.Equal(builtMsgBytes, builtMsg.Bytes())
+
+		parsedMsg, err := Parse(builtMsgBytes)
+		assert.NoError(err)
+		assert.Equal(builtMsgBytes, parsedMsg.Bytes())
+		assert.IsType(builtMsg, parsedMsg)
+
+		// The messages can not be sent to peers that only support [Version0]
+		_, err = BuildWithVersion(builtMsg, Version0)
+		assert.Error(err)
+	}
+
+	builtMsgBytes, err := Build(&EthTxsRequest{Hashes: hashes})
+	assert.NoError(err)
+	parsedMsg, err := Parse(builtMsgBytes)
+	assert.NoError(err)
+	assert.Equal(hashes, parsedMsg.(*EthTxsRequest).Hashes)
+}
+
 func TestEthTxsTooLarge(t *testing.T) {
 	assert := assert.New(t)
 
@@ -71,3 +205,44 @@ func TestParseGibberish(t *testing.T) {
 	_, err := Parse(randomBytes)
 	assert.Error(err)
 }
+
+func TestVersionRoundTrip(t *testing.T) {
+	for version := Version0; version <= CurrentVersion; version++ {
+		assert := assert.New(t)
+
+		msg := []byte("blah")
+		builtMsg := EthTxs{
+			Txs: msg,
+		}
+		builtMsgBytes, err := BuildWithVersion(&builtMsg, version)
+		assert.NoError(err)
+
+		parsedMsgIntf, parsedVersion, err := ParseWithVersion(builtMsgBytes)
+		assert.NoError(err)
+		assert.Equal(version, parsedVersion)
+		assert.Equal(builtMsgBytes, parsedMsgIntf.Bytes())
+
+		parsedMsg, ok := parsedMsgIntf.(*EthTxs)
+		assert.True(ok)
+		assert.Equal(msg, parsedMsg.Txs)
+	}
+}
+
+func TestParseUnknownVersion(t *testing.T) {
+	assert := assert.New(t)
+
+	builtMsgBytes, err := Build(&AtomicTx{Tx: []byte("blah")})
+	assert.NoError(err)
+
+	// Overwrite the version prefix with a version that is not supported
+	unknownMsgBytes := make([]byte, len(builtMsgBytes))
+	copy(unknownMsgBytes, builtMsgBytes)
+	binary.BigEndian.PutUint16(unknownMsgBytes, uint16(CurrentVersion+1))
+
+	_, version, err := ParseWithVersion(unknownMsgBytes)
+	assert.ErrorIs(err, ErrUnknownVersion)
+	assert.Equal(CurrentVersion+1, version)
+
+	_, err = BuildWithVersion(&AtomicTx{}, CurrentVersion+1)
+	assert.ErrorIs(err, ErrUnknownVersion)
+}
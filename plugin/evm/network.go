@@ -5,19 +5,30 @@ package evm
 
 import (
 	"container/heap"
+	"container/list"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
 	"math/big"
+	"math/rand"
+	"reflect"
 	"sync"
 	"time"
 
-	"github.com/ava-labs/avalanchego/cache"
+	"github.com/ava-labs/avalanchego/codec"
 	"github.com/ava-labs/avalanchego/ids"
 	"github.com/ava-labs/avalanchego/snow"
+	"github.com/ava-labs/avalanchego/utils/timer/mockable"
+	"github.com/ava-labs/avalanchego/utils/units"
 	"github.com/ava-labs/avalanchego/utils/wrappers"
+	"github.com/ava-labs/avalanchego/version"
 
 	commonEng "github.com/ava-labs/avalanchego/snow/engine/common"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
 	"github.com/ethereum/go-ethereum/rlp"
 
 	"github.com/ava-labs/coreth/core"
@@ -33,11 +44,99 @@ const (
 	// in the cache, not entire transactions.
 	recentCacheSize = 512
 
+	// [peerKnownTxsSize] is the number of acknowledged tx hashes remembered
+	// for each connected peer.
+	peerKnownTxsSize = 4096
+
+	// [recentEntryOverhead] is the approximate number of bytes used by a
+	// recent cache entry in addition to its key and value.
+	recentEntryOverhead = 64
+
+	// [droppedTxsChanSize] is the size of the channel listening for txs
+	// dropped from the tx pool.
+	droppedTxsChanSize = 1024
+
+	// [maxPendingGossipTxs] is the maximum number of atomic txs, and of eth
+	// txs, that are queued to be gossiped once enough peers are connected.
+	maxPendingGossipTxs = 4096
+
 	// [ethTxsGossipInterval] is how often we attempt to gossip newly seen
 	// transactions to other nodes.
 	ethTxsGossipInterval = 500 * time.Millisecond
+
+	// [maxEthTxsPerMessage] is the maximum number of eth txs that a gossiped
+	// EthTxs message may hold. Messages are built to hold at most
+	// [message.EthMsgSoftCapSize] bytes of txs, which fits well under this
+	// many of the smallest valid txs.
+	maxEthTxsPerMessage = 1024
+
+	// [maxEthTxsMsgSize] is the maximum size in bytes of the encoded txs that a
+	// gossiped EthTxs message may hold. Messages hold at most
+	// [message.EthMsgSoftCapSize] bytes of txs, unless they hold a single
+	// larger tx, which the tx pool limits to 128 KiB.
+	maxEthTxsMsgSize = 256 * units.KiB
+
+	// [gossipFlushTimeout] is the maximum amount of time that shutting down
+	// the network waits for the buffered eth txs to be gossiped.
+	gossipFlushTimeout = 5 * time.Second
+
+	// [ethTxsSendRetries] is the number of times sending an eth txs message is
+	// retried after the first attempt fails. The delay before each retry
+	// starts at [ethTxsSendRetryBackoff] and doubles after every retry.
+	ethTxsSendRetries      = 2
+	ethTxsSendRetryBackoff = 50 * time.Millisecond
+
+	// [atomicTxRequestRetries] is the number of times an atomic tx request
+	// that failed is resent to another peer if [ReliableAtomicGossip] is
+	// enabled.
+	atomicTxRequestRetries = 3
+
+	// [mempoolBloomGossipInterval] is how often we gossip a bloom filter of our
+	// mempool if [MempoolBloomEnabled], and [peerBloomTTL] is how long a bloom
+	// filter received from a peer is used.
+	mempoolBloomGossipInterval = 10 * time.Second
+	peerBloomTTL               = 3 * mempoolBloomGossipInterval
+)
+
+// Values of the "reason" field logged whenever a gossip message or tx is
+// dropped. Operators aggregate logs on these values, so they must not change.
+const (
+	dropReasonBeforeActivation = "before_activation"
+	dropReasonUnknownVersion   = "unknown_version"
+	dropReasonParseFailed      = "parse_failed"
+	dropReasonEmptyMsg         = "empty_msg"
+	dropReasonRecentDupe       = "recent_dupe"
+	dropReasonNotPending       = "not_pending"
+	dropReasonKnownTx          = "known_tx"
+	dropReasonMempoolRejected  = "mempool_rejected"
+	dropReasonBloomDisabled    = "bloom_disabled"
+	dropReasonInvalidBloom     = "invalid_bloom"
+	dropReasonOversizedTx      = "oversized_tx"
+	dropReasonSenderLimit      = "sender_limit"
+	dropReasonMempoolFull      = "mempool_full"
+	dropReasonPeerNotAllowed   = "peer_not_allowed"
+	dropReasonTooManyTxs       = "too_many_txs"
+	dropReasonNotValidator     = "not_validator"
+	dropReasonOversizedMsg     = "oversized_msg"
+	dropReasonLowGasPrice      = "low_gas_price"
+	// [dropReasonTxTypeNotActivated] is used for gossiped eth txs of a type
+	// that is not yet activated by this node's rules, which suggests that the
+	// sender is following different fork rules.
+	dropReasonTxTypeNotActivated = "tx_type_not_activated"
+	// [dropReasonInboundQueueFull] is used for the oldest gossip message
+	// queued to be handled once a newer message is queued behind a full
+	// queue.
+	dropReasonInboundQueueFull = "inbound_queue_full"
+	// [dropReasonPendingQueueFull] is used for txs that could not be gossiped
+	// and could not be queued to be gossiped again, as [maxPendingGossipTxs]
+	// txs are already queued.
+	dropReasonPendingQueueFull = "pending_queue_full"
 )
 
+// [version1MinNodeVersion] is the first node version that supports parsing
+// [message.Version1] messages.
+var version1MinNodeVersion = version.NewDefaultVersion(1, 7, 5)
+
 type Network interface {
 	// Message handling
 	AppRequestFailed(nodeID ids.ShortID, requestID uint32) error
@@ -45,9 +144,101 @@ type Network interface {
 	AppResponse(nodeID ids.ShortID, requestID uint32, msgBytes []byte) error
 	AppGossip(nodeID ids.ShortID, msgBytes []byte) error
 
+	// Peer tracking
+	Connected(nodeID ids.ShortID, nodeVersion version.Application) error
+	Disconnected(nodeID ids.ShortID) error
+
 	// Gossip entrypoints
 	GossipAtomicTxs(txs []*Tx) error
 	GossipEthTxs(txs []*types.Transaction) error
+	// GossipEthTx gossips [tx] right away, without waiting to batch it with
+	// other txs.
+	GossipEthTx(tx *types.Transaction) error
+	GossipEthTxsByHash(hashes []common.Hash) error
+	// GossipBlockAnnouncement notifies peers that the block [hash] was
+	// accepted at [height], if [BlockAnnouncementGossipEnabled].
+	GossipBlockAnnouncement(hash common.Hash, height uint64) error
+	// RegossipPendingTxs gossips every pending eth and atomic tx, even if it
+	// was recently gossiped, and returns the number of eth and atomic txs
+	// that were queued.
+	RegossipPendingTxs() (int, int, error)
+
+	// PendingGossipQueue and PendingAtomicGossipQueue return, in no
+	// particular order, the txs queued to be gossiped once enough peers are
+	// connected, including txs whose gossip failed to be sent.
+	PendingGossipQueue() []common.Hash
+	PendingAtomicGossipQueue() []ids.ID
+
+	// AtomicTxSource returns the peer that gossiped the atomic tx [txID] to
+	// this node, if the tx was recently issued from gossip.
+	AtomicTxSource(txID ids.ID) (ids.ShortID, bool)
+
+	// NetworkStats returns a snapshot of the current gossip state
+	NetworkStats() NetworkStats
+	// GossipMessageCounts returns the number of inbound messages handled and
+	// the last time that one was handled, keyed by message type
+	GossipMessageCounts() map[string]GossipMessageCount
+
+	// SetGossipChannels gossips each eth tx with the sender in [senders] of
+	// the channel that [route] returns for it. Eth txs whose channel has no
+	// sender are gossiped with the VM's AppSender, which is also used for
+	// every other message.
+	SetGossipChannels(senders map[ids.ID]commonEng.AppSender, route func(tx *types.Transaction) ids.ID)
+
+	// GossipEnabled returns false if the gossip entrypoints are no-ops, so
+	// that callers do not assume that the txs passed to them were gossiped.
+	GossipEnabled() bool
+	// GossipBackpressure returns true if gossip is currently being deferred
+	// or dropped because too few peers are connected, the mempool is full, or
+	// too many AppRequests are awaiting a response.
+	GossipBackpressure() bool
+
+	// Shutdown gossips the txs that are buffered to be gossiped. It must be
+	// called before the VM is shut down.
+	Shutdown()
+}
+
+// MempoolIface is the subset of the atomic mempool that [pushNetwork] depends
+// on to gossip atomic txs and to issue atomic txs received from peers.
+type MempoolIface interface {
+	// GetTx returns the tx [txID], whether it was dropped, and whether it was
+	// found.
+	GetTx(txID ids.ID) (*Tx, bool, bool)
+	// GetPendingTx returns the tx [txID] if it is waiting to be issued into a
+	// block.
+	GetPendingTx(txID ids.ID) (*Tx, bool)
+	// PendingTxs returns the txs waiting to be issued into a block, ordered
+	// from the highest to the lowest gas price.
+	PendingTxs() []*Tx
+	// IssueTx verifies [tx] and attempts to add it to the mempool.
+	IssueTx(tx *Tx, local bool) error
+	// HasCapacity returns true if a tx can be added to the mempool without
+	// evicting another tx.
+	HasCapacity() bool
+}
+
+// vmMempool implements [MempoolIface] by issuing txs through the VM so that
+// they are verified before being added to [Mempool].
+type vmMempool struct {
+	*Mempool
+	vm *VM
+}
+
+func (m *vmMempool) IssueTx(tx *Tx, local bool) error {
+	return m.vm.issueTx(tx, local)
+}
+
+// NetworkStats is a snapshot of the gossip state of a [Network] used to debug
+// transaction propagation.
+type NetworkStats struct {
+	// Number of txs that are tracked as recently gossiped
+	RecentAtomicTxs int `json:"recentAtomicTxs"`
+	RecentEthTxs    int `json:"recentEthTxs"`
+	// Number of inbound messages handled, keyed by message type
+	MessagesHandled map[string]uint64 `json:"messagesHandled"`
+	// Last time that txs were gossiped to the network
+	LastAtomicTxsGossip time.Time `json:"lastAtomicTxsGossip"`
+	LastEthTxsGossip    time.Time `json:"lastEthTxsGossip"`
 }
 
 func (vm *VM) AppRequest(nodeID ids.ShortID, requestID uint32, deadline time.Time, request []byte) error {
@@ -66,19 +257,39 @@ func (vm *VM) AppGossip(nodeID ids.ShortID, msg []byte) error {
 	return vm.network.AppGossip(nodeID, msg)
 }
 
-// NewNetwork creates a new Network based on the [vm.chainConfig].
+// NewNetwork creates a new Network based on the [vm.chainConfig] and the
+// configured [GossipMode].
 func (vm *VM) NewNetwork(appSender commonEng.AppSender) Network {
-	if vm.chainConfig.ApricotPhase4BlockTimestamp != nil {
-		return vm.newPushNetwork(
-			time.Unix(vm.chainConfig.ApricotPhase4BlockTimestamp.Int64(), 0),
-			vm.config,
-			appSender,
-			vm.chain,
-			vm.mempool,
-		)
+	if vm.chainConfig.ApricotPhase4BlockTimestamp == nil {
+		return &noopNetwork{}
 	}
 
-	return &noopNetwork{}
+	net := vm.newPushNetwork(
+		time.Unix(vm.chainConfig.ApricotPhase4BlockTimestamp.Int64(), 0),
+		vm.config,
+		appSender,
+		vm.chain,
+		&vmMempool{Mempool: vm.mempool, vm: vm},
+	)
+	net.start()
+	return withGossipMode(net, vm.config.GossipMode)
+}
+
+// withGossipMode returns the Network that gossips eth txs with [net] as
+// described by [mode].
+func withGossipMode(net *pushNetwork, mode GossipMode) Network {
+	switch mode {
+	case GossipModePull:
+		pull := &pullNetwork{pushNetwork: net}
+		net.ethTxsGossiper = pull
+		return pull
+	case GossipModeHybrid:
+		hybrid := &hybridNetwork{pushNetwork: net}
+		net.ethTxsGossiper = hybrid
+		return hybrid
+	default:
+		return net
+	}
 }
 
 type pushNetwork struct {
@@ -88,7 +299,15 @@ type pushNetwork struct {
 
 	appSender commonEng.AppSender
 	chain     *coreth.ETHChain
-	mempool   *Mempool
+	mempool   MempoolIface
+
+	// [channelSenders] are the senders of additional gossip channels, keyed
+	// by channel ID, and [ethTxChannel] returns the channel that an eth tx is
+	// gossiped on. Eth txs that are not routed to one of [channelSenders] are
+	// gossiped with [appSender].
+	channelsLock   sync.RWMutex
+	channelSenders map[ids.ID]commonEng.AppSender
+	ethTxChannel   func(tx *types.Transaction) ids.ID
 
 	gossipHandler message.Handler
 
@@ -96,14 +315,314 @@ type pushNetwork struct {
 	// amplification of mempol chatter.
 	ethTxsToGossipChan chan []*types.Transaction
 	ethTxsToGossip     map[common.Hash]*types.Transaction
+	// [ethTxsToGossipSize] is the total size of the txs in [ethTxsToGossip].
+	ethTxsToGossipSize common.StorageSize
 	lastGossiped       time.Time
 	shutdownChan       chan struct{}
 	shutdownWg         *sync.WaitGroup
 
+	// [ethTxsToRegossipChan] receives txs that should be gossiped even if
+	// they were recently gossiped.
+	ethTxsToRegossipChan chan []*types.Transaction
+	// [flushGossipChan] receives a channel that is closed once the buffered
+	// eth txs are gossiped.
+	flushGossipChan chan chan struct{}
+
 	// [recentAtomicTxs] and [recentEthTxs] prevent us from over-gossiping the
 	// same transaction in a short period of time.
-	recentAtomicTxs *cache.LRU
-	recentEthTxs    *cache.LRU
+	recentAtomicTxs *recentCache
+	recentEthTxs    *recentCache
+
+	// [statsLock] protects the fields below, which are reported by
+	// [NetworkStats] and [GossipMessageCounts] and may be read concurrently
+	// with gossiping.
+	statsLock           sync.Mutex
+	messagesHandled     map[string]uint64
+	messagesLastHandled map[string]time.Time
+	lastAtomicGossiped  time.Time
+	lastEthGossiped     time.Time
+
+	// [peerVersions] is the latest message version supported by each
+	// connected peer. Because gossip is sent to all peers, messages are built
+	// using the latest version supported by every connected peer.
+	peersLock    sync.RWMutex
+	peerVersions map[ids.ShortID]message.Version
+	// [peerBlooms] is the latest bloom filter of its mempool received from
+	// each connected peer.
+	peerBlooms map[ids.ShortID]peerBloom
+	// [peerKnownTxs] holds the hashes of the eth txs that each connected peer
+	// acknowledged adding to its mempool, so that they are not sent to it
+	// again.
+	peerKnownTxs map[ids.ShortID]*recentCache
+
+	// [outstandingRequests] maps the ID of each AppRequest that is awaiting a
+	// response to the peer it was sent to, and [onRequestFailed] and
+	// [onRequestResponse] hold the functions to call if the request fails or
+	// is responded to, if any. [requestTimers] holds the timer that fails
+	// each request after [AppRequestTimeout].
+	requestsLock        sync.Mutex
+	nextRequestID       uint32
+	outstandingRequests map[uint32]ids.ShortID
+	onRequestFailed     map[uint32]func()
+	onRequestResponse   map[uint32]func(response []byte)
+	requestTimers       map[uint32]*time.Timer
+
+	// [pendingAtomicTxs] and [pendingEthTxs] hold up to [maxPendingGossipTxs]
+	// txs each that could not be gossiped because not enough peers were
+	// connected or sending the gossip failed. They are gossiped again when the
+	// next peer connects or the txs are next regossiped. Eth txs are removed
+	// once the tx pool drops them.
+	pendingLock      sync.Mutex
+	pendingAtomicTxs map[ids.ID]*Tx
+	pendingEthTxs    map[common.Hash]*types.Transaction
+	// [pendingSince] is when the oldest queued tx was queued.
+	pendingSince time.Time
+
+	// [atomicTxSources] holds the peer that gossiped each of the atomic txs
+	// most recently issued from gossip, so that relaying peers can be
+	// identified.
+	atomicTxSources *recentCache
+
+	// [peerAllowlist] and [peerDenylist] restrict the peers that are gossiped
+	// with, as configured by [GossipPeerAllowlist] and [GossipPeerDenylist].
+	peerAllowlist ids.ShortSet
+	peerDenylist  ids.ShortSet
+
+	// [resyncHint] is called when a peer announces that it accepted a block
+	// above the last accepted block of this node.
+	resyncHint func(nodeID ids.ShortID, hash common.Hash, height uint64)
+
+	// [validators] is the cached validator set of this chain's subnet, which
+	// is used if [GossipFromValidatorsOnly].
+	validators *validatorSet
+
+	// [ethTxsGossiper] gossips the eth txs that are not routed to a gossip
+	// channel. If nil, the txs are pushed to peers.
+	ethTxsGossiper ethTxsGossiper
+
+	// [gossipWorkers] handle the gossip received from peers if
+	// [InboundGossipWorkers] is set. The gossip of each peer is queued to the
+	// same worker, so that it is handled in the order that it was received.
+	gossipWorkers []*gossipWorker
+
+	unknownVersionMsgs   metrics.Counter
+	inboundQueueFullMsgs metrics.Counter
+	notAllowedMsgs       metrics.Counter
+	tooManyTxsMsgs       metrics.Counter
+	nonValidatorMsgs     metrics.Counter
+	oversizedMsgs        metrics.Counter
+	pendingGossipTxs     metrics.Gauge
+	pendingGossipBytes   metrics.Gauge
+	pendingQueueFullTxs  metrics.Counter
+	oversizedTxs         metrics.Counter
+	lowGasPriceTxs       metrics.Counter
+	notActivatedTxs      metrics.Counter
+	senderLimitedTxs     metrics.Counter
+	mempoolFullTxs       metrics.Counter
+}
+
+// peerBloom is a bloom filter of a peer's mempool that may be used until
+// [expiry].
+type peerBloom struct {
+	bloom  mempoolBloom
+	expiry time.Time
+}
+
+// recentCache is an LRU cache of recently gossiped tx hashes. It is bounded
+// either by its number of entries or, if [maxBytes] is non-zero, by the
+// approximate number of bytes held by its entries. If [ttl] is non-zero, keys
+// are still reported as present for [ttl] after they were put, even if they
+// were evicted by the LRU policy.
+type recentCache struct {
+	lock sync.Mutex
+
+	maxLen   int
+	maxBytes int
+	bytes    int
+
+	// [order] holds *recentEntry values, most recently used first.
+	order   *list.List
+	entries map[interface{}]*list.Element
+
+	ttl   time.Duration
+	clock mockable.Clock
+	// [putTimes] holds *recentPut values, least recently put first, and
+	// [lastPut] holds the latest time each key was put within [ttl].
+	putTimes *list.List
+	lastPut  map[interface{}]time.Time
+}
+
+type recentEntry struct {
+	key, value interface{}
+	size       int
+}
+
+// recentEthTx is the value held by [recentEthTxs] for each eth tx.
+type recentEthTx struct {
+	// source is the peer that first gossiped the tx to this node, or
+	// ids.ShortEmpty if the tx was not received from a peer.
+	source ids.ShortID
+	// gossiped is true if this node has gossiped the tx.
+	gossiped bool
+}
+
+type recentPut struct {
+	key  interface{}
+	time time.Time
+}
+
+// newRecentCache returns a cache holding at most [maxLen] entries if
+// [maxBytes] is 0, and at most approximately [maxBytes] bytes otherwise.
+func newRecentCache(maxLen, maxBytes int) *recentCache {
+	return newRecentCacheWithTTL(maxLen, maxBytes, 0)
+}
+
+// newRecentCacheWithTTL returns a cache bounded as by newRecentCache that
+// reports its keys as present for at least [ttl] after they were put.
+func newRecentCacheWithTTL(maxLen, maxBytes int, ttl time.Duration) *recentCache {
+	return &recentCache{
+		maxLen:   maxLen,
+		maxBytes: maxBytes,
+		order:    list.New(),
+		entries:  make(map[interface{}]*list.Element),
+		ttl:      ttl,
+		putTimes: list.New(),
+		lastPut:  make(map[interface{}]time.Time),
+	}
+}
+
+// recentEntrySize returns the approximate number of bytes used to store
+// [key] and [value] in a recentCache.
+func recentEntrySize(key, value interface{}) int {
+	size := recentEntryOverhead
+	for _, elem := range []interface{}{key, value} {
+		switch elem := elem.(type) {
+		case common.Hash:
+			size += common.HashLength
+		case ids.ID:
+			size += len(elem)
+		case ids.ShortID:
+			size += len(elem)
+		case *recentEthTx:
+			size += len(elem.source) + 1
+		case []byte:
+			size += len(elem)
+		}
+	}
+	return size
+}
+
+func (c *recentCache) Get(key interface{}) (interface{}, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	elem, ok := c.entries[key]
+	if !ok {
+		// The value of an evicted key is no longer known.
+		c.expirePuts()
+		_, ok := c.lastPut[key]
+		return nil, ok
+	}
+	c.order.MoveToFront(elem)
+	return elem.Value.(*recentEntry).value, true
+}
+
+func (c *recentCache) Put(key, value interface{}) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if elem, ok := c.entries[key]; ok {
+		c.removeElement(elem)
+	}
+	entry := &recentEntry{
+		key:   key,
+		value: value,
+		size:  recentEntrySize(key, value),
+	}
+	c.entries[key] = c.order.PushFront(entry)
+	c.bytes += entry.size
+
+	if c.ttl > 0 {
+		now := c.clock.Time()
+		c.putTimes.PushBack(&recentPut{key: key, time: now})
+		c.lastPut[key] = now
+		c.expirePuts()
+	}
+
+	// Evict the least recently used entries until the cache is within its
+	// bounds, always keeping the entry that was just added.
+	for c.order.Len() > 1 && c.full() {
+		c.removeElement(c.order.Back())
+	}
+}
+
+// Evict removes [key] from the cache so that it may be gossiped again.
+func (c *recentCache) Evict(key interface{}) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if elem, ok := c.entries[key]; ok {
+		c.removeElement(elem)
+	}
+	delete(c.lastPut, key)
+}
+
+// Len returns the number of hashes in the cache.
+func (c *recentCache) Len() int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.order.Len()
+}
+
+// Bytes returns the approximate number of bytes held by the cache.
+func (c *recentCache) Bytes() int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.bytes
+}
+
+// full returns true if the cache exceeds its bounds. Assumes [c.lock] is held.
+func (c *recentCache) full() bool {
+	if c.maxBytes > 0 {
+		return c.bytes > c.maxBytes
+	}
+	return c.order.Len() > c.maxLen
+}
+
+// expirePuts forgets the keys that were last put at least [c.ttl] ago.
+// Assumes [c.lock] is held.
+func (c *recentCache) expirePuts() {
+	now := c.clock.Time()
+	for elem := c.putTimes.Front(); elem != nil; elem = c.putTimes.Front() {
+		put := elem.Value.(*recentPut)
+		if now.Sub(put.time) < c.ttl {
+			return
+		}
+		c.putTimes.Remove(elem)
+		// The key may have been put again since [put].
+		if lastPut, ok := c.lastPut[put.key]; ok && !lastPut.After(put.time) {
+			delete(c.lastPut, put.key)
+		}
+	}
+}
+
+// removeElement removes [elem] from the cache. Assumes [c.lock] is held.
+func (c *recentCache) removeElement(elem *list.Element) {
+	entry := c.order.Remove(elem).(*recentEntry)
+	delete(c.entries, entry.key)
+	c.bytes -= entry.size
+}
+
+// gossipActivationJitter returns the delay in [0, window) after the gossip
+// activation time at which [nodeID] starts gossiping. The delay is derived from
+// [nodeID] so that it is stable across restarts.
+func gossipActivationJitter(nodeID ids.ShortID, window time.Duration) time.Duration {
+	if window <= 0 {
+		return 0
+	}
+	return time.Duration(binary.BigEndian.Uint64(nodeID[:8]) % uint64(window))
 }
 
 func (vm *VM) newPushNetwork(
@@ -111,30 +630,72 @@ func (vm *VM) newPushNetwork(
 	config Config,
 	appSender commonEng.AppSender,
 	chain *coreth.ETHChain,
-	mempool *Mempool,
-) Network {
+	mempool MempoolIface,
+) *pushNetwork {
 	net := &pushNetwork{
 		ctx:                  vm.ctx,
-		gossipActivationTime: activationTime,
+		gossipActivationTime: activationTime.Add(gossipActivationJitter(vm.ctx.NodeID, config.GossipActivationJitter.Duration)),
 		config:               config,
 		appSender:            appSender,
 		chain:                chain,
 		mempool:              mempool,
 		ethTxsToGossipChan:   make(chan []*types.Transaction),
+		ethTxsToRegossipChan: make(chan []*types.Transaction),
+		flushGossipChan:      make(chan chan struct{}),
 		ethTxsToGossip:       make(map[common.Hash]*types.Transaction),
 		shutdownChan:         vm.shutdownChan,
 		shutdownWg:           &vm.shutdownWg,
-		recentAtomicTxs:      &cache.LRU{Size: recentCacheSize},
-		recentEthTxs:         &cache.LRU{Size: recentCacheSize},
+		recentAtomicTxs:      newRecentCacheWithTTL(recentCacheSize, config.GossipRecentCacheBytes, config.GossipTxTTL.Duration),
+		recentEthTxs:         newRecentCacheWithTTL(recentCacheSize, config.GossipRecentCacheBytes, config.GossipTxTTL.Duration),
+		messagesHandled:      make(map[string]uint64),
+		messagesLastHandled:  make(map[string]time.Time),
+		peerVersions:         make(map[ids.ShortID]message.Version),
+		peerBlooms:           make(map[ids.ShortID]peerBloom),
+		peerKnownTxs:         make(map[ids.ShortID]*recentCache),
+		outstandingRequests:  make(map[uint32]ids.ShortID),
+		onRequestFailed:      make(map[uint32]func()),
+		onRequestResponse:    make(map[uint32]func(response []byte)),
+		requestTimers:        make(map[uint32]*time.Timer),
+		pendingAtomicTxs:     make(map[ids.ID]*Tx),
+		pendingEthTxs:        make(map[common.Hash]*types.Transaction),
+		atomicTxSources:      newRecentCache(recentCacheSize, 0),
+		peerAllowlist:        vm.gossipPeerAllowlist,
+		peerDenylist:         vm.gossipPeerDenylist,
+		resyncHint:           vm.resyncHint,
+		unknownVersionMsgs:   metrics.GetOrRegisterCounter("gossip/msgs/unknown_version", nil),
+		notAllowedMsgs:       metrics.GetOrRegisterCounter("gossip/msgs/peer_not_allowed", nil),
+		tooManyTxsMsgs:       metrics.GetOrRegisterCounter("gossip/msgs/too_many_txs", nil),
+		nonValidatorMsgs:     metrics.GetOrRegisterCounter("gossip/msgs/not_validator", nil),
+		oversizedMsgs:        metrics.GetOrRegisterCounter("gossip/msgs/oversized", nil),
+		inboundQueueFullMsgs: metrics.GetOrRegisterCounter("gossip/msgs/inbound_queue_full", nil),
+		pendingGossipTxs:     metrics.GetOrRegisterGauge("gossip/txs/pending", nil),
+		pendingGossipBytes:   metrics.GetOrRegisterGauge("gossip/txs/pending_bytes", nil),
+		pendingQueueFullTxs:  metrics.GetOrRegisterCounter("gossip/txs/pending_queue_full", nil),
+		oversizedTxs:         metrics.GetOrRegisterCounter("gossip/txs/oversized", nil),
+		lowGasPriceTxs:       metrics.GetOrRegisterCounter("gossip/txs/low_gas_price", nil),
+		notActivatedTxs:      metrics.GetOrRegisterCounter("gossip/txs/tx_type_not_activated", nil),
+		senderLimitedTxs:     metrics.GetOrRegisterCounter("gossip/txs/sender_limited", nil),
+		mempoolFullTxs:       metrics.GetOrRegisterCounter("gossip/txs/mempool_full", nil),
+	}
+	if config.DevModeGossipAlwaysActive {
+		net.gossipActivationTime = time.Time{}
+	}
+	if config.GossipFromValidatorsOnly {
+		net.validators = newValidatorSet(vm.ctx.ValidatorState, vm.ctx.SubnetID)
 	}
 	net.gossipHandler = &GossipHandler{
-		vm:  vm,
 		net: net,
 	}
-	net.awaitEthTxGossip()
 	return net
 }
 
+// start starts the goroutines that gossip the eth txs queued to [n] and that
+// handle the gossip received from peers until the VM is shut down.
+func (n *pushNetwork) start() {
+	n.awaitEthTxGossip()
+	n.startGossipWorkers(n.config.InboundGossipWorkers)
+}
+
 // queueExecutableTxs attempts to select up to [maxTxs] from the tx pool for
 // regossiping.
 //
@@ -250,13 +811,58 @@ func (n *pushNetwork) awaitEthTxGossip() {
 		defer n.shutdownWg.Done()
 
 		var (
-			gossipTicker   = time.NewTicker(ethTxsGossipInterval)
-			regossipTicker = time.NewTicker(n.config.TxRegossipFrequency.Duration)
+			gossipTicker    = time.NewTicker(ethTxsGossipInterval)
+			regossipTicker  = time.NewTicker(n.config.TxRegossipFrequency.Duration)
+			bloomTickerC    <-chan time.Time
+			droppedTxsChan  = make(chan core.DroppedTxsEvent, droppedTxsChanSize)
+			droppedTxsSub   = n.chain.GetTxPool().SubscribeDroppedTxsEvent(droppedTxsChan)
+			replacedTxsChan = make(chan core.ReplacedTxsEvent, droppedTxsChanSize)
+			replacedTxsSub  = n.chain.GetTxPool().SubscribeReplacedTxsEvent(replacedTxsChan)
 		)
+		defer droppedTxsSub.Unsubscribe()
+		defer replacedTxsSub.Unsubscribe()
+		if n.config.MempoolBloomEnabled {
+			bloomTicker := time.NewTicker(mempoolBloomGossipInterval)
+			defer bloomTicker.Stop()
+			bloomTickerC = bloomTicker.C
+		}
 
 		for {
 			select {
+			case dropped := <-droppedTxsChan:
+				// Forget dropped txs so that they are gossiped again if they
+				// re-enter the mempool.
+				for _, hash := range dropped.Hashes {
+					n.recentEthTxs.Evict(hash)
+				}
+				n.removePendingEthTxs(dropped.Hashes)
+			case replaced := <-replacedTxsChan:
+				// Replaced txs will never be gossiped again, so they only take
+				// up space in [recentEthTxs].
+				for _, hash := range replaced.Hashes {
+					n.recentEthTxs.Evict(hash)
+				}
+				n.removePendingEthTxs(replaced.Hashes)
+				if !n.config.TxReplacedGossipEnabled {
+					continue
+				}
+				if err := n.gossipTxReplaced(replaced.Hashes); err != nil {
+					log.Warn(
+						"failed to send replaced eth tx hashes",
+						"len(hashes)", len(replaced.Hashes),
+						"err", err,
+					)
+				}
+			case <-bloomTickerC:
+				if err := n.gossipMempoolBloom(); err != nil {
+					log.Warn(
+						"failed to send mempool bloom",
+						"err", err,
+					)
+				}
 			case <-gossipTicker.C:
+				// Txs that could not be sent are retried with the next txs
+				n.bufferPendingTxs()
 				if attempted, err := n.gossipEthTxs(false); err != nil {
 					log.Warn(
 						"failed to send eth transactions",
@@ -265,8 +871,20 @@ func (n *pushNetwork) awaitEthTxGossip() {
 					)
 				}
 			case <-regossipTicker.C:
+				n.bufferPendingTxs()
 				for _, tx := range n.queueRegossipTxs() {
-					n.ethTxsToGossip[tx.Hash()] = tx
+					n.bufferEthTx(tx)
+				}
+				if attempted, err := n.gossipEthTxs(true); err != nil {
+					log.Warn(
+						"failed to send eth transactions",
+						"len(txs)", attempted,
+						"err", err,
+					)
+				}
+			case txs := <-n.ethTxsToRegossipChan:
+				for _, tx := range txs {
+					n.bufferEthTx(tx)
 				}
 				if attempted, err := n.gossipEthTxs(true); err != nil {
 					log.Warn(
@@ -277,7 +895,7 @@ func (n *pushNetwork) awaitEthTxGossip() {
 				}
 			case txs := <-n.ethTxsToGossipChan:
 				for _, tx := range txs {
-					n.ethTxsToGossip[tx.Hash()] = tx
+					n.bufferEthTx(tx)
 				}
 				if attempted, err := n.gossipEthTxs(false); err != nil {
 					log.Warn(
@@ -286,6 +904,15 @@ func (n *pushNetwork) awaitEthTxGossip() {
 						"err", err,
 					)
 				}
+			case flushed := <-n.flushGossipChan:
+				if attempted, err := n.gossipEthTxs(true); err != nil {
+					log.Warn(
+						"failed to flush eth transactions",
+						"len(txs)", attempted,
+						"err", err,
+					)
+				}
+				close(flushed)
 			case <-n.shutdownChan:
 				return
 			}
@@ -294,18 +921,151 @@ func (n *pushNetwork) awaitEthTxGossip() {
 }
 
 func (n *pushNetwork) AppRequestFailed(nodeID ids.ShortID, requestID uint32) error {
+	if onFailed, _ := n.releaseAppRequest(nodeID, requestID); onFailed != nil {
+		onFailed()
+	}
 	return nil
 }
 
+// AppRequest handles the gossip message [msgBytes] sent as a request, and
+// responds to it so that the peer knows that the message was delivered.
 func (n *pushNetwork) AppRequest(nodeID ids.ShortID, requestID uint32, deadline time.Time, msgBytes []byte) error {
+	handler := &appRequestHandler{
+		Handler: n.gossipHandler,
+		net:     n,
+	}
+	if err := n.handle(
+		handler,
+		"Request",
+		nodeID,
+		requestID,
+		msgBytes,
+	); err != nil {
+		return err
+	}
+
+	if err := n.appSender.SendAppResponse(nodeID, requestID, handler.response); err != nil {
+		log.Debug(
+			"failed to respond to AppRequest",
+			"peerID", nodeID,
+			"requestID", requestID,
+			"err", err,
+		)
+	}
 	return nil
 }
 
 func (n *pushNetwork) AppResponse(nodeID ids.ShortID, requestID uint32, msgBytes []byte) error {
+	if _, onResponse := n.releaseAppRequest(nodeID, requestID); onResponse != nil {
+		onResponse(msgBytes)
+	}
+	return nil
+}
+
+// appRequestHandler handles a single AppRequest with [Handler], except for
+// the requests that are responded to with something other than an empty
+// response, which it handles itself.
+type appRequestHandler struct {
+	message.Handler
+
+	net *pushNetwork
+	// [response] is sent in response to the request once it is handled
+	response []byte
+}
+
+func (h *appRequestHandler) HandleEthTxsRequest(nodeID ids.ShortID, requestID uint32, msg *message.EthTxsRequest) error {
+	response, err := h.net.buildEthTxsResponse(nodeID, msg.Hashes)
+	if err != nil {
+		return err
+	}
+	h.response = response
 	return nil
 }
 
+// sendAppRequest sends the request [msgBytes] to [nodeID] and returns the ID
+// of the request. If [onFailed] is non-nil, it is called if the request
+// fails. If [MaxOutstandingAppRequests] requests are already awaiting a
+// response, the request is not sent and errTooManyOutstandingAppRequests is
+// returned.
+func (n *pushNetwork) sendAppRequest(nodeID ids.ShortID, msgBytes []byte, onFailed func()) (uint32, error) {
+	return n.sendAppRequestWithResponse(nodeID, msgBytes, nil, onFailed)
+}
+
+// sendAppRequestWithResponse sends the request [msgBytes] to [nodeID] as
+// sendAppRequest does, and calls [onResponse], if it is non-nil, with the
+// response to the request.
+func (n *pushNetwork) sendAppRequestWithResponse(nodeID ids.ShortID, msgBytes []byte, onResponse func(response []byte), onFailed func()) (uint32, error) {
+	n.requestsLock.Lock()
+	defer n.requestsLock.Unlock()
+
+	if n.appRequestsFull() {
+		return 0, errTooManyOutstandingAppRequests
+	}
+
+	requestID := n.nextRequestID
+	n.nextRequestID++
+	nodeIDs := ids.NewShortSet(1)
+	nodeIDs.Add(nodeID)
+	if err := n.appSender.SendAppRequest(nodeIDs, requestID, msgBytes); err != nil {
+		return 0, err
+	}
+	n.outstandingRequests[requestID] = nodeID
+	if onFailed != nil {
+		n.onRequestFailed[requestID] = onFailed
+	}
+	if onResponse != nil {
+		n.onRequestResponse[requestID] = onResponse
+	}
+	if timeout := n.config.AppRequestTimeout.Duration; timeout > 0 {
+		n.requestTimers[requestID] = time.AfterFunc(timeout, func() {
+			log.Debug(
+				"AppRequest timed out",
+				"peerID", nodeID,
+				"requestID", requestID,
+				"timeout", timeout,
+			)
+			_ = n.AppRequestFailed(nodeID, requestID)
+		})
+	}
+	return requestID, nil
+}
+
+// appRequestsFull returns true if [MaxOutstandingAppRequests] requests are
+// awaiting a response.
+// Assumes [requestsLock] is held.
+func (n *pushNetwork) appRequestsFull() bool {
+	maxRequests := n.config.MaxOutstandingAppRequests
+	return maxRequests > 0 && len(n.outstandingRequests) >= maxRequests
+}
+
+// releaseAppRequest marks the request [requestID] sent to [nodeID] as no
+// longer outstanding, and returns the functions to call if it failed or was
+// responded to.
+func (n *pushNetwork) releaseAppRequest(nodeID ids.ShortID, requestID uint32) (func(), func(response []byte)) {
+	n.requestsLock.Lock()
+	defer n.requestsLock.Unlock()
+
+	requestNodeID, ok := n.outstandingRequests[requestID]
+	if !ok || requestNodeID != nodeID {
+		return nil, nil
+	}
+	onFailed := n.onRequestFailed[requestID]
+	onResponse := n.onRequestResponse[requestID]
+	delete(n.outstandingRequests, requestID)
+	delete(n.onRequestFailed, requestID)
+	delete(n.onRequestResponse, requestID)
+	if timer, ok := n.requestTimers[requestID]; ok {
+		timer.Stop()
+		delete(n.requestTimers, requestID)
+	}
+	return onFailed, onResponse
+}
+
 func (n *pushNetwork) AppGossip(nodeID ids.ShortID, msgBytes []byte) error {
+	if len(n.gossipWorkers) > 0 {
+		n.queueInboundGossip(nodeID, msgBytes)
+		return nil
+	}
 	return n.handle(
 		n.gossipHandler,
 		"Gossip",
@@ -315,107 +1075,1198 @@ func (n *pushNetwork) AppGossip(nodeID ids.ShortID, msgBytes []byte) error {
 	)
 }
 
-func (n *pushNetwork) GossipAtomicTxs(txs []*Tx) error {
-	if time.Now().Before(n.gossipActivationTime) {
+// inboundGossip is a gossip message received from [nodeID] that is queued to
+// be handled.
+type inboundGossip struct {
+	nodeID   ids.ShortID
+	msgBytes []byte
+}
+
+// gossipWorker handles the gossip queued to it in the order that it was
+// queued.
+type gossipWorker struct {
+	lock  sync.Mutex
+	queue []inboundGossip
+	// [ready] holds an item while [queue] may not be empty
+	ready chan struct{}
+}
+
+// startGossipWorkers starts [numWorkers] goroutines that handle the gossip
+// received from peers until the VM is shut down.
+func (n *pushNetwork) startGossipWorkers(numWorkers int) {
+	for i := 0; i < numWorkers; i++ {
+		worker := &gossipWorker{
+			ready: make(chan struct{}, 1),
+		}
+		n.gossipWorkers = append(n.gossipWorkers, worker)
+
+		n.shutdownWg.Add(1)
+		go n.ctx.Log.RecoverAndPanic(func() {
+			defer n.shutdownWg.Done()
+
+			for {
+				select {
+				case <-worker.ready:
+					n.handleQueuedGossip(worker)
+				case <-n.shutdownChan:
+					return
+				}
+			}
+		})
+	}
+}
+
+// queueInboundGossip queues [msgBytes] received from [nodeID] to be handled by
+// the worker of [nodeID]. If the queue of the worker is full, the oldest
+// queued message is dropped.
+func (n *pushNetwork) queueInboundGossip(nodeID ids.ShortID, msgBytes []byte) {
+	worker := n.gossipWorkers[binary.BigEndian.Uint64(nodeID[:8])%uint64(len(n.gossipWorkers))]
+
+	worker.lock.Lock()
+	if len(worker.queue) >= n.config.InboundGossipQueueSize {
+		dropped := worker.queue[0]
+		worker.queue[0] = inboundGossip{}
+		worker.queue = worker.queue[1:]
+		n.inboundQueueFullMsgs.Inc(1)
 		log.Trace(
-			"not gossiping atomic tx before the gossiping activation time",
-			"txs", txs,
+			"dropping queued App message",
+			"reason", dropReasonInboundQueueFull,
+			"peerID", dropped.nodeID,
 		)
-		return nil
 	}
+	worker.queue = append(worker.queue, inboundGossip{
+		nodeID:   nodeID,
+		msgBytes: msgBytes,
+	})
+	worker.lock.Unlock()
 
-	errs := wrappers.Errs{}
-	for _, tx := range txs {
-		errs.Add(n.gossipAtomicTx(tx))
+	select {
+	case worker.ready <- struct{}{}:
+	default:
 	}
-	return errs.Err
 }
 
-func (n *pushNetwork) gossipAtomicTx(tx *Tx) error {
-	txID := tx.ID()
-	// Don't gossip transaction if it has been recently gossiped.
-	if _, has := n.recentAtomicTxs.Get(txID); has {
-		return nil
-	}
-	// If the transaction is not pending according to the mempool
-	// then there is no need to gossip it further.
-	if _, pending := n.mempool.GetPendingTx(txID); !pending {
-		return nil
-	}
-	n.recentAtomicTxs.Put(txID, nil)
+// handleQueuedGossip handles the gossip queued to [worker] until its queue is
+// empty.
+func (n *pushNetwork) handleQueuedGossip(worker *gossipWorker) {
+	for {
+		worker.lock.Lock()
+		if len(worker.queue) == 0 {
+			worker.lock.Unlock()
+			return
+		}
+		msg := worker.queue[0]
+		worker.queue[0] = inboundGossip{}
+		worker.queue = worker.queue[1:]
+		worker.lock.Unlock()
 
-	msg := message.AtomicTx{
-		Tx: tx.Bytes(),
-	}
-	msgBytes, err := message.Build(&msg)
-	if err != nil {
-		return err
+		if err := n.handle(n.gossipHandler, "Gossip", msg.nodeID, 0, msg.msgBytes); err != nil {
+			log.Debug(
+				"failed to handle queued App message",
+				"peerID", msg.nodeID,
+				"err", err,
+			)
+		}
 	}
+}
 
-	log.Trace(
-		"gossiping atomic tx",
-		"txID", txID,
-	)
-	return n.appSender.SendAppGossip(msgBytes)
+func (n *pushNetwork) Connected(nodeID ids.ShortID, nodeVersion version.Application) error {
+	n.peersLock.Lock()
+	n.peerVersions[nodeID] = peerMessageVersion(nodeVersion)
+	n.peersLock.Unlock()
+
+	return n.gossipPendingTxs()
 }
 
-func (n *pushNetwork) sendEthTxs(txs []*types.Transaction) error {
-	if len(txs) == 0 {
-		return nil
+func (n *pushNetwork) Disconnected(nodeID ids.ShortID) error {
+	n.peersLock.Lock()
+	defer n.peersLock.Unlock()
+
+	delete(n.peerVersions, nodeID)
+	delete(n.peerBlooms, nodeID)
+	delete(n.peerKnownTxs, nodeID)
+	return nil
+}
+
+// peerMessageVersion returns the latest message version supported by a peer
+// running [nodeVersion].
+func peerMessageVersion(nodeVersion version.Application) message.Version {
+	switch {
+	case nodeVersion == nil:
+		return message.Version0
+	case nodeVersion.Major() != version1MinNodeVersion.Major():
+		if nodeVersion.Major() > version1MinNodeVersion.Major() {
+			return message.Version1
+		}
+	case nodeVersion.Minor() != version1MinNodeVersion.Minor():
+		if nodeVersion.Minor() > version1MinNodeVersion.Minor() {
+			return message.Version1
+		}
+	case nodeVersion.Patch() >= version1MinNodeVersion.Patch():
+		return message.Version1
 	}
+	return message.Version0
+}
 
-	txBytes, err := rlp.EncodeToBytes(txs)
-	if err != nil {
-		return err
+// numPeers returns the number of connected peers.
+func (n *pushNetwork) numPeers() int {
+	n.peersLock.RLock()
+	defer n.peersLock.RUnlock()
+
+	return len(n.peerVersions)
+}
+
+// hasGossipPeers returns true if enough peers are connected for txs to be
+// gossiped, as configured by [MinGossipPeers]. Once txs were queued for
+// [MinGossipPeersTimeout], any connected peer is enough.
+func (n *pushNetwork) hasGossipPeers() bool {
+	numPeers := n.numPeers()
+	if numPeers == 0 {
+		return false
 	}
-	msg := message.EthTxs{
-		Txs: txBytes,
+	if numPeers >= n.config.MinGossipPeers {
+		return true
 	}
-	msgBytes, err := message.Build(&msg)
-	if err != nil {
-		return err
+
+	timeout := n.config.MinGossipPeersTimeout.Duration
+	if timeout <= 0 {
+		return false
 	}
+	n.pendingLock.Lock()
+	defer n.pendingLock.Unlock()
 
-	log.Trace(
-		"gossiping eth txs",
-		"len(txs)", len(txs),
-		"size(txs)", len(msg.Txs),
-	)
-	return n.appSender.SendAppGossip(msgBytes)
+	return !n.pendingSince.IsZero() && time.Since(n.pendingSince) >= timeout
 }
 
-func (n *pushNetwork) gossipEthTxs(force bool) (int, error) {
-	if (!force && time.Since(n.lastGossiped) < ethTxsGossipInterval) || len(n.ethTxsToGossip) == 0 {
-		return 0, nil
+// updatePendingSince records when the oldest queued tx was queued.
+// Assumes [pendingLock] is held.
+func (n *pushNetwork) updatePendingSince() {
+	switch {
+	case len(n.pendingAtomicTxs) == 0 && len(n.pendingEthTxs) == 0:
+		n.pendingSince = time.Time{}
+	case n.pendingSince.IsZero():
+		n.pendingSince = time.Now()
 	}
-	n.lastGossiped = time.Now()
-	txs := make([]*types.Transaction, 0, len(n.ethTxsToGossip))
-	for _, tx := range n.ethTxsToGossip {
-		txs = append(txs, tx)
-		delete(n.ethTxsToGossip, tx.Hash())
+}
+
+// queuePendingAtomicTx queues [tx] to be gossiped when enough peers are
+// connected, unless [maxPendingGossipTxs] atomic txs are already queued.
+func (n *pushNetwork) queuePendingAtomicTx(tx *Tx) {
+	n.pendingLock.Lock()
+	defer n.pendingLock.Unlock()
+
+	txID := tx.ID()
+	if _, queued := n.pendingAtomicTxs[txID]; !queued && len(n.pendingAtomicTxs) >= maxPendingGossipTxs {
+		n.pendingQueueFullTxs.Inc(1)
+		log.Trace(
+			"dropping atomic tx queued to be gossiped",
+			"reason", dropReasonPendingQueueFull,
+			"txID", txID,
+		)
+		return
 	}
+	n.pendingAtomicTxs[txID] = tx
+	n.updatePendingSince()
+	n.pendingGossipTxs.Update(int64(len(n.pendingAtomicTxs) + len(n.pendingEthTxs)))
+}
+
+// queuePendingEthTxs queues [txs] to be gossiped when enough peers are
+// connected, unless [maxPendingGossipTxs] eth txs are already queued.
+func (n *pushNetwork) queuePendingEthTxs(txs []*types.Transaction) {
+	n.pendingLock.Lock()
+	defer n.pendingLock.Unlock()
 
-	pool := n.chain.GetTxPool()
-	selectedTxs := make([]*types.Transaction, 0)
 	for _, tx := range txs {
 		txHash := tx.Hash()
-		txStatus := pool.Status([]common.Hash{txHash})[0]
-		if txStatus != core.TxStatusPending {
+		if _, queued := n.pendingEthTxs[txHash]; !queued && len(n.pendingEthTxs) >= maxPendingGossipTxs {
+			n.pendingQueueFullTxs.Inc(1)
+			log.Trace(
+				"dropping eth tx queued to be gossiped",
+				"reason", dropReasonPendingQueueFull,
+				"hash", txHash,
+			)
 			continue
 		}
+		n.pendingEthTxs[txHash] = tx
+	}
+	n.updatePendingSince()
+	n.pendingGossipTxs.Update(int64(len(n.pendingAtomicTxs) + len(n.pendingEthTxs)))
+}
 
-		if n.config.RemoteTxGossipOnlyEnabled && pool.HasLocal(txHash) {
-			continue
-		}
+// removePendingEthTxs removes the eth txs with [hashes], which are no longer
+// in the tx pool, from the txs queued to be gossiped.
+func (n *pushNetwork) removePendingEthTxs(hashes []common.Hash) {
+	n.pendingLock.Lock()
+	defer n.pendingLock.Unlock()
 
-		// We check [force] outside of the if statement to avoid an unnecessary
-		// cache lookup.
-		if !force {
-			if _, has := n.recentEthTxs.Get(txHash); has {
-				continue
+	if len(n.pendingEthTxs) == 0 {
+		return
+	}
+	for _, hash := range hashes {
+		delete(n.pendingEthTxs, hash)
+	}
+	n.updatePendingSince()
+	n.pendingGossipTxs.Update(int64(len(n.pendingAtomicTxs) + len(n.pendingEthTxs)))
+}
+
+// takePendingTxs removes and returns the queued txs if enough peers are
+// connected for them to be gossiped.
+func (n *pushNetwork) takePendingTxs() ([]*Tx, []*types.Transaction) {
+	if !n.hasGossipPeers() {
+		return nil, nil
+	}
+
+	n.pendingLock.Lock()
+	defer n.pendingLock.Unlock()
+
+	if len(n.pendingAtomicTxs) == 0 && len(n.pendingEthTxs) == 0 {
+		return nil, nil
+	}
+	atomicTxs := make([]*Tx, 0, len(n.pendingAtomicTxs))
+	for _, tx := range n.pendingAtomicTxs {
+		atomicTxs = append(atomicTxs, tx)
+	}
+	ethTxs := make([]*types.Transaction, 0, len(n.pendingEthTxs))
+	for _, tx := range n.pendingEthTxs {
+		ethTxs = append(ethTxs, tx)
+	}
+	n.pendingAtomicTxs = make(map[ids.ID]*Tx)
+	n.pendingEthTxs = make(map[common.Hash]*types.Transaction)
+	n.pendingSince = time.Time{}
+	n.pendingGossipTxs.Update(0)
+
+	log.Debug(
+		"gossiping txs queued while they could not be gossiped",
+		"len(atomicTxs)", len(atomicTxs),
+		"len(ethTxs)", len(ethTxs),
+	)
+	return atomicTxs, ethTxs
+}
+
+// bufferPendingTxs gossips the queued atomic txs and buffers the queued eth
+// txs to be gossiped, if enough peers are connected. It must only be called
+// by the eth tx gossip goroutine.
+func (n *pushNetwork) bufferPendingTxs() {
+	atomicTxs, ethTxs := n.takePendingTxs()
+	if err := n.GossipAtomicTxs(atomicTxs); err != nil {
+		log.Warn(
+			"failed to send queued atomic transactions",
+			"len(txs)", len(atomicTxs),
+			"err", err,
+		)
+	}
+	for _, tx := range ethTxs {
+		n.bufferEthTx(tx)
+	}
+}
+
+// gossipPendingTxs gossips all txs that were queued while not enough peers
+// were connected.
+func (n *pushNetwork) gossipPendingTxs() error {
+	atomicTxs, ethTxs := n.takePendingTxs()
+	// Atomic txs are cross-chain and more latency sensitive than eth txs, so
+	// they are gossiped before the eth txs are handed to the gossip goroutine.
+	err := n.GossipAtomicTxs(atomicTxs)
+	if len(ethTxs) > 0 {
+		// The eth txs are gossiped even if they were recently gossiped, as
+		// they were marked as gossiped when they were queued.
+		select {
+		case n.ethTxsToRegossipChan <- ethTxs:
+		case <-n.shutdownChan:
+		}
+	}
+	return err
+}
+
+// gossipVersion returns the latest message version supported by every
+// connected peer.
+func (n *pushNetwork) gossipVersion() message.Version {
+	n.peersLock.RLock()
+	defer n.peersLock.RUnlock()
+
+	gossipVersion := message.CurrentVersion
+	for _, peerVersion := range n.peerVersions {
+		if peerVersion < gossipVersion {
+			gossipVersion = peerVersion
+		}
+	}
+	return gossipVersion
+}
+
+// updatePeerVersion records that [nodeID] supports [msgVersion] if it is later
+// than the version previously recorded for the peer.
+func (n *pushNetwork) updatePeerVersion(nodeID ids.ShortID, msgVersion message.Version) {
+	n.peersLock.Lock()
+	defer n.peersLock.Unlock()
+
+	if peerVersion, ok := n.peerVersions[nodeID]; ok && peerVersion < msgVersion {
+		n.peerVersions[nodeID] = msgVersion
+	}
+}
+
+func (n *pushNetwork) GossipAtomicTxs(txs []*Tx) error {
+	if time.Now().Before(n.gossipActivationTime) {
+		log.Trace(
+			"not gossiping atomic tx before the gossiping activation time",
+			"reason", dropReasonBeforeActivation,
+			"txs", txs,
+		)
+		return nil
+	}
+
+	errs := wrappers.Errs{}
+	for _, tx := range txs {
+		errs.Add(n.gossipAtomicTx(tx))
+	}
+	return errs.Err
+}
+
+func (n *pushNetwork) gossipAtomicTx(tx *Tx) error {
+	txID := tx.ID()
+	// Don't gossip transaction if it has been recently gossiped.
+	if _, has := n.recentAtomicTxs.Get(txID); has {
+		log.Trace(
+			"not gossiping recently gossiped atomic tx",
+			"reason", dropReasonRecentDupe,
+			"txID", txID,
+		)
+		return nil
+	}
+	// If the transaction is not pending according to the mempool
+	// then there is no need to gossip it further.
+	if _, pending := n.mempool.GetPendingTx(txID); !pending {
+		log.Trace(
+			"not gossiping atomic tx that is not pending",
+			"reason", dropReasonNotPending,
+			"txID", txID,
+		)
+		return nil
+	}
+	if !n.hasGossipPeers() {
+		log.Trace(
+			"queueing atomic tx until enough peers connect",
+			"txID", txID,
+		)
+		n.queuePendingAtomicTx(tx)
+		return nil
+	}
+
+	msg := message.AtomicTx{
+		Tx: tx.Bytes(),
+	}
+	msgBytes, err := message.BuildWithVersion(&msg, n.gossipVersion())
+	if err != nil {
+		return err
+	}
+
+	log.Trace(
+		"gossiping atomic tx",
+		"txID", txID,
+	)
+	if n.config.ReliableAtomicGossip {
+		err = n.sendAtomicTxRequests(tx, msgBytes)
+	} else {
+		err = n.sendTxsGossip(n.appSender, msgBytes)
+	}
+	if err != nil {
+		n.queuePendingAtomicTx(tx)
+		return err
+	}
+	n.recentAtomicTxs.Put(txID, nil)
+	n.statsLock.Lock()
+	n.lastAtomicGossiped = time.Now()
+	n.statsLock.Unlock()
+	return nil
+}
+
+// sendAtomicTxRequests sends the atomic tx message [msgBytes] holding [tx] as
+// an AppRequest to a random subset of the connected peers. An error is only
+// returned if the message could not be sent to any peer.
+func (n *pushNetwork) sendAtomicTxRequests(tx *Tx, msgBytes []byte) error {
+	var firstErr error
+	sent := false
+	for nodeID := range n.samplePeers() {
+		if err := n.sendAtomicTxRequest(nodeID, tx, msgBytes, 0); err != nil {
+			if firstErr == nil {
+				firstErr = err
 			}
+			continue
+		}
+		sent = true
+	}
+	if sent {
+		return nil
+	}
+	return firstErr
+}
+
+// sendAtomicTxRequest sends the atomic tx message [msgBytes] holding [tx] as
+// an AppRequest to [nodeID]. If the request fails, it is resent to another
+// peer until it has been retried [atomicTxRequestRetries] times or [tx] is no
+// longer pending.
+func (n *pushNetwork) sendAtomicTxRequest(nodeID ids.ShortID, tx *Tx, msgBytes []byte, retries int) error {
+	onFailed := func() {
+		txID := tx.ID()
+		if retries >= atomicTxRequestRetries {
+			log.Debug(
+				"not retrying atomic tx request after reaching the max retries",
+				"txID", txID,
+			)
+			return
+		}
+		if _, pending := n.mempool.GetPendingTx(txID); !pending {
+			return
+		}
+		retryNodeID, ok := n.randomPeer(nodeID)
+		if !ok {
+			n.queuePendingAtomicTx(tx)
+			return
+		}
+		if err := n.sendAtomicTxRequest(retryNodeID, tx, msgBytes, retries+1); err != nil {
+			log.Debug(
+				"failed to retry atomic tx request",
+				"txID", txID,
+				"peerID", retryNodeID,
+				"err", err,
+			)
+			n.queuePendingAtomicTx(tx)
+		}
+	}
+	_, err := n.sendAppRequest(nodeID, msgBytes, onFailed)
+	return err
+}
+
+// randomPeer returns a random connected peer other than [exclude], if there is
+// one.
+func (n *pushNetwork) randomPeer(exclude ids.ShortID) (ids.ShortID, bool) {
+	n.peersLock.RLock()
+	defer n.peersLock.RUnlock()
+
+	peers := make([]ids.ShortID, 0, len(n.peerVersions))
+	for nodeID := range n.peerVersions {
+		if nodeID != exclude && n.peerAllowed(nodeID) {
+			peers = append(peers, nodeID)
+		}
+	}
+	if len(peers) == 0 {
+		return ids.ShortID{}, false
+	}
+	return peers[rand.Intn(len(peers))], true
+}
+
+func (n *pushNetwork) buildEthTxsMsg(txs []*types.Transaction) ([]byte, error) {
+	txBytes, err := rlp.EncodeToBytes(txs)
+	if err != nil {
+		return nil, err
+	}
+	msg := message.EthTxs{
+		Txs: txBytes,
+	}
+	msgBytes, err := message.BuildWithVersion(&msg, n.gossipVersion())
+	if err != nil {
+		return nil, err
+	}
+
+	log.Trace(
+		"gossiping eth txs",
+		"len(txs)", len(txs),
+		"size(txs)", len(msg.Txs),
+	)
+	return msgBytes, nil
+}
+
+// buildEthTxHashesMsg returns a message announcing the hashes of [txs].
+func (n *pushNetwork) buildEthTxHashesMsg(txs []*types.Transaction) ([]byte, error) {
+	hashes := make([]common.Hash, len(txs))
+	for i, tx := range txs {
+		hashes[i] = tx.Hash()
+	}
+	log.Trace(
+		"announcing eth txs",
+		"len(txs)", len(txs),
+	)
+	return message.BuildWithVersion(&message.EthTxHashes{Hashes: hashes}, message.Version1)
+}
+
+// buildEthTxsResponse returns an EthTxs message holding the pending txs with
+// [hashes] requested by [nodeID]. The txs that are no longer in the tx pool
+// are left out, as are the txs past [message.EthMsgSoftCapSize]. If none of
+// the txs are included, nil is returned.
+func (n *pushNetwork) buildEthTxsResponse(nodeID ids.ShortID, hashes []common.Hash) ([]byte, error) {
+	if len(hashes) > maxEthTxsPerMessage {
+		n.tooManyTxsMsgs.Inc(1)
+		log.Trace(
+			"AppRequest requested too many txs",
+			"reason", dropReasonTooManyTxs,
+			"peerID", nodeID,
+			"len(hashes)", len(hashes),
+		)
+		return nil, nil
+	}
+
+	var (
+		pool = n.chain.GetTxPool()
+		txs  = make([]*types.Transaction, 0, len(hashes))
+		size common.StorageSize
+	)
+	for _, hash := range hashes {
+		tx := pool.Get(hash)
+		if tx == nil {
+			continue
+		}
+		if len(txs) > 0 && size+tx.Size() > message.EthMsgSoftCapSize {
+			break
+		}
+		txs = append(txs, tx)
+		size += tx.Size()
+	}
+	if len(txs) == 0 {
+		return nil, nil
+	}
+	txBytes, err := rlp.EncodeToBytes(txs)
+	if err != nil {
+		return nil, err
+	}
+	// The requester supports [message.Version1], as [message.EthTxsRequest]
+	// is only supported as of [message.Version1].
+	return message.BuildWithVersion(&message.EthTxs{Txs: txBytes}, message.Version1)
+}
+
+func (n *pushNetwork) sendEthTxs(txs []*types.Transaction) error {
+	if len(txs) == 0 {
+		return nil
+	}
+	if !n.hasGossipPeers() {
+		log.Trace(
+			"queueing eth txs until enough peers connect",
+			"len(txs)", len(txs),
+		)
+		n.queuePendingEthTxs(txs)
+		return nil
+	}
+
+	// The txs that failed to be sent on their channel are already requeued,
+	// so the txs that are not routed to a channel are gossiped regardless.
+	unrouted, channelErr := n.sendChannelEthTxs(txs)
+	if len(unrouted) == 0 {
+		if channelErr == nil {
+			// Every tx was gossiped on a channel
+			n.statsLock.Lock()
+			n.lastEthGossiped = time.Now()
+			n.statsLock.Unlock()
+		}
+		return channelErr
+	}
+	gossiper := n.ethTxsGossiper
+	if gossiper == nil {
+		gossiper = n
+	}
+	if err := gossiper.sendDefaultEthTxs(unrouted); err != nil {
+		return err
+	}
+	n.statsLock.Lock()
+	n.lastEthGossiped = time.Now()
+	n.statsLock.Unlock()
+	return channelErr
+}
+
+// ethTxsGossiper gossips the eth txs that are not routed to a gossip channel
+// with the VM's sender. It is implemented by each [GossipMode].
+type ethTxsGossiper interface {
+	sendDefaultEthTxs(txs []*types.Transaction) error
+}
+
+// sendDefaultEthTxs pushes [txs] to peers, skipping the peers that are known
+// to have them when possible.
+func (n *pushNetwork) sendDefaultEthTxs(txs []*types.Transaction) error {
+	sources := n.ethTxSources(txs)
+	if blooms := n.peerMempoolBlooms(); len(blooms) > 0 || n.hasPeerKnownTxs() || len(sources) > 0 {
+		if err := n.sendEthTxsToPeers(txs, blooms, sources); err != nil {
+			n.requeueEthTxs(txs)
+			return err
+		}
+	} else {
+		msgBytes, err := n.buildEthTxsMsg(txs)
+		if err != nil {
+			return err
+		}
+		if err := n.sendWithRetries(func() error { return n.sendTxsGossip(n.ethTxsSender(), msgBytes) }); err != nil {
+			n.requeueEthTxs(txs)
+			return err
+		}
+	}
+	return nil
+}
+
+// pullNetwork is a [pushNetwork] that gossips only the hashes of eth txs, and
+// peers request the txs that they don't have.
+type pullNetwork struct {
+	*pushNetwork
+}
+
+// sendDefaultEthTxs gossips the hashes of [txs], unless a peer can't request
+// txs, in which case the txs are pushed.
+func (n *pullNetwork) sendDefaultEthTxs(txs []*types.Transaction) error {
+	// Peers can only request txs as of [message.Version1]
+	if n.gossipVersion() < message.Version1 {
+		return n.pushNetwork.sendDefaultEthTxs(txs)
+	}
+	msgBytes, err := n.buildEthTxHashesMsg(txs)
+	if err != nil {
+		return err
+	}
+	if err := n.sendWithRetries(func() error { return n.sendTxsGossip(n.ethTxsSender(), msgBytes) }); err != nil {
+		n.requeueEthTxs(txs)
+		return err
+	}
+	return nil
+}
+
+// hybridNetwork is a [pushNetwork] that gossips eth txs to a random subset of
+// the peers, and only their hashes to the other peers.
+type hybridNetwork struct {
+	*pushNetwork
+}
+
+// sendDefaultEthTxs gossips [txs] with [sendEthTxsHybrid], unless a peer
+// can't request txs, in which case the txs are pushed.
+func (n *hybridNetwork) sendDefaultEthTxs(txs []*types.Transaction) error {
+	// Peers can only request txs as of [message.Version1]
+	if n.gossipVersion() < message.Version1 {
+		return n.pushNetwork.sendDefaultEthTxs(txs)
+	}
+	if err := n.sendEthTxsHybrid(txs); err != nil {
+		n.requeueEthTxs(txs)
+		return err
+	}
+	return nil
+}
+
+func (n *pushNetwork) SetGossipChannels(senders map[ids.ID]commonEng.AppSender, route func(tx *types.Transaction) ids.ID) {
+	n.channelsLock.Lock()
+	defer n.channelsLock.Unlock()
+
+	n.channelSenders = senders
+	n.ethTxChannel = route
+}
+
+// sendChannelEthTxs gossips each tx in [txs] that is routed to a gossip
+// channel with the sender of that channel, and returns the txs that are not
+// routed to any channel. Txs that could not be sent are queued to be gossiped
+// again.
+func (n *pushNetwork) sendChannelEthTxs(txs []*types.Transaction) ([]*types.Transaction, error) {
+	n.channelsLock.RLock()
+	defer n.channelsLock.RUnlock()
+
+	if len(n.channelSenders) == 0 || n.ethTxChannel == nil {
+		return txs, nil
+	}
+
+	var (
+		unrouted   = make([]*types.Transaction, 0, len(txs))
+		channelIDs []ids.ID
+		channelTxs = make(map[ids.ID][]*types.Transaction)
+	)
+	for _, tx := range txs {
+		channelID := n.ethTxChannel(tx)
+		if _, ok := n.channelSenders[channelID]; !ok {
+			unrouted = append(unrouted, tx)
+			continue
+		}
+		if _, ok := channelTxs[channelID]; !ok {
+			channelIDs = append(channelIDs, channelID)
+		}
+		channelTxs[channelID] = append(channelTxs[channelID], tx)
+	}
+
+	errs := wrappers.Errs{}
+	for _, channelID := range channelIDs {
+		sender := n.channelSenders[channelID]
+		routed := channelTxs[channelID]
+		msgBytes, err := n.buildEthTxsMsg(routed)
+		if err != nil {
+			errs.Add(err)
+			continue
+		}
+		log.Trace(
+			"gossiping eth txs on channel",
+			"channelID", channelID,
+			"len(txs)", len(routed),
+		)
+		if err := n.sendWithRetries(func() error { return sender.SendAppGossip(msgBytes) }); err != nil {
+			n.requeueEthTxs(routed)
+			errs.Add(err)
+		}
+	}
+	return unrouted, errs.Err
+}
+
+// gossipSender sends gossip messages to every peer or to a set of peers.
+// It is implemented by [commonEng.AppSender].
+type gossipSender interface {
+	SendAppGossip(msgBytes []byte) error
+	SendAppGossipSpecific(nodeIDs ids.ShortSet, msgBytes []byte) error
+}
+
+// lowPriorityAppSender is implemented by AppSenders that can send gossip with
+// a lower priority than the gossip sent by SendAppGossip and
+// SendAppGossipSpecific.
+type lowPriorityAppSender interface {
+	SendAppGossipLowPriority(msgBytes []byte) error
+	SendAppGossipSpecificLowPriority(nodeIDs ids.ShortSet, msgBytes []byte) error
+}
+
+// lowPriorityGossipSender sends gossip with the low priority methods of
+// [sender].
+type lowPriorityGossipSender struct {
+	sender lowPriorityAppSender
+}
+
+func (s lowPriorityGossipSender) SendAppGossip(msgBytes []byte) error {
+	return s.sender.SendAppGossipLowPriority(msgBytes)
+}
+
+func (s lowPriorityGossipSender) SendAppGossipSpecific(nodeIDs ids.ShortSet, msgBytes []byte) error {
+	return s.sender.SendAppGossipSpecificLowPriority(nodeIDs, msgBytes)
+}
+
+// ethTxsSender returns the sender that eth txs are gossiped with. If
+// [LowPriorityEthGossip] is set and [appSender] supports it, eth txs are
+// gossiped with a lower priority than atomic txs, so that atomic txs are not
+// delayed behind them.
+func (n *pushNetwork) ethTxsSender() gossipSender {
+	if n.config.LowPriorityEthGossip {
+		if sender, ok := n.appSender.(lowPriorityAppSender); ok {
+			return lowPriorityGossipSender{sender: sender}
+		}
+	}
+	return n.appSender
+}
+
+// sendTxsGossip gossips the txs message [msgBytes] with [sender] to a random
+// subset of the connected peers if [GossipFanout] is set, and to every peer
+// otherwise.
+func (n *pushNetwork) sendTxsGossip(sender gossipSender, msgBytes []byte) error {
+	if n.config.GossipFanout == 0 {
+		return n.sendGossip(sender, msgBytes)
+	}
+	return sender.SendAppGossipSpecific(n.samplePeers(), msgBytes)
+}
+
+// sendGossip gossips [msgBytes] with [sender] to every peer. If gossip is
+// restricted to a subset of the peers, the message is only sent to the
+// connected peers that are allowed.
+func (n *pushNetwork) sendGossip(sender gossipSender, msgBytes []byte) error {
+	if n.peerAllowlist.Len() == 0 && n.peerDenylist.Len() == 0 {
+		return sender.SendAppGossip(msgBytes)
+	}
+	peers := ids.NewShortSet(0)
+	peers.Add(n.allowedPeers()...)
+	return sender.SendAppGossipSpecific(peers, msgBytes)
+}
+
+// peerAllowed returns true if gossip may be exchanged with [nodeID].
+func (n *pushNetwork) peerAllowed(nodeID ids.ShortID) bool {
+	if n.peerDenylist.Contains(nodeID) {
+		return false
+	}
+	return n.peerAllowlist.Len() == 0 || n.peerAllowlist.Contains(nodeID)
+}
+
+// allowedPeers returns the connected peers that gossip may be exchanged with.
+func (n *pushNetwork) allowedPeers() []ids.ShortID {
+	n.peersLock.RLock()
+	defer n.peersLock.RUnlock()
+
+	peers := make([]ids.ShortID, 0, len(n.peerVersions))
+	for nodeID := range n.peerVersions {
+		if n.peerAllowed(nodeID) {
+			peers = append(peers, nodeID)
+		}
+	}
+	return peers
+}
+
+// samplePeers returns a random subset of the allowed connected peers holding
+// [gossipFanoutSize] of them.
+func (n *pushNetwork) samplePeers() ids.ShortSet {
+	peers := n.allowedPeers()
+
+	size := gossipFanoutSize(len(peers), n.config.GossipFanout)
+	rand.Shuffle(len(peers), func(i, j int) {
+		peers[i], peers[j] = peers[j], peers[i]
+	})
+	sampled := ids.NewShortSet(size)
+	sampled.Add(peers[:size]...)
+	return sampled
+}
+
+// gossipFanoutSize returns the number of the [numPeers] connected peers that
+// each batch of txs is gossiped to: the square root of [numPeers], but at
+// least [fanout] and at most [numPeers].
+func gossipFanoutSize(numPeers int, fanout int) int {
+	size := int(math.Ceil(math.Sqrt(float64(numPeers))))
+	if size < fanout {
+		size = fanout
+	}
+	if size > numPeers {
+		size = numPeers
+	}
+	return size
+}
+
+// sendEthTxsHybrid gossips [txs] to a random subset of the allowed connected
+// peers, sized as with [GossipFanout], and only their hashes to the other
+// allowed connected peers.
+func (n *pushNetwork) sendEthTxsHybrid(txs []*types.Transaction) error {
+	pushed := n.samplePeers()
+	announced := ids.NewShortSet(0)
+	for _, nodeID := range n.allowedPeers() {
+		if !pushed.Contains(nodeID) {
+			announced.Add(nodeID)
+		}
+	}
+
+	sender := n.ethTxsSender()
+	txsMsgBytes, err := n.buildEthTxsMsg(txs)
+	if err != nil {
+		return err
+	}
+	if err := n.sendWithRetries(func() error { return sender.SendAppGossipSpecific(pushed, txsMsgBytes) }); err != nil {
+		return err
+	}
+	if announced.Len() == 0 {
+		return nil
+	}
+	hashesMsgBytes, err := n.buildEthTxHashesMsg(txs)
+	if err != nil {
+		return err
+	}
+	return n.sendWithRetries(func() error { return sender.SendAppGossipSpecific(announced, hashesMsgBytes) })
+}
+
+// sendEthTxsToPeers sends each connected peer the txs in [txs] that are not in
+// its mempool according to its bloom filter in [blooms] or the txs it
+// acknowledged in [peerKnownTxs], and that they did not gossip to this node as
+// recorded in [sources]. Peers without any of these are sent all of [txs].
+func (n *pushNetwork) sendEthTxsToPeers(txs []*types.Transaction, blooms map[ids.ShortID]mempoolBloom, sources map[common.Hash]ids.ShortID) error {
+	sourcePeers := ids.NewShortSet(len(sources))
+	for _, nodeID := range sources {
+		sourcePeers.Add(nodeID)
+	}
+
+	n.peersLock.RLock()
+	otherPeers := ids.NewShortSet(len(n.peerVersions))
+	knownTxs := make(map[ids.ShortID]*recentCache, len(n.peerKnownTxs))
+	targetedPeers := make([]ids.ShortID, 0, len(n.peerVersions))
+	for nodeID := range n.peerVersions {
+		if !n.peerAllowed(nodeID) {
+			continue
+		}
+		_, hasBloom := blooms[nodeID]
+		known, hasKnownTxs := n.peerKnownTxs[nodeID]
+		if !hasBloom && !hasKnownTxs && !sourcePeers.Contains(nodeID) {
+			otherPeers.Add(nodeID)
+			continue
+		}
+		if hasKnownTxs {
+			knownTxs[nodeID] = known
+		}
+		targetedPeers = append(targetedPeers, nodeID)
+	}
+	n.peersLock.RUnlock()
+
+	if otherPeers.Len() > 0 {
+		msgBytes, err := n.buildEthTxsMsg(txs)
+		if err != nil {
+			return err
+		}
+		if err := n.sendWithRetries(func() error { return n.ethTxsSender().SendAppGossipSpecific(otherPeers, msgBytes) }); err != nil {
+			return err
+		}
+	}
+
+	for _, nodeID := range targetedPeers {
+		bloom, hasBloom := blooms[nodeID]
+		known := knownTxs[nodeID]
+		peerTxs := make([]*types.Transaction, 0, len(txs))
+		for _, tx := range txs {
+			txHash := tx.Hash()
+			if hasBloom && bloom.contains(txHash) {
+				continue
+			}
+			if source, ok := sources[txHash]; ok && source == nodeID {
+				continue
+			}
+			if known != nil {
+				if _, has := known.Get(txHash); has {
+					continue
+				}
+			}
+			peerTxs = append(peerTxs, tx)
+		}
+		if len(peerTxs) == 0 {
+			continue
+		}
+
+		msgBytes, err := n.buildEthTxsMsg(peerTxs)
+		if err != nil {
+			return err
+		}
+		peer := ids.NewShortSet(1)
+		peer.Add(nodeID)
+		if err := n.sendWithRetries(func() error { return n.ethTxsSender().SendAppGossipSpecific(peer, msgBytes) }); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sendWithRetries calls [send] until it succeeds, retrying up to
+// [ethTxsSendRetries] times with an exponential backoff. The error of the last
+// attempt is returned if every attempt fails.
+func (n *pushNetwork) sendWithRetries(send func() error) error {
+	backoff := ethTxsSendRetryBackoff
+	for retry := 0; ; retry++ {
+		err := send()
+		if err == nil || retry == ethTxsSendRetries {
+			return err
+		}
+		log.Debug(
+			"retrying failed gossip send",
+			"retry", retry+1,
+			"backoff", backoff,
+			"err", err,
+		)
+		select {
+		case <-time.After(backoff):
+		case <-n.shutdownChan:
+			return err
+		}
+		backoff *= 2
+	}
+}
+
+// requeueEthTxs queues [txs], which could not be sent, to be gossiped again
+// on the next gossip tick, and forgets that they were recently gossiped, so
+// that they are not skipped when they are gossiped again.
+func (n *pushNetwork) requeueEthTxs(txs []*types.Transaction) {
+	for _, tx := range txs {
+		n.recentEthTxs.Evict(tx.Hash())
+	}
+	n.queuePendingEthTxs(txs)
+}
+
+// ethTxGossiped returns true if [txHash] was recently gossiped by this node.
+func (n *pushNetwork) ethTxGossiped(txHash common.Hash) bool {
+	value, has := n.recentEthTxs.Get(txHash)
+	if !has {
+		return false
+	}
+	// The value of a tx that is only remembered because of [GossipTxTTL] is
+	// not known, so it is assumed to have been gossiped.
+	tx, ok := value.(*recentEthTx)
+	return !ok || tx.gossiped
+}
+
+// ethTxSource returns the peer that first gossiped [txHash] to this node, or
+// ids.ShortEmpty if it is not known.
+func (n *pushNetwork) ethTxSource(txHash common.Hash) ids.ShortID {
+	value, _ := n.recentEthTxs.Get(txHash)
+	if tx, ok := value.(*recentEthTx); ok {
+		return tx.source
+	}
+	return ids.ShortEmpty
+}
+
+// ethTxSources returns the peer that first gossiped each of [txs] to this
+// node, for the txs whose source is known.
+func (n *pushNetwork) ethTxSources(txs []*types.Transaction) map[common.Hash]ids.ShortID {
+	sources := make(map[common.Hash]ids.ShortID)
+	for _, tx := range txs {
+		if source := n.ethTxSource(tx.Hash()); source != ids.ShortEmpty {
+			sources[tx.Hash()] = source
+		}
+	}
+	return sources
+}
+
+// recordEthTxSources records [nodeID] as the source of the txs of [txs] that
+// are not already in [recentEthTxs], and returns which txs were recorded.
+func (n *pushNetwork) recordEthTxSources(nodeID ids.ShortID, txs []*types.Transaction) []bool {
+	recorded := make([]bool, len(txs))
+	for i, tx := range txs {
+		if _, has := n.recentEthTxs.Get(tx.Hash()); has {
+			continue
+		}
+		n.recentEthTxs.Put(tx.Hash(), &recentEthTx{source: nodeID})
+		recorded[i] = true
+	}
+	return recorded
+}
+
+// hasPeerKnownTxs returns true if any connected peer acknowledged txs.
+func (n *pushNetwork) hasPeerKnownTxs() bool {
+	n.peersLock.RLock()
+	defer n.peersLock.RUnlock()
+
+	return len(n.peerKnownTxs) > 0
+}
+
+// sendTxsAck acknowledges to [nodeID] that the eth txs with [hashes], which it
+// gossiped to us, were added to the mempool.
+func (n *pushNetwork) sendTxsAck(nodeID ids.ShortID, hashes []common.Hash) error {
+	n.peersLock.RLock()
+	version, connected := n.peerVersions[nodeID]
+	n.peersLock.RUnlock()
+
+	// Peers that do not support [message.Version1] can not parse the message
+	if !connected || version < message.Version1 {
+		return nil
+	}
+
+	msgBytes, err := message.BuildWithVersion(&message.TxsAck{Hashes: hashes}, version)
+	if err != nil {
+		return err
+	}
+	peer := ids.NewShortSet(1)
+	peer.Add(nodeID)
+	return n.appSender.SendAppGossipSpecific(peer, msgBytes)
+}
+
+// peerMempoolBlooms returns the unexpired bloom filters received from
+// connected peers.
+func (n *pushNetwork) peerMempoolBlooms() map[ids.ShortID]mempoolBloom {
+	n.peersLock.RLock()
+	defer n.peersLock.RUnlock()
+
+	now := time.Now()
+	blooms := make(map[ids.ShortID]mempoolBloom, len(n.peerBlooms))
+	for nodeID, peerBloom := range n.peerBlooms {
+		if now.Before(peerBloom.expiry) {
+			blooms[nodeID] = peerBloom.bloom
+		}
+	}
+	return blooms
+}
+
+// gossipMempoolBloom gossips a bloom filter of the pending txs in the tx pool
+// so that peers do not send us txs that we already have.
+func (n *pushNetwork) gossipMempoolBloom() error {
+	// Peers that do not support [message.Version1] can not parse the bloom
+	version := n.gossipVersion()
+	if version < message.Version1 {
+		return nil
+	}
+
+	bloom := newMempoolBloom()
+	for _, txs := range n.chain.GetTxPool().Pending(false) {
+		for _, tx := range txs {
+			bloom.add(tx.Hash())
+		}
+	}
+	msgBytes, err := message.BuildWithVersion(&message.MempoolBloom{Bloom: bloom}, version)
+	if err != nil {
+		return err
+	}
+	return n.sendGossip(n.appSender, msgBytes)
+}
+
+// gossipTxReplaced notifies peers that the eth txs with [hashes] were replaced
+// in the mempool, so that they can forget them.
+func (n *pushNetwork) gossipTxReplaced(hashes []common.Hash) error {
+	// Peers that do not support [message.Version1] can not parse the message
+	version := n.gossipVersion()
+	if version < message.Version1 || n.numPeers() == 0 {
+		return nil
+	}
+
+	msgBytes, err := message.BuildWithVersion(&message.TxReplaced{Hashes: hashes}, version)
+	if err != nil {
+		return err
+	}
+
+	log.Trace(
+		"gossiping replaced eth tx hashes",
+		"len(hashes)", len(hashes),
+	)
+	return n.sendGossip(n.appSender, msgBytes)
+}
+
+// GossipBlockAnnouncement notifies peers that the block [hash] was accepted at
+// [height], so that peers that are behind can discover it.
+func (n *pushNetwork) GossipBlockAnnouncement(hash common.Hash, height uint64) error {
+	if !n.config.BlockAnnouncementGossipEnabled || time.Now().Before(n.gossipActivationTime) {
+		return nil
+	}
+	// Peers that do not support [message.Version1] can not parse the message
+	version := n.gossipVersion()
+	if version < message.Version1 || n.numPeers() == 0 {
+		return nil
+	}
+
+	msgBytes, err := message.BuildWithVersion(&message.BlockAnnouncement{Hash: hash, Height: height}, version)
+	if err != nil {
+		return err
+	}
+
+	log.Trace(
+		"gossiping block announcement",
+		"hash", hash,
+		"height", height,
+	)
+	return n.sendGossip(n.appSender, msgBytes)
+}
+
+func (n *pushNetwork) gossipEthTxs(force bool) (int, error) {
+	if len(n.ethTxsToGossip) == 0 {
+		return 0, nil
+	}
+	// The buffered txs are gossiped before [ethTxsGossipInterval] has passed
+	// if they exceed [MaxPendingGossipBytes], so that the buffer stays bounded.
+	if !force && time.Since(n.lastGossiped) < ethTxsGossipInterval {
+		if maxBytes := n.config.MaxPendingGossipBytes; maxBytes == 0 || n.ethTxsToGossipSize <= common.StorageSize(maxBytes) {
+			return 0, nil
+		}
+		log.Trace(
+			"gossiping eth txs early after exceeding the max pending gossip bytes",
+			"len(txs)", len(n.ethTxsToGossip),
+			"size", n.ethTxsToGossipSize,
+		)
+	}
+	n.lastGossiped = time.Now()
+	txs := make([]*types.Transaction, 0, len(n.ethTxsToGossip))
+	for _, tx := range n.ethTxsToGossip {
+		txs = append(txs, tx)
+		delete(n.ethTxsToGossip, tx.Hash())
+	}
+	n.ethTxsToGossipSize = 0
+	n.pendingGossipBytes.Update(0)
+
+	// Look up the status of every tx at once to avoid taking the tx pool lock
+	// once per tx.
+	hashes := make([]common.Hash, len(txs))
+	for i, tx := range txs {
+		hashes[i] = tx.Hash()
+	}
+	pool := n.chain.GetTxPool()
+	statuses := pool.Status(hashes)
+	minGasPrice := new(big.Int).SetUint64(n.config.MinGossipGasPrice)
+	selectedTxs := make([]*types.Transaction, 0)
+	for i, tx := range txs {
+		txHash := hashes[i]
+		if statuses[i] != core.TxStatusPending {
+			continue
+		}
+		if !n.ethTxGossipable(pool, tx, txHash, minGasPrice) {
+			continue
+		}
+
+		// We check [force] outside of the if statement to avoid an unnecessary
+		// cache lookup.
+		if !force && n.ethTxGossiped(txHash) {
+			continue
 		}
-		n.recentEthTxs.Put(txHash, nil)
 
 		selectedTxs = append(selectedTxs, tx)
 	}
@@ -424,24 +2275,96 @@ func (n *pushNetwork) gossipEthTxs(force bool) (int, error) {
 		return 0, nil
 	}
 
-	// Attempt to gossip [selectedTxs]
-	msgTxs := make([]*types.Transaction, 0)
-	msgTxsSize := common.StorageSize(0)
-	for _, tx := range selectedTxs {
+	// Attempt to gossip [selectedTxs] using at most [MaxGossipMsgsPerBlock]
+	// messages. Any txs that do not fit are left in [ethTxsToGossip] to be
+	// gossiped in the next gossip cycle.
+	var (
+		msgTxs     = make([]*types.Transaction, 0)
+		msgTxsSize = common.StorageSize(0)
+		msgsSent   = 0
+		sendErr    error
+	)
+	for i, tx := range selectedTxs {
 		size := tx.Size()
 		if msgTxsSize+size > message.EthMsgSoftCapSize {
-			if err := n.sendEthTxs(msgTxs); err != nil {
-				return len(selectedTxs), err
+			// A failed message does not prevent the remaining txs from being
+			// gossiped, as [sendEthTxs] already queued its txs to be retried.
+			if err := n.sendEthTxs(msgTxs); err != nil && sendErr == nil {
+				sendErr = err
 			}
-			msgTxs = msgTxs[:0]
+			msgTxs = make([]*types.Transaction, 0)
 			msgTxsSize = 0
+
+			msgsSent++
+			if maxMsgs := n.config.MaxGossipMsgsPerBlock; maxMsgs > 0 && msgsSent >= maxMsgs {
+				for _, deferredTx := range selectedTxs[i:] {
+					n.bufferEthTx(deferredTx)
+				}
+				log.Trace(
+					"deferring eth txs gossip after reaching the max gossip messages",
+					"len(txs)", len(selectedTxs)-i,
+				)
+				return i, sendErr
+			}
 		}
+		n.recentEthTxs.Put(tx.Hash(), &recentEthTx{
+			source:   n.ethTxSource(tx.Hash()),
+			gossiped: true,
+		})
 		msgTxs = append(msgTxs, tx)
 		msgTxsSize += size
 	}
 
 	// Send any remaining [msgTxs]
-	return len(selectedTxs), n.sendEthTxs(msgTxs)
+	if err := n.sendEthTxs(msgTxs); err != nil && sendErr == nil {
+		sendErr = err
+	}
+	return len(selectedTxs), sendErr
+}
+
+// ethTxGossipable returns false if the pending tx [tx] should not be gossiped
+// according to the config, regardless of whether it was recently gossiped.
+func (n *pushNetwork) ethTxGossipable(pool *core.TxPool, tx *types.Transaction, txHash common.Hash, minGasPrice *big.Int) bool {
+	if n.config.RemoteTxGossipOnlyEnabled && pool.HasLocal(txHash) {
+		return false
+	}
+
+	// A tx that is too large to be gossiped can still be included in a
+	// block if it was issued to this node.
+	if maxSize := n.config.MaxGossipTxSize; maxSize > 0 && tx.Size() > common.StorageSize(maxSize) {
+		n.oversizedTxs.Inc(1)
+		log.Warn(
+			"not gossiping eth tx larger than the max gossip tx size",
+			"reason", dropReasonOversizedTx,
+			"txHash", txHash,
+			"size", tx.Size(),
+			"maxSize", maxSize,
+		)
+		return false
+	}
+
+	if minGasPrice.Sign() > 0 && tx.GasFeeCapIntCmp(minGasPrice) < 0 && !pool.HasLocal(txHash) {
+		n.lowGasPriceTxs.Inc(1)
+		log.Trace(
+			"not gossiping eth tx below the min gossip gas price",
+			"reason", dropReasonLowGasPrice,
+			"txHash", txHash,
+			"gasFeeCap", tx.GasFeeCap(),
+			"minGasPrice", minGasPrice,
+		)
+		return false
+	}
+	return true
+}
+
+// bufferEthTx adds [tx] to the txs that are gossiped in the next gossip cycle.
+func (n *pushNetwork) bufferEthTx(tx *types.Transaction) {
+	txHash := tx.Hash()
+	if _, ok := n.ethTxsToGossip[txHash]; !ok {
+		n.ethTxsToGossipSize += tx.Size()
+		n.pendingGossipBytes.Update(int64(n.ethTxsToGossipSize))
+	}
+	n.ethTxsToGossip[txHash] = tx
 }
 
 // GossipEthTxs enqueues the provided [txs] for gossiping. At some point, the
@@ -454,16 +2377,138 @@ func (n *pushNetwork) GossipEthTxs(txs []*types.Transaction) error {
 	if time.Now().Before(n.gossipActivationTime) {
 		log.Trace(
 			"not gossiping eth txs before the gossiping activation time",
+			"reason", dropReasonBeforeActivation,
 			"len(txs)", len(txs),
 		)
 		return nil
 	}
 
-	select {
-	case n.ethTxsToGossipChan <- txs:
-	case <-n.shutdownChan:
+	select {
+	case n.ethTxsToGossipChan <- txs:
+	case <-n.shutdownChan:
+	}
+	return nil
+}
+
+// GossipEthTx gossips the single eth tx [tx] right away, rather than buffering
+// it until the next gossip cycle as GossipEthTxs does. It shares the record of
+// recently gossiped txs with GossipEthTxs, so the tx is not gossiped if it was
+// recently gossiped by either.
+func (n *pushNetwork) GossipEthTx(tx *types.Transaction) error {
+	txHash := tx.Hash()
+	if time.Now().Before(n.gossipActivationTime) {
+		log.Trace(
+			"not gossiping eth tx before the gossiping activation time",
+			"reason", dropReasonBeforeActivation,
+			"txHash", txHash,
+		)
+		return nil
+	}
+
+	pool := n.chain.GetTxPool()
+	if pool.Status([]common.Hash{txHash})[0] != core.TxStatusPending {
+		log.Trace(
+			"not gossiping eth tx that is not pending",
+			"reason", dropReasonNotPending,
+			"txHash", txHash,
+		)
+		return nil
+	}
+	if !n.ethTxGossipable(pool, tx, txHash, new(big.Int).SetUint64(n.config.MinGossipGasPrice)) {
+		return nil
+	}
+	if n.ethTxGossiped(txHash) {
+		log.Trace(
+			"not gossiping recently gossiped eth tx",
+			"reason", dropReasonRecentDupe,
+			"txHash", txHash,
+		)
+		return nil
+	}
+
+	n.recentEthTxs.Put(txHash, &recentEthTx{
+		source:   n.ethTxSource(txHash),
+		gossiped: true,
+	})
+	return n.sendEthTxs([]*types.Transaction{tx})
+}
+
+// GossipEthTxsByHash looks up [hashes] in the tx pool and gossips the txs that
+// are still pending, even if they were recently gossiped. Hashes of txs that
+// are unknown to the tx pool or were already included in a block are skipped.
+//
+// NOTE: We never return a non-nil error from this function but retain the
+// option to do so in case it becomes useful.
+func (n *pushNetwork) GossipEthTxsByHash(hashes []common.Hash) error {
+	if time.Now().Before(n.gossipActivationTime) {
+		log.Trace(
+			"not gossiping eth txs before the gossiping activation time",
+			"reason", dropReasonBeforeActivation,
+			"len(hashes)", len(hashes),
+		)
+		return nil
+	}
+
+	pool := n.chain.GetTxPool()
+	statuses := pool.Status(hashes)
+	txs := make([]*types.Transaction, 0, len(hashes))
+	for i, hash := range hashes {
+		if statuses[i] != core.TxStatusPending {
+			continue
+		}
+		// The tx may have been removed from the pool since its status was
+		// fetched.
+		if tx := pool.Get(hash); tx != nil {
+			txs = append(txs, tx)
+		}
+	}
+	if len(txs) == 0 {
+		return nil
+	}
+
+	select {
+	case n.ethTxsToRegossipChan <- txs:
+	case <-n.shutdownChan:
+	}
+	return nil
+}
+
+// RegossipPendingTxs forgets that the pending txs of the tx pool and the
+// atomic mempool were recently gossiped and gossips them again. The eth txs
+// are subject to [MaxGossipMsgsPerBlock] like any other gossip, and at most
+// [MaxGossipMsgsPerBlock] of the highest priced atomic txs are gossiped so
+// that a large mempool does not cause a burst of messages.
+func (n *pushNetwork) RegossipPendingTxs() (int, int, error) {
+	ethTxs := make([]*types.Transaction, 0)
+	for _, txs := range n.chain.GetTxPool().Pending(false) {
+		for _, tx := range txs {
+			n.recentEthTxs.Evict(tx.Hash())
+			ethTxs = append(ethTxs, tx)
+		}
+	}
+	// The eth txs are forced through the regossip path so that they are sent
+	// right away instead of waiting for the next gossip interval.
+	if len(ethTxs) > 0 && !time.Now().Before(n.gossipActivationTime) {
+		select {
+		case n.ethTxsToRegossipChan <- ethTxs:
+		case <-n.shutdownChan:
+		}
+	}
+
+	atomicTxs := n.mempool.PendingTxs()
+	if maxMsgs := n.config.MaxGossipMsgsPerBlock; maxMsgs > 0 && len(atomicTxs) > maxMsgs {
+		atomicTxs = atomicTxs[:maxMsgs]
 	}
-	return nil
+	for _, tx := range atomicTxs {
+		n.recentAtomicTxs.Evict(tx.ID())
+	}
+
+	log.Debug(
+		"regossiping pending txs",
+		"len(ethTxs)", len(ethTxs),
+		"len(atomicTxs)", len(atomicTxs),
+	)
+	return len(ethTxs), len(atomicTxs), n.GossipAtomicTxs(atomicTxs)
 }
 
 func (n *pushNetwork) handle(
@@ -482,27 +2527,199 @@ func (n *pushNetwork) handle(
 	)
 
 	if time.Now().Before(n.gossipActivationTime) {
-		log.Trace("App message called before activation time")
+		log.Trace(
+			"dropping App message before activation time",
+			"reason", dropReasonBeforeActivation,
+			"peerID", nodeID,
+		)
+		return nil
+	}
+
+	if !n.peerAllowed(nodeID) {
+		n.notAllowedMsgs.Inc(1)
+		log.Trace(
+			"dropping App message from a peer that is not allowed",
+			"reason", dropReasonPeerNotAllowed,
+			"peerID", nodeID,
+		)
+		return nil
+	}
+
+	if n.validators != nil && !n.validators.contains(nodeID) {
+		n.nonValidatorMsgs.Inc(1)
+		log.Trace(
+			"dropping App message from a peer that is not a validator",
+			"reason", dropReasonNotValidator,
+			"peerID", nodeID,
+		)
 		return nil
 	}
 
-	msg, err := message.Parse(msgBytes)
+	msg, msgVersion, err := message.ParseWithVersion(msgBytes)
+	if errors.Is(err, message.ErrUnknownVersion) {
+		n.unknownVersionMsgs.Inc(1)
+		log.Trace(
+			"dropping App message with unknown version",
+			"reason", dropReasonUnknownVersion,
+			"peerID", nodeID,
+			"err", err,
+		)
+		return nil
+	}
 	if err != nil {
 		log.Trace(
 			"dropping App message due to failing to parse message",
+			"reason", dropReasonParseFailed,
+			"peerID", nodeID,
 			"err", err,
 		)
 		return nil
 	}
+	n.updatePeerVersion(nodeID, msgVersion)
+
+	msgType := reflect.TypeOf(msg).Elem().Name()
+	n.statsLock.Lock()
+	n.messagesHandled[msgType]++
+	n.messagesLastHandled[msgType] = time.Now()
+	n.statsLock.Unlock()
 
 	return msg.Handle(handler, nodeID, requestID)
 }
 
+func (n *pushNetwork) PendingGossipQueue() []common.Hash {
+	n.pendingLock.Lock()
+	defer n.pendingLock.Unlock()
+
+	hashes := make([]common.Hash, 0, len(n.pendingEthTxs))
+	for hash := range n.pendingEthTxs {
+		hashes = append(hashes, hash)
+	}
+	return hashes
+}
+
+func (n *pushNetwork) AtomicTxSource(txID ids.ID) (ids.ShortID, bool) {
+	source, ok := n.atomicTxSources.Get(txID)
+	if !ok {
+		return ids.ShortEmpty, false
+	}
+	return source.(ids.ShortID), true
+}
+
+func (n *pushNetwork) PendingAtomicGossipQueue() []ids.ID {
+	n.pendingLock.Lock()
+	defer n.pendingLock.Unlock()
+
+	txIDs := make([]ids.ID, 0, len(n.pendingAtomicTxs))
+	for txID := range n.pendingAtomicTxs {
+		txIDs = append(txIDs, txID)
+	}
+	return txIDs
+}
+
+// GossipEnabled returns true, as [pushNetwork] gossips txs once the gossip
+// activation time has passed.
+func (n *pushNetwork) GossipEnabled() bool {
+	return true
+}
+
+func (n *pushNetwork) GossipBackpressure() bool {
+	if !n.hasGossipPeers() || !n.mempool.HasCapacity() || !n.chain.GetTxPool().HasCapacity() {
+		return true
+	}
+
+	n.requestsLock.Lock()
+	defer n.requestsLock.Unlock()
+
+	return n.appRequestsFull()
+}
+
+// Shutdown gossips the eth txs that are waiting for the next gossip interval,
+// so that they are not lost, waiting at most [gossipFlushTimeout].
+func (n *pushNetwork) Shutdown() {
+	flushed := make(chan struct{})
+	timeout := time.NewTimer(gossipFlushTimeout)
+	defer timeout.Stop()
+
+	select {
+	case n.flushGossipChan <- flushed:
+	case <-timeout.C:
+		log.Warn("timed out waiting to flush buffered eth txs gossip")
+		return
+	}
+	select {
+	case <-flushed:
+	case <-timeout.C:
+		log.Warn("timed out flushing buffered eth txs gossip")
+	}
+}
+
+// NetworkStats returns a snapshot of the current gossip state of [n].
+func (n *pushNetwork) NetworkStats() NetworkStats {
+	n.statsLock.Lock()
+	defer n.statsLock.Unlock()
+
+	messagesHandled := make(map[string]uint64, len(n.messagesHandled))
+	for msgType, count := range n.messagesHandled {
+		messagesHandled[msgType] = count
+	}
+	return NetworkStats{
+		RecentAtomicTxs:     n.recentAtomicTxs.Len(),
+		RecentEthTxs:        n.recentEthTxs.Len(),
+		MessagesHandled:     messagesHandled,
+		LastAtomicTxsGossip: n.lastAtomicGossiped,
+		LastEthTxsGossip:    n.lastEthGossiped,
+	}
+}
+
+// GossipMessageCount is the number of inbound messages of a single type that
+// were handled, and the last time that one was handled.
+type GossipMessageCount struct {
+	Received     uint64    `json:"received"`
+	LastReceived time.Time `json:"lastReceived"`
+}
+
+func (n *pushNetwork) GossipMessageCounts() map[string]GossipMessageCount {
+	n.statsLock.Lock()
+	defer n.statsLock.Unlock()
+
+	counts := make(map[string]GossipMessageCount, len(n.messagesHandled))
+	for msgType, count := range n.messagesHandled {
+		counts[msgType] = GossipMessageCount{
+			Received:     count,
+			LastReceived: n.messagesLastHandled[msgType],
+		}
+	}
+	return counts
+}
+
+// invalidEthTxErrs are the tx pool errors that show a gossiped eth tx can
+// never be valid, rather than being invalid only in the current pool state.
+var invalidEthTxErrs = []error{
+	core.ErrInvalidSender,
+	core.ErrNegativeValue,
+	core.ErrOversizedData,
+	core.ErrIntrinsicGas,
+	core.ErrGasUintOverflow,
+	core.ErrTipAboveFeeCap,
+	core.ErrTipVeryHigh,
+	core.ErrFeeCapVeryHigh,
+}
+
 type GossipHandler struct {
 	message.NoopHandler
 
-	vm  *VM
 	net *pushNetwork
+
+	// OnInvalidTx, if non-nil, is called when [nodeID] gossips a tx that can
+	// never be valid, such as a tx that fails to parse. Txs that are rejected
+	// only because of the current chain or mempool state are not reported.
+	OnInvalidTx func(nodeID ids.ShortID, err error)
+}
+
+func (h *GossipHandler) invalidTx(nodeID ids.ShortID, err error) {
+	if h.OnInvalidTx != nil {
+		h.OnInvalidTx(nodeID, err)
+	}
 }
 
 func (h *GossipHandler) HandleAtomicTx(nodeID ids.ShortID, _ uint32, msg *message.AtomicTx) error {
@@ -514,6 +2731,7 @@ func (h *GossipHandler) HandleAtomicTx(nodeID ids.ShortID, _ uint32, msg *messag
 	if len(msg.Tx) == 0 {
 		log.Trace(
 			"AppGossip received empty AtomicTx Message",
+			"reason", dropReasonEmptyMsg,
 			"peerID", nodeID,
 		)
 		return nil
@@ -521,40 +2739,73 @@ func (h *GossipHandler) HandleAtomicTx(nodeID ids.ShortID, _ uint32, msg *messag
 
 	// In the case that the gossip message contains a transaction,
 	// attempt to parse it and add it as a remote.
-	tx := Tx{}
-	if _, err := Codec.Unmarshal(msg.Tx, &tx); err != nil {
+	tx, err := parseGossipedAtomicTx(Codec, msg.Tx)
+	if err != nil {
 		log.Trace(
 			"AppGossip provided invalid tx",
+			"reason", dropReasonParseFailed,
+			"peerID", nodeID,
 			"err", err,
 		)
+		h.invalidTx(nodeID, err)
 		return nil
 	}
-	unsignedBytes, err := Codec.Marshal(codecVersion, &tx.UnsignedAtomicTx)
-	if err != nil {
+
+	txID := tx.ID()
+	if _, dropped, found := h.net.mempool.GetTx(txID); found || dropped {
 		log.Trace(
-			"AppGossip failed to marshal unsigned tx",
-			"err", err,
+			"AppGossip provided known tx",
+			"reason", dropReasonKnownTx,
+			"peerID", nodeID,
+			"txID", txID,
 		)
 		return nil
 	}
-	tx.Initialize(unsignedBytes, msg.Tx)
 
-	txID := tx.ID()
-	if _, dropped, found := h.net.mempool.GetTx(txID); found || dropped {
+	// Remote txs are dropped rather than evicting txs that are already in a
+	// full mempool. They will be gossiped again once the mempool drains.
+	if !h.net.mempool.HasCapacity() {
+		h.net.mempoolFullTxs.Inc(1)
+		log.Trace(
+			"AppGossip provided tx while the mempool is full",
+			"reason", dropReasonMempoolFull,
+			"peerID", nodeID,
+			"txID", txID,
+		)
 		return nil
 	}
 
-	if err := h.vm.issueTx(&tx, false /*=local*/); err != nil {
+	if err := h.net.mempool.IssueTx(tx, false /*=local*/); err != nil {
 		log.Trace(
 			"AppGossip provided invalid transaction",
+			"reason", dropReasonMempoolRejected,
 			"peerID", nodeID,
 			"err", err,
 		)
+		return nil
 	}
+	h.net.atomicTxSources.Put(txID, nodeID)
 
 	return nil
 }
 
+// parseGossipedAtomicTx parses the atomic tx [txBytes] with [c]. The unsigned
+// bytes of the tx are marshalled with the codec version that [txBytes] was
+// encoded with, so that the tx keeps the ID and signatures of its sender.
+func parseGossipedAtomicTx(c codec.Manager, txBytes []byte) (*Tx, error) {
+	tx := &Tx{}
+	version, err := c.Unmarshal(txBytes, tx)
+	if err != nil {
+		return nil, err
+	}
+	unsignedBytes, err := c.Marshal(version, &tx.UnsignedAtomicTx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal unsigned tx: %w", err)
+	}
+	tx.Initialize(unsignedBytes, txBytes)
+	return tx, nil
+}
+
 func (h *GossipHandler) HandleEthTxs(nodeID ids.ShortID, _ uint32, msg *message.EthTxs) error {
 	log.Trace(
 		"AppGossip called with EthTxs",
@@ -565,31 +2816,341 @@ func (h *GossipHandler) HandleEthTxs(nodeID ids.ShortID, _ uint32, msg *message.
 	if len(msg.Txs) == 0 {
 		log.Trace(
 			"AppGossip received empty EthTxs Message",
+			"reason", dropReasonEmptyMsg,
 			"peerID", nodeID,
 		)
 		return nil
 	}
 
-	// The maximum size of this encoded object is enforced by the codec.
+	// The codec bounds the size of the whole message, but decoding is only
+	// attempted on payloads that an honest peer could have sent, so that a
+	// crafted payload can not cause excessive allocations while decoding.
+	if len(msg.Txs) > maxEthTxsMsgSize {
+		h.net.oversizedMsgs.Inc(1)
+		log.Trace(
+			"AppGossip provided oversized txs",
+			"reason", dropReasonOversizedMsg,
+			"peerID", nodeID,
+			"size(txs)", len(msg.Txs),
+		)
+		return nil
+	}
 	txs := make([]*types.Transaction, 0)
 	if err := rlp.DecodeBytes(msg.Txs, &txs); err != nil {
 		log.Trace(
 			"AppGossip provided invalid txs",
+			"reason", dropReasonParseFailed,
 			"peerID", nodeID,
 			"err", err,
 		)
+		h.invalidTx(nodeID, err)
+		return nil
+	}
+	// The size of the message does not bound the number of txs it holds
+	// tightly enough to prevent a peer from forcing this node to process
+	// many tiny txs at once.
+	if len(txs) > maxEthTxsPerMessage {
+		h.net.tooManyTxsMsgs.Inc(1)
+		log.Trace(
+			"AppGossip provided too many txs",
+			"reason", dropReasonTooManyTxs,
+			"peerID", nodeID,
+			"len(txs)", len(txs),
+		)
+		return nil
+	}
+	if maxTxs := h.net.config.MaxGossipTxsPerSender; maxTxs > 0 {
+		txs = h.limitTxsPerSender(nodeID, txs, maxTxs)
+	}
+	pool := h.net.chain.GetTxPool()
+	if !pool.HasCapacity() {
+		h.net.mempoolFullTxs.Inc(int64(len(txs)))
+		log.Trace(
+			"AppGossip provided txs while the tx pool is full",
+			"reason", dropReasonMempoolFull,
+			"peerID", nodeID,
+			"size(txs)", len(txs),
+		)
 		return nil
 	}
-	errs := h.net.chain.GetTxPool().AddRemotes(txs)
+	// The sources are recorded before the txs are added to the tx pool, which
+	// may gossip them right away, so that they are not gossiped back to
+	// [nodeID].
+	recorded := h.net.recordEthTxSources(nodeID, txs)
+	errs := pool.AddRemotes(txs)
+	added := make([]common.Hash, 0, len(txs))
 	for i, err := range errs {
-		if err != nil {
+		if err == nil {
+			added = append(added, txs[i].Hash())
+		} else {
+			if recorded[i] {
+				h.net.recentEthTxs.Evict(txs[i].Hash())
+			}
+			// The tx pool only rejects tx types that are not yet activated
+			// with [core.ErrTxTypeNotSupported]. Such txs could not have been
+			// gossiped by a peer following the same rules, so the peer is
+			// reported even though the tx may become valid after a fork.
+			if errors.Is(err, core.ErrTxTypeNotSupported) {
+				h.net.notActivatedTxs.Inc(1)
+				log.Trace(
+					"AppGossip provided tx of a type that is not activated",
+					"reason", dropReasonTxTypeNotActivated,
+					"peerID", nodeID,
+					"type", txs[i].Type(),
+					"tx", txs[i].Hash(),
+				)
+				h.invalidTx(nodeID, err)
+				continue
+			}
 			log.Trace(
 				"AppGossip failed to add to mempool",
+				"reason", dropReasonMempoolRejected,
+				"peerID", nodeID,
 				"err", err,
 				"tx", txs[i].Hash(),
 			)
+			for _, invalidErr := range invalidEthTxErrs {
+				if errors.Is(err, invalidErr) {
+					h.invalidTx(nodeID, err)
+					break
+				}
+			}
+		}
+	}
+
+	// Acks are an optimization, so failing to send one is not an error.
+	if h.net.config.TxsAckEnabled && len(added) > 0 {
+		if err := h.net.sendTxsAck(nodeID, added); err != nil {
+			log.Debug(
+				"failed to send TxsAck",
+				"peerID", nodeID,
+				"err", err,
+			)
+		}
+	}
+	return nil
+}
+
+// limitTxsPerSender returns the txs of [txs] without the txs from any sender
+// after its first [maxTxs]. Txs whose sender cannot be recovered are kept, as
+// they will be rejected by the tx pool.
+func (h *GossipHandler) limitTxsPerSender(nodeID ids.ShortID, txs []*types.Transaction, maxTxs int) []*types.Transaction {
+	signer := types.LatestSigner(h.net.chain.BlockChain().Config())
+	senderTxs := make(map[common.Address]int)
+	limitedTxs := make([]*types.Transaction, 0, len(txs))
+	for _, tx := range txs {
+		sender, err := types.Sender(signer, tx)
+		if err != nil {
+			limitedTxs = append(limitedTxs, tx)
+			continue
+		}
+		if senderTxs[sender] >= maxTxs {
+			h.net.senderLimitedTxs.Inc(1)
+			log.Trace(
+				"AppGossip provided too many txs from a single sender",
+				"reason", dropReasonSenderLimit,
+				"peerID", nodeID,
+				"sender", sender,
+				"tx", tx.Hash(),
+			)
+			continue
+		}
+		senderTxs[sender]++
+		limitedTxs = append(limitedTxs, tx)
+	}
+	return limitedTxs
+}
+
+func (h *GossipHandler) HandleMempoolBloom(nodeID ids.ShortID, _ uint32, msg *message.MempoolBloom) error {
+	log.Trace(
+		"AppGossip called with MempoolBloom",
+		"peerID", nodeID,
+	)
+
+	if !h.net.config.MempoolBloomEnabled {
+		log.Trace(
+			"AppGossip provided mempool bloom while mempool blooms are disabled",
+			"reason", dropReasonBloomDisabled,
+			"peerID", nodeID,
+		)
+		return nil
+	}
+	if len(msg.Bloom) != mempoolBloomSize {
+		log.Trace(
+			"AppGossip provided invalid mempool bloom",
+			"reason", dropReasonInvalidBloom,
+			"peerID", nodeID,
+			"size(bloom)", len(msg.Bloom),
+		)
+		return nil
+	}
+
+	h.net.peersLock.Lock()
+	defer h.net.peersLock.Unlock()
+
+	// Only track blooms of connected peers, so that [peerBlooms] is cleaned
+	// up when they disconnect.
+	if _, connected := h.net.peerVersions[nodeID]; connected {
+		h.net.peerBlooms[nodeID] = peerBloom{
+			bloom:  mempoolBloom(msg.Bloom),
+			expiry: time.Now().Add(peerBloomTTL),
+		}
+	}
+	return nil
+}
+
+// HandleEthTxHashes requests the eth txs announced by [nodeID] that are not
+// in the tx pool. The requested txs are handled as if they were gossiped.
+func (h *GossipHandler) HandleEthTxHashes(nodeID ids.ShortID, _ uint32, msg *message.EthTxHashes) error {
+	log.Trace(
+		"AppGossip called with EthTxHashes",
+		"peerID", nodeID,
+		"len(hashes)", len(msg.Hashes),
+	)
+
+	if len(msg.Hashes) == 0 {
+		log.Trace(
+			"AppGossip received empty EthTxHashes Message",
+			"reason", dropReasonEmptyMsg,
+			"peerID", nodeID,
+		)
+		return nil
+	}
+	if len(msg.Hashes) > maxEthTxsPerMessage {
+		h.net.tooManyTxsMsgs.Inc(1)
+		log.Trace(
+			"AppGossip provided too many tx hashes",
+			"reason", dropReasonTooManyTxs,
+			"peerID", nodeID,
+			"len(hashes)", len(msg.Hashes),
+		)
+		return nil
+	}
+	pool := h.net.chain.GetTxPool()
+	if !pool.HasCapacity() {
+		h.net.mempoolFullTxs.Inc(int64(len(msg.Hashes)))
+		log.Trace(
+			"AppGossip provided tx hashes while the tx pool is full",
+			"reason", dropReasonMempoolFull,
+			"peerID", nodeID,
+			"len(hashes)", len(msg.Hashes),
+		)
+		return nil
+	}
+
+	unknown := make([]common.Hash, 0, len(msg.Hashes))
+	for _, hash := range msg.Hashes {
+		if !pool.Has(hash) {
+			unknown = append(unknown, hash)
 		}
 	}
+	if len(unknown) == 0 {
+		return nil
+	}
+	msgBytes, err := message.BuildWithVersion(&message.EthTxsRequest{Hashes: unknown}, message.Version1)
+	if err != nil {
+		return err
+	}
+	onResponse := func(response []byte) {
+		h.handleEthTxsResponse(nodeID, response)
+	}
+	// Announcements are an optimization, so failing to request the txs is
+	// not an error.
+	if _, err := h.net.sendAppRequestWithResponse(nodeID, msgBytes, onResponse, nil); err != nil {
+		log.Debug(
+			"failed to request announced eth txs",
+			"peerID", nodeID,
+			"len(hashes)", len(unknown),
+			"err", err,
+		)
+	}
+	return nil
+}
+
+// handleEthTxsResponse handles the [response] of [nodeID] to an
+// EthTxsRequest.
+func (h *GossipHandler) handleEthTxsResponse(nodeID ids.ShortID, response []byte) {
+	// The peer may no longer have any of the requested txs
+	if len(response) == 0 {
+		return
+	}
+	msg, err := message.Parse(response)
+	if err != nil {
+		log.Trace(
+			"AppResponse provided invalid EthTxs",
+			"reason", dropReasonParseFailed,
+			"peerID", nodeID,
+			"err", err,
+		)
+		h.invalidTx(nodeID, err)
+		return
+	}
+	ethTxs, ok := msg.(*message.EthTxs)
+	if !ok {
+		log.Trace(
+			"AppResponse provided unexpected message",
+			"reason", dropReasonParseFailed,
+			"peerID", nodeID,
+			"type", reflect.TypeOf(msg),
+		)
+		return
+	}
+	_ = h.HandleEthTxs(nodeID, 0, ethTxs)
+}
+
+func (h *GossipHandler) HandleTxsAck(nodeID ids.ShortID, _ uint32, msg *message.TxsAck) error {
+	log.Trace(
+		"AppGossip called with TxsAck",
+		"peerID", nodeID,
+		"len(hashes)", len(msg.Hashes),
+	)
+
+	h.net.peersLock.Lock()
+	defer h.net.peersLock.Unlock()
+
+	// Only track the txs known by connected peers, so that [peerKnownTxs] is
+	// cleaned up when they disconnect.
+	if _, connected := h.net.peerVersions[nodeID]; !connected {
+		return nil
+	}
+	known, ok := h.net.peerKnownTxs[nodeID]
+	if !ok {
+		known = newRecentCache(peerKnownTxsSize, 0)
+		h.net.peerKnownTxs[nodeID] = known
+	}
+	for _, hash := range msg.Hashes {
+		known.Put(hash, nil)
+	}
+	return nil
+}
+
+// HandleTxReplaced ignores the eth txs that [nodeID] reports were replaced in
+// its mempool. Replaced txs are only forgotten once the local tx pool reports
+// them replaced, so that peers can't make this node re-process and re-gossip
+// arbitrary txs.
+func (h *GossipHandler) HandleTxReplaced(nodeID ids.ShortID, _ uint32, msg *message.TxReplaced) error {
+	log.Trace(
+		"AppGossip called with TxReplaced",
+		"peerID", nodeID,
+		"len(hashes)", len(msg.Hashes),
+	)
+	return nil
+}
+
+func (h *GossipHandler) HandleBlockAnnouncement(nodeID ids.ShortID, _ uint32, msg *message.BlockAnnouncement) error {
+	log.Trace(
+		"AppGossip called with BlockAnnouncement",
+		"peerID", nodeID,
+		"hash", msg.Hash,
+		"height", msg.Height,
+	)
+
+	if msg.Height <= h.net.chain.LastAcceptedBlock().NumberU64() {
+		return nil
+	}
+	if h.net.resyncHint != nil {
+		h.net.resyncHint(nodeID, msg.Hash, msg.Height)
+	}
 	return nil
 }
 
@@ -608,9 +3169,51 @@ func (n *noopNetwork) AppResponse(nodeID ids.ShortID, requestID uint32, msgBytes
 func (n *noopNetwork) AppGossip(nodeID ids.ShortID, msgBytes []byte) error {
 	return nil
 }
+func (n *noopNetwork) Connected(nodeID ids.ShortID, nodeVersion version.Application) error {
+	return nil
+}
+func (n *noopNetwork) Disconnected(nodeID ids.ShortID) error {
+	return nil
+}
 func (n *noopNetwork) GossipAtomicTxs(tx []*Tx) error {
 	return nil
 }
 func (n *noopNetwork) GossipEthTxs(txs []*types.Transaction) error {
 	return nil
 }
+func (n *noopNetwork) GossipEthTx(tx *types.Transaction) error {
+	return nil
+}
+func (n *noopNetwork) GossipEthTxsByHash(hashes []common.Hash) error {
+	return nil
+}
+func (n *noopNetwork) GossipBlockAnnouncement(hash common.Hash, height uint64) error {
+	return nil
+}
+func (n *noopNetwork) RegossipPendingTxs() (int, int, error) {
+	return 0, 0, nil
+}
+func (n *noopNetwork) PendingGossipQueue() []common.Hash {
+	return nil
+}
+func (n *noopNetwork) PendingAtomicGossipQueue() []ids.ID {
+	return nil
+}
+func (n *noopNetwork) AtomicTxSource(ids.ID) (ids.ShortID, bool) {
+	return ids.ShortEmpty, false
+}
+func (n *noopNetwork) GossipEnabled() bool {
+	return false
+}
+func (n *noopNetwork) GossipBackpressure() bool {
+	return false
+}
+func (n *noopNetwork) Shutdown() {}
+func (n *noopNetwork) NetworkStats() NetworkStats {
+	return NetworkStats{MessagesHandled: make(map[string]uint64)}
+}
+func (n *noopNetwork) GossipMessageCounts() map[string]GossipMessageCount {
+	return make(map[string]GossipMessageCount)
+}
+func (n *noopNetwork) SetGossipChannels(map[ids.ID]commonEng.AppSender, func(*types.Transaction) ids.ID) {
+}
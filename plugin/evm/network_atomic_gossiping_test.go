@@ -4,14 +4,26 @@
 package evm
 
 import (
+	"reflect"
 	"sync"
 	"testing"
 	"time"
 
+	"github.com/ava-labs/avalanchego/codec"
 	"github.com/ava-labs/avalanchego/ids"
+	engCommon "github.com/ava-labs/avalanchego/snow/engine/common"
+	"github.com/ava-labs/avalanchego/utils/constants"
+	"github.com/ava-labs/avalanchego/utils/crypto"
+	"github.com/ava-labs/avalanchego/version"
+	"github.com/ava-labs/avalanchego/vms/components/avax"
+	"github.com/ava-labs/avalanchego/vms/secp256k1fx"
+
+	"github.com/ethereum/go-ethereum/common"
+	ethcrypto "github.com/ethereum/go-ethereum/crypto"
 
 	"github.com/stretchr/testify/assert"
 
+	"github.com/ava-labs/coreth/core/types"
 	"github.com/ava-labs/coreth/plugin/evm/message"
 )
 
@@ -221,3 +233,437 @@ func TestMempoolAtmTxsAppGossipHandlingDiscardedTx(t *testing.T) {
 	assert.False(mempool.has(txID))
 	assert.True(mempool.has(conflictingTx.ID()))
 }
+
+// fakeMempool is a [MempoolIface] that records the txs issued to it
+type fakeMempool struct {
+	txs     map[ids.ID]*Tx
+	dropped ids.Set
+	issued  []*Tx
+
+	// maxSize is the number of txs the mempool can hold, or 0 if unbounded
+	maxSize int
+}
+
+func newFakeMempool() *fakeMempool {
+	return &fakeMempool{
+		txs:     make(map[ids.ID]*Tx),
+		dropped: ids.NewSet(0),
+	}
+}
+
+func (m *fakeMempool) GetTx(txID ids.ID) (*Tx, bool, bool) {
+	tx, found := m.txs[txID]
+	return tx, m.dropped.Contains(txID), found
+}
+
+func (m *fakeMempool) GetPendingTx(txID ids.ID) (*Tx, bool) {
+	if m.dropped.Contains(txID) {
+		return nil, false
+	}
+	tx, found := m.txs[txID]
+	return tx, found
+}
+
+func (m *fakeMempool) PendingTxs() []*Tx {
+	txs := make([]*Tx, 0, len(m.txs))
+	for txID, tx := range m.txs {
+		if !m.dropped.Contains(txID) {
+			txs = append(txs, tx)
+		}
+	}
+	return txs
+}
+
+func (m *fakeMempool) IssueTx(tx *Tx, local bool) error {
+	m.issued = append(m.issued, tx)
+	m.txs[tx.ID()] = tx
+	return nil
+}
+
+func (m *fakeMempool) HasCapacity() bool {
+	return m.maxSize == 0 || len(m.txs) < m.maxSize
+}
+
+// newTestAtomicTx returns a signed import tx that is not backed by any UTXO
+func newTestAtomicTx(t testing.TB) *Tx {
+	tx := &Tx{UnsignedAtomicTx: &UnsignedImportTx{
+		NetworkID:    testNetworkID,
+		BlockchainID: testCChainID,
+		SourceChain:  testXChainID,
+		ImportedInputs: []*avax.TransferableInput{{
+			UTXOID: avax.UTXOID{TxID: ids.GenerateTestID()},
+			Asset:  avax.Asset{ID: testAvaxAssetID},
+			In: &secp256k1fx.TransferInput{
+				Amt:   1,
+				Input: secp256k1fx.Input{SigIndices: []uint32{0}},
+			},
+		}},
+		Outs: []EVMOutput{{
+			Address: testEthAddrs[0],
+			Amount:  1,
+			AssetID: testAvaxAssetID,
+		}},
+	}}
+	if err := tx.Sign(Codec, [][]*crypto.PrivateKeySECP256K1R{{testKeys[0]}}); err != nil {
+		t.Fatal(err)
+	}
+	return tx
+}
+
+// gossiped atomic txs should only be issued to the mempool if they are not
+// already known to it
+func TestGossipHandlerAtomicTx(t *testing.T) {
+	assert := assert.New(t)
+
+	mempool := newFakeMempool()
+	var invalidTxPeers []ids.ShortID
+	handler := &GossipHandler{
+		net: newTestPushNetwork(t, func(n *pushNetwork) {
+			n.mempool = mempool
+		}),
+		OnInvalidTx: func(nodeID ids.ShortID, _ error) {
+			invalidTxPeers = append(invalidTxPeers, nodeID)
+		},
+	}
+	nodeID := ids.GenerateTestShortID()
+
+	knownTx := newTestAtomicTx(t)
+	mempool.txs[knownTx.ID()] = knownTx
+	droppedTx := newTestAtomicTx(t)
+	mempool.txs[droppedTx.ID()] = droppedTx
+	mempool.dropped.Add(droppedTx.ID())
+	newTx := newTestAtomicTx(t)
+
+	for _, tx := range []*Tx{knownTx, droppedTx, newTx} {
+		assert.NoError(handler.HandleAtomicTx(nodeID, 0, &message.AtomicTx{Tx: tx.Bytes()}))
+	}
+	if assert.Len(mempool.issued, 1) {
+		assert.Equal(newTx.ID(), mempool.issued[0].ID())
+	}
+
+	// Only the peer that gossiped the issued tx is recorded as a source
+	source, ok := handler.net.AtomicTxSource(newTx.ID())
+	assert.True(ok)
+	assert.Equal(nodeID, source)
+	for _, tx := range []*Tx{knownTx, droppedTx} {
+		_, ok := handler.net.AtomicTxSource(tx.ID())
+		assert.False(ok)
+	}
+
+	// Re-gossiping the now known tx should not issue it again
+	assert.NoError(handler.HandleAtomicTx(nodeID, 0, &message.AtomicTx{Tx: newTx.Bytes()}))
+	assert.Len(mempool.issued, 1)
+
+	assert.Empty(invalidTxPeers)
+
+	// Empty and unparsable messages are dropped, and only unparsable txs are
+	// reported as invalid
+	assert.NoError(handler.HandleAtomicTx(nodeID, 0, &message.AtomicTx{}))
+	assert.Empty(invalidTxPeers)
+	assert.NoError(handler.HandleAtomicTx(nodeID, 0, &message.AtomicTx{Tx: []byte("not a tx")}))
+	assert.Len(mempool.issued, 1)
+	assert.Equal([]ids.ShortID{nodeID}, invalidTxPeers)
+}
+
+// gossiped atomic txs should not be issued to a full mempool
+func TestGossipHandlerAtomicTxMempoolFull(t *testing.T) {
+	assert := assert.New(t)
+
+	mempool := newFakeMempool()
+	mempool.maxSize = 2
+	handler := &GossipHandler{
+		net: newTestPushNetwork(t, func(n *pushNetwork) {
+			n.mempool = mempool
+		}),
+	}
+	nodeID := ids.GenerateTestShortID()
+
+	for i := 0; i < mempool.maxSize; i++ {
+		tx := newTestAtomicTx(t)
+		assert.NoError(handler.HandleAtomicTx(nodeID, 0, &message.AtomicTx{Tx: tx.Bytes()}))
+	}
+	assert.Len(mempool.issued, mempool.maxSize)
+	assert.False(mempool.HasCapacity())
+
+	for i := 0; i < 3; i++ {
+		tx := newTestAtomicTx(t)
+		assert.NoError(handler.HandleAtomicTx(nodeID, 0, &message.AtomicTx{Tx: tx.Bytes()}))
+	}
+	assert.Len(mempool.issued, mempool.maxSize)
+	assert.EqualValues(3, handler.net.mempoolFullTxs.Count())
+
+	// Once a tx leaves the mempool, gossiped txs are issued again
+	delete(mempool.txs, mempool.issued[0].ID())
+	tx := newTestAtomicTx(t)
+	assert.NoError(handler.HandleAtomicTx(nodeID, 0, &message.AtomicTx{Tx: tx.Bytes()}))
+	if assert.Len(mempool.issued, mempool.maxSize+1) {
+		assert.Equal(tx.ID(), mempool.issued[mempool.maxSize].ID())
+	}
+}
+
+// gossiped atomic txs should keep the codec version they were encoded with
+func TestParseGossipedAtomicTxCodecVersion(t *testing.T) {
+	assert := assert.New(t)
+
+	const otherCodecVersion = codecVersion + 1
+	c := codec.NewDefaultManager()
+	for _, version := range []uint16{codecVersion, otherCodecVersion} {
+		linearCodec, err := newAtomicTxLinearCodec()
+		assert.NoError(err)
+		assert.NoError(c.RegisterCodec(version, linearCodec))
+	}
+
+	tx := newTestAtomicTx(t)
+	for _, version := range []uint16{codecVersion, otherCodecVersion} {
+		txBytes, err := c.Marshal(version, tx)
+		assert.NoError(err)
+		unsignedBytes, err := c.Marshal(version, &tx.UnsignedAtomicTx)
+		assert.NoError(err)
+
+		parsedTx, err := parseGossipedAtomicTx(c, txBytes)
+		assert.NoError(err)
+		assert.Equal(txBytes, parsedTx.Bytes())
+		assert.Equal(unsignedBytes, parsedTx.UnsignedBytes())
+	}
+
+	// The tx bytes differ only by the codec version prefix
+	defaultBytes, err := c.Marshal(codecVersion, tx)
+	assert.NoError(err)
+	otherBytes, err := c.Marshal(otherCodecVersion, tx)
+	assert.NoError(err)
+	assert.NotEqual(defaultBytes, otherBytes)
+	assert.Equal(defaultBytes[2:], otherBytes[2:])
+
+	// [Codec] only registers [codecVersion]
+	_, err = parseGossipedAtomicTx(Codec, otherBytes)
+	assert.Error(err)
+}
+
+// atomic txs gossiped while no peers are connected should be gossiped when
+// the next peer connects
+func TestGossipAtomicTxsNoPeers(t *testing.T) {
+	assert := assert.New(t)
+
+	mempool := newFakeMempool()
+	sender := &engCommon.SenderTest{T: t}
+	n := newTestPushNetwork(t, func(n *pushNetwork) {
+		n.appSender = sender
+		n.mempool = mempool
+	})
+
+	var gossiped []ids.ID
+	sender.SendAppGossipF = func(msgBytes []byte) error {
+		msg, err := message.Parse(msgBytes)
+		assert.NoError(err)
+		atomicMsg, ok := msg.(*message.AtomicTx)
+		if assert.True(ok) {
+			tx := Tx{}
+			_, err := Codec.Unmarshal(atomicMsg.Tx, &tx)
+			assert.NoError(err)
+			unsignedBytes, err := Codec.Marshal(codecVersion, &tx.UnsignedAtomicTx)
+			assert.NoError(err)
+			tx.Initialize(unsignedBytes, atomicMsg.Tx)
+			gossiped = append(gossiped, tx.ID())
+		}
+		return nil
+	}
+
+	tx := newTestAtomicTx(t)
+	mempool.txs[tx.ID()] = tx
+
+	// With no peers connected, the tx is queued rather than sent
+	assert.NoError(n.GossipAtomicTxs([]*Tx{tx}))
+	assert.Empty(gossiped)
+	assert.EqualValues(1, n.pendingGossipTxs.Value())
+
+	// The queued tx is gossiped once a peer connects
+	assert.NoError(n.Connected(ids.GenerateTestShortID(), version.NewDefaultApplication(constants.PlatformName, 1, 7, 5)))
+	assert.Equal([]ids.ID{tx.ID()}, gossiped)
+	assert.EqualValues(0, n.pendingGossipTxs.Value())
+
+	// The queue is empty, so connecting another peer does not gossip the tx
+	// again
+	assert.NoError(n.Connected(ids.GenerateTestShortID(), version.NewDefaultApplication(constants.PlatformName, 1, 7, 5)))
+	assert.Len(gossiped, 1)
+}
+
+// atomic txs gossiped while fewer than [MinGossipPeers] peers are connected
+// should be gossiped once enough peers connect
+func TestGossipAtomicTxsMinGossipPeers(t *testing.T) {
+	assert := assert.New(t)
+
+	mempool := newFakeMempool()
+	sender := &engCommon.SenderTest{T: t}
+	n := newTestPushNetwork(t, func(n *pushNetwork) {
+		n.config.MinGossipPeers = 2
+		n.appSender = sender
+		n.mempool = mempool
+	})
+
+	gossiped := 0
+	sender.SendAppGossipF = func(msgBytes []byte) error {
+		gossiped++
+		return nil
+	}
+
+	tx := newTestAtomicTx(t)
+	mempool.txs[tx.ID()] = tx
+
+	// With fewer peers connected than required, the tx is deferred
+	assert.NoError(n.Connected(ids.GenerateTestShortID(), version.NewDefaultApplication(constants.PlatformName, 1, 7, 5)))
+	assert.NoError(n.GossipAtomicTxs([]*Tx{tx}))
+	assert.Zero(gossiped)
+	assert.EqualValues(1, n.pendingGossipTxs.Value())
+
+	// The deferred tx is gossiped once enough peers are connected
+	assert.NoError(n.Connected(ids.GenerateTestShortID(), version.NewDefaultApplication(constants.PlatformName, 1, 7, 5)))
+	assert.Equal(1, gossiped)
+	assert.EqualValues(0, n.pendingGossipTxs.Value())
+}
+
+// atomic txs deferred until [MinGossipPeers] peers connect should be gossiped
+// to the connected peers once they were deferred for [MinGossipPeersTimeout]
+func TestGossipAtomicTxsMinGossipPeersTimeout(t *testing.T) {
+	assert := assert.New(t)
+
+	mempool := newFakeMempool()
+	sender := &engCommon.SenderTest{T: t}
+	n := newTestPushNetwork(t, func(n *pushNetwork) {
+		n.config.MinGossipPeers = 2
+		n.config.MinGossipPeersTimeout = Duration{10 * time.Millisecond}
+		n.appSender = sender
+		n.mempool = mempool
+	})
+
+	gossiped := 0
+	sender.SendAppGossipF = func(msgBytes []byte) error {
+		gossiped++
+		return nil
+	}
+
+	tx := newTestAtomicTx(t)
+	mempool.txs[tx.ID()] = tx
+
+	assert.NoError(n.Connected(ids.GenerateTestShortID(), version.NewDefaultApplication(constants.PlatformName, 1, 7, 5)))
+	assert.NoError(n.GossipAtomicTxs([]*Tx{tx}))
+	assert.Zero(gossiped)
+
+	// The deferred tx is gossiped to the single peer after the timeout
+	time.Sleep(20 * time.Millisecond)
+	assert.NoError(n.gossipPendingTxs())
+	assert.Equal(1, gossiped)
+	assert.Empty(n.PendingAtomicGossipQueue())
+}
+
+// atomic txs and eth txs deferred until enough peers connect should be
+// gossiped with the atomic txs first
+func TestGossipPendingAtomicTxsBeforeEthTxs(t *testing.T) {
+	assert := assert.New(t)
+
+	key, err := ethcrypto.GenerateKey()
+	assert.NoError(err)
+	cfgJson, err := fundAddressByGenesis([]common.Address{ethcrypto.PubkeyToAddress(key.PublicKey)})
+	assert.NoError(err)
+
+	// Only [testPeerID] is connected, so txs are deferred
+	_, vm, _, _, sender := GenesisVM(t, true, cfgJson, `{"min-gossip-peers": 2}`, "")
+	defer func() {
+		assert.NoError(vm.Shutdown())
+	}()
+	net := vm.network.(*pushNetwork)
+	mempool := newFakeMempool()
+	net.mempool = mempool
+
+	var (
+		lock     sync.Mutex
+		msgTypes []string
+	)
+	sender.CantSendAppGossip = false
+	sender.SendAppGossipF = func(msgBytes []byte) error {
+		msg, err := message.Parse(msgBytes)
+		assert.NoError(err)
+
+		lock.Lock()
+		defer lock.Unlock()
+		msgTypes = append(msgTypes, reflect.TypeOf(msg).Elem().Name())
+		return nil
+	}
+
+	vm.chain.GetTxPool().SetGasPrice(common.Big1)
+	vm.chain.GetTxPool().SetMinFee(common.Big0)
+	ethTx := getValidEthTxs(key, 1, common.Big1)[0]
+	for _, err := range vm.chain.GetTxPool().AddRemotesSync([]*types.Transaction{ethTx}) {
+		assert.NoError(err)
+	}
+	assert.Eventually(func() bool {
+		return len(net.PendingGossipQueue()) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+
+	atomicTx := newTestAtomicTx(t)
+	mempool.txs[atomicTx.ID()] = atomicTx
+	assert.NoError(net.GossipAtomicTxs([]*Tx{atomicTx}))
+	assert.Len(net.PendingAtomicGossipQueue(), 1)
+
+	assert.NoError(vm.Connected(ids.GenerateTestShortID(), version.NewDefaultApplication(constants.PlatformName, 1, 7, 5)))
+	assert.Eventually(func() bool {
+		lock.Lock()
+		defer lock.Unlock()
+		return len(msgTypes) == 2
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Equal([]string{"AtomicTx", "EthTxs"}, msgTypes)
+}
+
+// atomic txs sent with [ReliableAtomicGossip] should be resent to another peer
+// when the request fails
+func TestReliableAtomicGossipRetry(t *testing.T) {
+	assert := assert.New(t)
+
+	mempool := newFakeMempool()
+	sender := &engCommon.SenderTest{T: t}
+	n := newTestPushNetwork(t, func(n *pushNetwork) {
+		n.config.ReliableAtomicGossip = true
+		n.appSender = sender
+		n.mempool = mempool
+	})
+
+	sender.SendAppGossipF = func([]byte) error {
+		t.Fatal("atomic txs should not be sent with AppGossip")
+		return nil
+	}
+	type request struct {
+		nodeID    ids.ShortID
+		requestID uint32
+	}
+	var requests []request
+	sender.SendAppRequestF = func(nodeIDs ids.ShortSet, requestID uint32, _ []byte) error {
+		for nodeID := range nodeIDs {
+			requests = append(requests, request{nodeID: nodeID, requestID: requestID})
+		}
+		return nil
+	}
+
+	nodeVersion := version.NewDefaultApplication(constants.PlatformName, 1, 7, 5)
+	firstNodeID := ids.GenerateTestShortID()
+	assert.NoError(n.Connected(firstNodeID, nodeVersion))
+
+	tx := newTestAtomicTx(t)
+	mempool.txs[tx.ID()] = tx
+	assert.NoError(n.GossipAtomicTxs([]*Tx{tx}))
+	assert.Equal([]request{{nodeID: firstNodeID, requestID: 0}}, requests)
+
+	// The request to the first peer is dropped, so it is resent to the other
+	// peer
+	secondNodeID := ids.GenerateTestShortID()
+	assert.NoError(n.Connected(secondNodeID, nodeVersion))
+	assert.NoError(n.AppRequestFailed(firstNodeID, 0))
+	assert.Equal([]request{
+		{nodeID: firstNodeID, requestID: 0},
+		{nodeID: secondNodeID, requestID: 1},
+	}, requests)
+
+	// The retried request succeeds, so it is not resent again
+	assert.NoError(n.AppResponse(secondNodeID, 1, nil))
+	assert.Empty(n.outstandingRequests)
+	assert.Empty(n.onRequestFailed)
+	assert.Len(requests, 2)
+}
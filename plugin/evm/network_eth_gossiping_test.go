@@ -6,16 +6,23 @@ package evm
 import (
 	"crypto/ecdsa"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"math/big"
+	"reflect"
 	"strings"
 	"sync"
 	"testing"
 	"time"
 
 	"github.com/ava-labs/avalanchego/ids"
+	engCommon "github.com/ava-labs/avalanchego/snow/engine/common"
+	"github.com/ava-labs/avalanchego/utils/constants"
+	"github.com/ava-labs/avalanchego/version"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/metrics"
 	"github.com/ethereum/go-ethereum/rlp"
 
 	"github.com/stretchr/testify/assert"
@@ -27,13 +34,22 @@ import (
 )
 
 func fundAddressByGenesis(addrs []common.Address) (string, error) {
-	balance := big.NewInt(0xffffffffffffff)
+	balances := make(map[common.Address]*big.Int, len(addrs))
+	for _, addr := range addrs {
+		balances[addr] = big.NewInt(0xffffffffffffff)
+	}
+	return fundAddressesByGenesis(balances)
+}
+
+// fundAddressesByGenesis returns a genesis funding each address of
+// [balances] with its balance.
+func fundAddressesByGenesis(balances map[common.Address]*big.Int) (string, error) {
 	genesis := &core.Genesis{
 		Difficulty: common.Big0,
 		GasLimit:   uint64(5000000),
 	}
 	funds := make(map[common.Address]core.GenesisAccount)
-	for _, addr := range addrs {
+	for addr, balance := range balances {
 		funds[addr] = core.GenesisAccount{
 			Balance: balance,
 		}
@@ -221,6 +237,303 @@ func TestMempoolEthTxsAddedTxsGossipedAfterActivationChunking(t *testing.T) {
 	}
 }
 
+// show that [MaxGossipMsgsPerBlock] limits the number of messages sent per
+// gossip cycle and that the remaining txs are gossiped in a later cycle
+func TestMempoolEthTxsGossipMaxMsgsPerBlock(t *testing.T) {
+	assert := assert.New(t)
+
+	key, err := crypto.GenerateKey()
+	assert.NoError(err)
+
+	addr := crypto.PubkeyToAddress(key.PublicKey)
+
+	cfgJson, err := fundAddressByGenesis([]common.Address{addr})
+	assert.NoError(err)
+
+	_, vm, _, _, sender := GenesisVM(t, true, cfgJson, `{"max-gossip-msgs-per-block": 1}`, "")
+	defer func() {
+		err := vm.Shutdown()
+		assert.NoError(err)
+	}()
+	vm.chain.GetTxPool().SetGasPrice(common.Big1)
+	vm.chain.GetTxPool().SetMinFee(common.Big0)
+
+	// create enough eth txes to require two messages
+	ethTxs := getValidEthTxs(key, 100, common.Big1)
+
+	var (
+		wg       sync.WaitGroup
+		lock     sync.Mutex
+		sentAt   []time.Time
+		msgSizes []int
+	)
+	wg.Add(2)
+	sender.CantSendAppGossip = false
+	seen := map[common.Hash]struct{}{}
+	sender.SendAppGossipF = func(gossipedBytes []byte) error {
+		notifyMsgIntf, err := message.Parse(gossipedBytes)
+		assert.NoError(err)
+
+		requestMsg, ok := notifyMsgIntf.(*message.EthTxs)
+		assert.True(ok)
+
+		txs := make([]*types.Transaction, 0)
+		assert.NoError(rlp.DecodeBytes(requestMsg.Txs, &txs))
+
+		lock.Lock()
+		defer lock.Unlock()
+		for _, tx := range txs {
+			seen[tx.Hash()] = struct{}{}
+		}
+		sentAt = append(sentAt, time.Now())
+		msgSizes = append(msgSizes, len(txs))
+		wg.Done()
+		return nil
+	}
+
+	// Notify VM about eth txs
+	errs := vm.chain.GetTxPool().AddRemotesSync(ethTxs)
+	for _, err := range errs {
+		assert.NoError(err, "failed adding coreth tx to mempool")
+	}
+
+	attemptAwait(t, &wg, 5*time.Second)
+
+	lock.Lock()
+	defer lock.Unlock()
+	assert.Len(sentAt, 2)
+	assert.Less(msgSizes[0], len(ethTxs), "first message should not contain all txs")
+	// The deferred txs should only be sent in the next gossip cycle
+	assert.GreaterOrEqual(int64(sentAt[1].Sub(sentAt[0])), int64(ethTxsGossipInterval/2))
+	for _, tx := range ethTxs {
+		_, ok := seen[tx.Hash()]
+		assert.True(ok, "missing hash: %v", tx.Hash())
+	}
+}
+
+// show that gossiping by hash only gossips txs that are still pending, even if
+// they were recently gossiped
+func TestMempoolEthTxsGossipByHash(t *testing.T) {
+	assert := assert.New(t)
+
+	key, err := crypto.GenerateKey()
+	assert.NoError(err)
+
+	addr := crypto.PubkeyToAddress(key.PublicKey)
+
+	cfgJson, err := fundAddressByGenesis([]common.Address{addr})
+	assert.NoError(err)
+
+	issuer, vm, _, _, sender := GenesisVM(t, true, cfgJson, "", "")
+	defer func() {
+		err := vm.Shutdown()
+		assert.NoError(err)
+	}()
+
+	newTxPoolHeadChan := make(chan core.NewTxPoolReorgEvent, 1)
+	vm.chain.GetTxPool().SubscribeNewReorgEvent(newTxPoolHeadChan)
+
+	gossiped := make(chan []common.Hash, 3)
+	sender.CantSendAppGossip = false
+	sender.SendAppGossipF = func(gossipedBytes []byte) error {
+		notifyMsgIntf, err := message.Parse(gossipedBytes)
+		assert.NoError(err)
+
+		requestMsg, ok := notifyMsgIntf.(*message.EthTxs)
+		assert.True(ok)
+
+		txs := make([]*types.Transaction, 0)
+		assert.NoError(rlp.DecodeBytes(requestMsg.Txs, &txs))
+		hashes := make([]common.Hash, 0, len(txs))
+		for _, tx := range txs {
+			hashes = append(hashes, tx.Hash())
+		}
+		gossiped <- hashes
+		return nil
+	}
+	awaitGossip := func() []common.Hash {
+		select {
+		case hashes := <-gossiped:
+			return hashes
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for eth txs to be gossiped")
+			return nil
+		}
+	}
+
+	ethTxs := getValidEthTxs(key, 2, initialBaseFee)
+
+	// Include the first tx in an accepted block
+	errs := vm.chain.GetTxPool().AddRemotesSync(ethTxs[:1])
+	assert.NoError(errs[0], "failed adding coreth tx to mempool")
+	assert.Equal([]common.Hash{ethTxs[0].Hash()}, awaitGossip())
+	<-issuer
+
+	blk, err := vm.BuildBlock()
+	assert.NoError(err)
+	assert.NoError(blk.Verify())
+	assert.NoError(vm.SetPreference(blk.ID()))
+	assert.NoError(blk.Accept())
+	<-newTxPoolHeadChan
+
+	// Leave the second tx pending
+	errs = vm.chain.GetTxPool().AddRemotesSync(ethTxs[1:])
+	assert.NoError(errs[0], "failed adding coreth tx to mempool")
+	assert.Equal([]common.Hash{ethTxs[1].Hash()}, awaitGossip())
+
+	// Only the pending tx should be gossiped again
+	assert.NoError(vm.network.GossipEthTxsByHash([]common.Hash{
+		ethTxs[0].Hash(),
+		ethTxs[1].Hash(),
+		common.Hash{1},
+	}))
+	assert.Equal([]common.Hash{ethTxs[1].Hash()}, awaitGossip())
+}
+
+// show that an eth tx dropped from the mempool is forgotten by the gossip
+// cache, so that it is gossiped again if it is re-added
+func TestMempoolEthTxsDroppedTxsGossipedAgain(t *testing.T) {
+	assert := assert.New(t)
+
+	key, err := crypto.GenerateKey()
+	assert.NoError(err)
+
+	addr := crypto.PubkeyToAddress(key.PublicKey)
+
+	cfgJson, err := fundAddressByGenesis([]common.Address{addr})
+	assert.NoError(err)
+
+	_, vm, _, _, sender := GenesisVM(t, true, cfgJson, "", "")
+	defer func() {
+		err := vm.Shutdown()
+		assert.NoError(err)
+	}()
+
+	gossiped := make(chan []common.Hash, 3)
+	sender.CantSendAppGossip = false
+	sender.SendAppGossipF = func(gossipedBytes []byte) error {
+		notifyMsgIntf, err := message.Parse(gossipedBytes)
+		assert.NoError(err)
+
+		requestMsg, ok := notifyMsgIntf.(*message.EthTxs)
+		assert.True(ok)
+
+		txs := make([]*types.Transaction, 0)
+		assert.NoError(rlp.DecodeBytes(requestMsg.Txs, &txs))
+		hashes := make([]common.Hash, 0, len(txs))
+		for _, tx := range txs {
+			hashes = append(hashes, tx.Hash())
+		}
+		gossiped <- hashes
+		return nil
+	}
+	awaitGossip := func() []common.Hash {
+		select {
+		case hashes := <-gossiped:
+			return hashes
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for eth txs to be gossiped")
+			return nil
+		}
+	}
+
+	txPool := vm.chain.GetTxPool()
+	ethTxs := getValidEthTxs(key, 1, initialBaseFee)
+	txHash := ethTxs[0].Hash()
+
+	errs := txPool.AddRemotesSync(ethTxs)
+	assert.NoError(errs[0], "failed adding coreth tx to mempool")
+	assert.Equal([]common.Hash{txHash}, awaitGossip())
+
+	// Evict the tx by raising the minimum gas price above its tip
+	minGasPrice := txPool.GasPrice()
+	txPool.SetGasPrice(new(big.Int).Add(initialBaseFee, common.Big1))
+	assert.False(txPool.Has(txHash))
+	assert.Eventually(func() bool {
+		_, has := vm.network.(*pushNetwork).recentEthTxs.Get(txHash)
+		return !has
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// Re-adding the tx should cause it to be gossiped again
+	txPool.SetGasPrice(minGasPrice)
+	errs = txPool.AddRemotesSync(ethTxs)
+	assert.NoError(errs[0], "failed re-adding coreth tx to mempool")
+	assert.Equal([]common.Hash{txHash}, awaitGossip())
+}
+
+// show that a replacement eth tx is gossiped and that peers are notified of
+// the hash of the tx it replaced
+func TestMempoolEthTxsReplacementGossiped(t *testing.T) {
+	assert := assert.New(t)
+
+	key, err := crypto.GenerateKey()
+	assert.NoError(err)
+
+	addr := crypto.PubkeyToAddress(key.PublicKey)
+
+	cfgJson, err := fundAddressByGenesis([]common.Address{addr})
+	assert.NoError(err)
+
+	_, vm, _, _, sender := GenesisVM(t, true, cfgJson, `{"tx-replaced-gossip-enabled": true}`, "")
+	defer func() {
+		err := vm.Shutdown()
+		assert.NoError(err)
+	}()
+
+	gossipedTxs := make(chan []common.Hash, 3)
+	replacedTxs := make(chan []common.Hash, 3)
+	sender.CantSendAppGossip = false
+	sender.SendAppGossipF = func(gossipedBytes []byte) error {
+		msgIntf, err := message.Parse(gossipedBytes)
+		assert.NoError(err)
+
+		switch msg := msgIntf.(type) {
+		case *message.EthTxs:
+			txs := make([]*types.Transaction, 0)
+			assert.NoError(rlp.DecodeBytes(msg.Txs, &txs))
+			hashes := make([]common.Hash, 0, len(txs))
+			for _, tx := range txs {
+				hashes = append(hashes, tx.Hash())
+			}
+			gossipedTxs <- hashes
+		case *message.TxReplaced:
+			replacedTxs <- msg.Hashes
+		default:
+			t.Errorf("unexpected message type %T", msgIntf)
+		}
+		return nil
+	}
+	await := func(gossiped chan []common.Hash) []common.Hash {
+		select {
+		case hashes := <-gossiped:
+			return hashes
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for gossip")
+			return nil
+		}
+	}
+
+	txPool := vm.chain.GetTxPool()
+	tx := getValidEthTxs(key, 1, initialBaseFee)[0]
+	errs := txPool.AddRemotesSync([]*types.Transaction{tx})
+	assert.NoError(errs[0], "failed adding coreth tx to mempool")
+	assert.Equal([]common.Hash{tx.Hash()}, await(gossipedTxs))
+
+	// Replace the tx with one paying twice the gas price
+	replacementTx := getValidEthTxs(key, 1, new(big.Int).Mul(initialBaseFee, common.Big2))[0]
+	errs = txPool.AddRemotesSync([]*types.Transaction{replacementTx})
+	assert.NoError(errs[0], "failed adding replacement coreth tx to mempool")
+	assert.Equal([]common.Hash{replacementTx.Hash()}, await(gossipedTxs))
+	assert.Equal([]common.Hash{tx.Hash()}, await(replacedTxs))
+
+	// Peers can't make the node forget txs that its tx pool did not replace
+	msgBytes, err := message.Build(&message.TxReplaced{Hashes: []common.Hash{replacementTx.Hash()}})
+	assert.NoError(err)
+	assert.NoError(vm.AppGossip(ids.GenerateTestShortID(), msgBytes))
+	_, has := vm.network.(*pushNetwork).recentEthTxs.Get(replacementTx.Hash())
+	assert.True(has, "replacement tx should not be forgotten by a peer's TxReplaced message")
+}
+
 // show that a geth tx discovered from gossip is requested to the same node that
 // gossiped it
 func TestMempoolEthTxsAppGossipHandling(t *testing.T) {
@@ -278,6 +591,105 @@ func TestMempoolEthTxsAppGossipHandling(t *testing.T) {
 	attemptAwait(t, &wg, 5*time.Second)
 }
 
+// show that gossiped eth txs that can never be valid are reported to the
+// OnInvalidTx callback
+func TestGossipHandlerEthTxsOnInvalidTx(t *testing.T) {
+	assert := assert.New(t)
+
+	key, err := crypto.GenerateKey()
+	assert.NoError(err)
+
+	addr := crypto.PubkeyToAddress(key.PublicKey)
+
+	cfgJson, err := fundAddressByGenesis([]common.Address{addr})
+	assert.NoError(err)
+
+	_, vm, _, _, sender := GenesisVM(t, true, cfgJson, "", "")
+	defer func() {
+		err := vm.Shutdown()
+		assert.NoError(err)
+	}()
+	sender.CantSendAppGossip = false
+
+	var invalidTxPeers []ids.ShortID
+	handler := &GossipHandler{
+		net: vm.network.(*pushNetwork),
+		OnInvalidTx: func(nodeID ids.ShortID, _ error) {
+			invalidTxPeers = append(invalidTxPeers, nodeID)
+		},
+	}
+	nodeID := ids.GenerateTestShortID()
+	handleTxs := func(txs []*types.Transaction) {
+		txBytes, err := rlp.EncodeToBytes(txs)
+		assert.NoError(err)
+		assert.NoError(handler.HandleEthTxs(nodeID, 0, &message.EthTxs{Txs: txBytes}))
+	}
+
+	handleTxs(getValidEthTxs(key, 1, initialBaseFee))
+	assert.Empty(invalidTxPeers)
+
+	assert.NoError(handler.HandleEthTxs(nodeID, 0, &message.EthTxs{Txs: []byte("not txs")}))
+	assert.Equal([]ids.ShortID{nodeID}, invalidTxPeers)
+
+	// A tx with a gas limit below its intrinsic gas can never be valid
+	lowGasTx, err := types.SignTx(
+		types.NewTransaction(1, common.Address{}, common.Big1, 1000, initialBaseFee, nil),
+		types.HomesteadSigner{},
+		key,
+	)
+	assert.NoError(err)
+	handleTxs([]*types.Transaction{lowGasTx})
+	assert.Equal([]ids.ShortID{nodeID, nodeID}, invalidTxPeers)
+}
+
+// show that gossiped eth txs of a type that is not yet activated are dropped
+// with a distinct reason and reported to the OnInvalidTx callback
+func TestGossipHandlerEthTxsTypeNotActivated(t *testing.T) {
+	assert := assert.New(t)
+
+	key, err := crypto.GenerateKey()
+	assert.NoError(err)
+
+	// Dynamic fee txs are activated in ApricotPhase3
+	_, vm, _, _, sender := GenesisVM(t, true, genesisJSONApricotPhase2, "", "")
+	defer func() {
+		err := vm.Shutdown()
+		assert.NoError(err)
+	}()
+	sender.CantSendAppGossip = false
+
+	var invalidTxErrs []error
+	net := vm.network.(*pushNetwork)
+	handler := &GossipHandler{
+		net: net,
+		OnInvalidTx: func(_ ids.ShortID, err error) {
+			invalidTxErrs = append(invalidTxErrs, err)
+		},
+	}
+
+	chainID := vm.chain.BlockChain().Config().ChainID
+	tx, err := types.SignNewTx(key, types.LatestSignerForChainID(chainID), &types.DynamicFeeTx{
+		ChainID:   chainID,
+		Nonce:     0,
+		GasTipCap: common.Big1,
+		GasFeeCap: initialBaseFee,
+		Gas:       params.TxGas,
+		To:        &common.Address{},
+		Value:     common.Big1,
+	})
+	assert.NoError(err)
+	txBytes, err := rlp.EncodeToBytes([]*types.Transaction{tx})
+	assert.NoError(err)
+
+	notActivated := net.notActivatedTxs.Count()
+	assert.NoError(handler.HandleEthTxs(ids.GenerateTestShortID(), 0, &message.EthTxs{Txs: txBytes}))
+	assert.EqualValues(notActivated+1, net.notActivatedTxs.Count())
+	if assert.Len(invalidTxErrs, 1) {
+		assert.ErrorIs(invalidTxErrs[0], core.ErrTxTypeNotSupported)
+	}
+	assert.Nil(vm.chain.GetTxPool().Get(tx.Hash()))
+}
+
 func TestMempoolEthTxsRegossipSingleAccount(t *testing.T) {
 	assert := assert.New(t)
 
@@ -376,3 +788,1121 @@ func TestMempoolEthTxsRegossip(t *testing.T) {
 	// (due to the non-deterministic way pending transactions are surfaced, this can be difficult
 	// to assert as well).
 }
+
+// eth txs should not be sent to a peer whose mempool bloom contains them
+func TestMempoolBloomTargetedGossip(t *testing.T) {
+	assert := assert.New(t)
+
+	sender := &engCommon.SenderTest{T: t}
+	n := newTestPushNetwork(t, func(n *pushNetwork) {
+		n.config.MempoolBloomEnabled = true
+		n.appSender = sender
+	})
+
+	bloomPeer, otherPeer := ids.GenerateTestShortID(), ids.GenerateTestShortID()
+	for _, nodeID := range []ids.ShortID{bloomPeer, otherPeer} {
+		assert.NoError(n.Connected(nodeID, version.NewDefaultApplication(constants.PlatformName, 1, 7, 5)))
+	}
+
+	knownTx := types.NewTransaction(0, common.Address{1}, common.Big1, params.TxGas, common.Big1, nil)
+	newTx := types.NewTransaction(1, common.Address{1}, common.Big1, params.TxGas, common.Big1, nil)
+
+	// [bloomPeer] reports that it already has [knownTx]
+	bloom := newMempoolBloom()
+	bloom.add(knownTx.Hash())
+	assert.True(bloom.contains(knownTx.Hash()))
+	assert.False(bloom.contains(newTx.Hash()))
+	msgBytes, err := message.Build(&message.MempoolBloom{Bloom: bloom})
+	assert.NoError(err)
+	assert.NoError(n.AppGossip(bloomPeer, msgBytes))
+
+	sent := make(map[ids.ShortID][]common.Hash)
+	sender.SendAppGossipSpecificF = func(nodeIDs ids.ShortSet, msgBytes []byte) error {
+		msg, err := message.Parse(msgBytes)
+		assert.NoError(err)
+		ethTxsMsg, ok := msg.(*message.EthTxs)
+		if !assert.True(ok) {
+			return nil
+		}
+		txs := make([]*types.Transaction, 0)
+		assert.NoError(rlp.DecodeBytes(ethTxsMsg.Txs, &txs))
+		for nodeID := range nodeIDs {
+			for _, tx := range txs {
+				sent[nodeID] = append(sent[nodeID], tx.Hash())
+			}
+		}
+		return nil
+	}
+	assert.NoError(n.sendEthTxs([]*types.Transaction{knownTx, newTx}))
+	assert.Equal([]common.Hash{newTx.Hash()}, sent[bloomPeer])
+	assert.Equal([]common.Hash{knownTx.Hash(), newTx.Hash()}, sent[otherPeer])
+
+	// Once the bloom expires, txs are gossiped to all peers
+	n.peerBlooms[bloomPeer] = peerBloom{bloom: bloom, expiry: time.Now().Add(-time.Second)}
+	gossiped := 0
+	sender.SendAppGossipF = func([]byte) error {
+		gossiped++
+		return nil
+	}
+	assert.NoError(n.sendEthTxs([]*types.Transaction{knownTx, newTx}))
+	assert.Equal(1, gossiped)
+	assert.Len(sent[bloomPeer], 1)
+}
+
+// eth txs acknowledged by a peer should not be sent to it again
+func TestTxsAckUpdatesPeerKnownTxs(t *testing.T) {
+	assert := assert.New(t)
+
+	sender := &engCommon.SenderTest{T: t}
+	n := newTestPushNetwork(t, func(n *pushNetwork) {
+		n.appSender = sender
+	})
+
+	ackPeer, otherPeer := ids.GenerateTestShortID(), ids.GenerateTestShortID()
+	for _, nodeID := range []ids.ShortID{ackPeer, otherPeer} {
+		n.peerVersions[nodeID] = message.Version1
+	}
+
+	knownTx := types.NewTransaction(0, common.Address{1}, common.Big1, params.TxGas, common.Big1, nil)
+	newTx := types.NewTransaction(1, common.Address{1}, common.Big1, params.TxGas, common.Big1, nil)
+
+	// [ackPeer] acknowledges that it added [knownTx] to its mempool
+	msgBytes, err := message.Build(&message.TxsAck{Hashes: []common.Hash{knownTx.Hash()}})
+	assert.NoError(err)
+	assert.NoError(n.AppGossip(ackPeer, msgBytes))
+	if assert.Contains(n.peerKnownTxs, ackPeer) {
+		_, has := n.peerKnownTxs[ackPeer].Get(knownTx.Hash())
+		assert.True(has)
+	}
+
+	// Acks from peers that are not connected are ignored
+	assert.NoError(n.AppGossip(ids.GenerateTestShortID(), msgBytes))
+	assert.Len(n.peerKnownTxs, 1)
+
+	sent := make(map[ids.ShortID][]common.Hash)
+	sender.SendAppGossipSpecificF = func(nodeIDs ids.ShortSet, msgBytes []byte) error {
+		msg, err := message.Parse(msgBytes)
+		assert.NoError(err)
+		ethTxsMsg, ok := msg.(*message.EthTxs)
+		if !assert.True(ok) {
+			return nil
+		}
+		txs := make([]*types.Transaction, 0)
+		assert.NoError(rlp.DecodeBytes(ethTxsMsg.Txs, &txs))
+		for nodeID := range nodeIDs {
+			for _, tx := range txs {
+				sent[nodeID] = append(sent[nodeID], tx.Hash())
+			}
+		}
+		return nil
+	}
+	assert.NoError(n.sendEthTxs([]*types.Transaction{knownTx, newTx}))
+	assert.Equal([]common.Hash{newTx.Hash()}, sent[ackPeer])
+	assert.Equal([]common.Hash{knownTx.Hash(), newTx.Hash()}, sent[otherPeer])
+
+	// The known txs of a peer are forgotten when it disconnects
+	assert.NoError(n.Disconnected(ackPeer))
+	assert.Empty(n.peerKnownTxs)
+}
+
+// eth txs should be sent to a random subset of the peers when a gossip fanout
+// is configured
+func TestGossipFanout(t *testing.T) {
+	assert := assert.New(t)
+
+	sender := &engCommon.SenderTest{T: t}
+	n := newTestPushNetwork(t, func(n *pushNetwork) {
+		n.config.GossipFanout = 2
+		n.appSender = sender
+	})
+
+	peers := ids.NewShortSet(9)
+	for i := 0; i < 9; i++ {
+		nodeID := ids.GenerateTestShortID()
+		peers.Add(nodeID)
+		n.peerVersions[nodeID] = message.Version1
+	}
+
+	var sampled []ids.ShortSet
+	sender.SendAppGossipSpecificF = func(nodeIDs ids.ShortSet, msgBytes []byte) error {
+		sampled = append(sampled, nodeIDs)
+		return nil
+	}
+	tx := types.NewTransaction(0, common.Address{1}, common.Big1, params.TxGas, common.Big1, nil)
+
+	// The subset holds the square root of the number of peers
+	assert.NoError(n.sendEthTxs([]*types.Transaction{tx}))
+	// The fanout is a lower bound on the size of the subset
+	n.config.GossipFanout = 5
+	assert.NoError(n.sendEthTxs([]*types.Transaction{tx}))
+
+	if assert.Len(sampled, 2) {
+		assert.Equal(3, sampled[0].Len())
+		assert.Equal(5, sampled[1].Len())
+		for _, nodeIDs := range sampled {
+			for nodeID := range nodeIDs {
+				assert.True(peers.Contains(nodeID))
+			}
+		}
+	}
+
+	// The subset never holds more than the connected peers
+	assert.Equal(9, gossipFanoutSize(9, 20))
+	assert.Equal(0, gossipFanoutSize(0, 2))
+}
+
+// show that pending eth txs are gossiped again by the admin API even though
+// they were recently gossiped
+func TestRegossipPendingTxs(t *testing.T) {
+	assert := assert.New(t)
+
+	key, err := crypto.GenerateKey()
+	assert.NoError(err)
+
+	addr := crypto.PubkeyToAddress(key.PublicKey)
+
+	cfgJson, err := fundAddressByGenesis([]common.Address{addr})
+	assert.NoError(err)
+
+	_, vm, _, _, sender := GenesisVM(t, true, cfgJson, "", "")
+	defer func() {
+		err := vm.Shutdown()
+		assert.NoError(err)
+	}()
+
+	gossiped := make(chan []common.Hash, 2)
+	sender.CantSendAppGossip = false
+	sender.SendAppGossipF = func(gossipedBytes []byte) error {
+		notifyMsgIntf, err := message.Parse(gossipedBytes)
+		assert.NoError(err)
+
+		requestMsg, ok := notifyMsgIntf.(*message.EthTxs)
+		assert.True(ok)
+
+		txs := make([]*types.Transaction, 0)
+		assert.NoError(rlp.DecodeBytes(requestMsg.Txs, &txs))
+		hashes := make([]common.Hash, 0, len(txs))
+		for _, tx := range txs {
+			hashes = append(hashes, tx.Hash())
+		}
+		gossiped <- hashes
+		return nil
+	}
+	awaitGossip := func() []common.Hash {
+		select {
+		case hashes := <-gossiped:
+			return hashes
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for eth txs to be gossiped")
+			return nil
+		}
+	}
+
+	ethTxs := getValidEthTxs(key, 1, initialBaseFee)
+	txHash := ethTxs[0].Hash()
+
+	errs := vm.chain.GetTxPool().AddRemotesSync(ethTxs)
+	assert.NoError(errs[0], "failed adding coreth tx to mempool")
+	assert.Equal([]common.Hash{txHash}, awaitGossip())
+
+	admin := NewAdminService(vm, t.TempDir())
+	reply := &RegossipPendingTxsReply{}
+	assert.NoError(admin.RegossipPendingTxs(nil, nil, reply))
+	assert.Equal(1, reply.EthTxs)
+	assert.Equal(0, reply.AtomicTxs)
+	assert.Equal([]common.Hash{txHash}, awaitGossip())
+}
+
+// show that eth txs larger than the max gossip tx size are not gossiped
+func TestMempoolEthTxsOversizedNotGossiped(t *testing.T) {
+	assert := assert.New(t)
+
+	key, err := crypto.GenerateKey()
+	assert.NoError(err)
+
+	addr := crypto.PubkeyToAddress(key.PublicKey)
+
+	cfgJson, err := fundAddressByGenesis([]common.Address{addr})
+	assert.NoError(err)
+
+	_, vm, _, _, sender := GenesisVM(t, true, cfgJson, `{"max-gossip-tx-size": 500}`, "")
+	defer func() {
+		err := vm.Shutdown()
+		assert.NoError(err)
+	}()
+
+	oversizedTxs := metrics.NewCounterForced()
+	vm.network.(*pushNetwork).oversizedTxs = oversizedTxs
+
+	gossiped := make(chan []common.Hash, 2)
+	sender.CantSendAppGossip = false
+	sender.SendAppGossipF = func(gossipedBytes []byte) error {
+		notifyMsgIntf, err := message.Parse(gossipedBytes)
+		assert.NoError(err)
+
+		requestMsg, ok := notifyMsgIntf.(*message.EthTxs)
+		assert.True(ok)
+
+		txs := make([]*types.Transaction, 0)
+		assert.NoError(rlp.DecodeBytes(requestMsg.Txs, &txs))
+		hashes := make([]common.Hash, 0, len(txs))
+		for _, tx := range txs {
+			hashes = append(hashes, tx.Hash())
+		}
+		gossiped <- hashes
+		return nil
+	}
+
+	// [smallTx] has no calldata, while the txs returned by [getValidEthTxs]
+	// carry 1000 bytes of calldata.
+	smallTx, err := types.SignTx(
+		types.NewTransaction(0, common.Address{}, big.NewInt(10000), params.TxGas, initialBaseFee, nil),
+		types.HomesteadSigner{}, key)
+	assert.NoError(err)
+	largeTx := getValidEthTxs(key, 2, initialBaseFee)[1]
+	assert.Less(float64(smallTx.Size()), float64(500))
+	assert.Greater(float64(largeTx.Size()), float64(500))
+
+	errs := vm.chain.GetTxPool().AddRemotesSync([]*types.Transaction{smallTx, largeTx})
+	for _, err := range errs {
+		assert.NoError(err, "failed adding coreth tx to mempool")
+	}
+
+	select {
+	case hashes := <-gossiped:
+		assert.Equal([]common.Hash{smallTx.Hash()}, hashes)
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for eth txs to be gossiped")
+	}
+	assert.Eventually(func() bool {
+		return oversizedTxs.Count() == 1
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestMempoolEthTxsBelowMinGasPriceNotGossiped(t *testing.T) {
+	assert := assert.New(t)
+
+	remoteKey, err := crypto.GenerateKey()
+	assert.NoError(err)
+	localKey, err := crypto.GenerateKey()
+	assert.NoError(err)
+
+	cfgJson, err := fundAddressByGenesis([]common.Address{
+		crypto.PubkeyToAddress(remoteKey.PublicKey),
+		crypto.PubkeyToAddress(localKey.PublicKey),
+	})
+	assert.NoError(err)
+
+	lowGasPrice := initialBaseFee
+	minGasPrice := new(big.Int).Mul(initialBaseFee, big.NewInt(2))
+	highGasPrice := new(big.Int).Mul(initialBaseFee, big.NewInt(3))
+	_, vm, _, _, sender := GenesisVM(t, true, cfgJson, fmt.Sprintf(`{"min-gossip-gas-price": %d}`, minGasPrice), "")
+	defer func() {
+		err := vm.Shutdown()
+		assert.NoError(err)
+	}()
+
+	lowGasPriceTxs := metrics.NewCounterForced()
+	vm.network.(*pushNetwork).lowGasPriceTxs = lowGasPriceTxs
+
+	gossiped := make(chan []common.Hash, 2)
+	sender.CantSendAppGossip = false
+	sender.SendAppGossipF = func(gossipedBytes []byte) error {
+		notifyMsgIntf, err := message.Parse(gossipedBytes)
+		assert.NoError(err)
+
+		requestMsg, ok := notifyMsgIntf.(*message.EthTxs)
+		assert.True(ok)
+
+		txs := make([]*types.Transaction, 0)
+		assert.NoError(rlp.DecodeBytes(requestMsg.Txs, &txs))
+		hashes := make([]common.Hash, 0, len(txs))
+		for _, tx := range txs {
+			hashes = append(hashes, tx.Hash())
+		}
+		gossiped <- hashes
+		return nil
+	}
+
+	lowTx := getValidEthTxs(remoteKey, 2, lowGasPrice)[0]
+	highTx := getValidEthTxs(remoteKey, 2, highGasPrice)[1]
+	localLowTx := getValidEthTxs(localKey, 1, lowGasPrice)[0]
+
+	txPool := vm.chain.GetTxPool()
+	for _, err := range txPool.AddRemotesSync([]*types.Transaction{lowTx, highTx}) {
+		assert.NoError(err, "failed adding coreth tx to mempool")
+	}
+	assert.NoError(txPool.AddLocal(localLowTx))
+
+	// Txs issued to this node are gossiped even if their gas price is low
+	expected := map[common.Hash]bool{highTx.Hash(): true, localLowTx.Hash(): true}
+	for len(expected) > 0 {
+		select {
+		case hashes := <-gossiped:
+			for _, hash := range hashes {
+				assert.True(expected[hash], "unexpected tx %s gossiped", hash)
+				delete(expected, hash)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for eth txs to be gossiped")
+		}
+	}
+	assert.Eventually(func() bool {
+		return lowGasPriceTxs.Count() == 1
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+// show that buffered eth txs are gossiped before the next gossip cycle once
+// they exceed [MaxPendingGossipBytes]
+func TestMempoolEthTxsMaxPendingGossipBytes(t *testing.T) {
+	assert := assert.New(t)
+
+	key, err := crypto.GenerateKey()
+	assert.NoError(err)
+
+	cfgJson, err := fundAddressByGenesis([]common.Address{crypto.PubkeyToAddress(key.PublicKey)})
+	assert.NoError(err)
+
+	_, vm, _, _, vmSender := GenesisVM(t, true, cfgJson, "", "")
+	defer func() {
+		err := vm.Shutdown()
+		assert.NoError(err)
+	}()
+	vmSender.CantSendAppGossip = false
+	vm.chain.GetTxPool().SetGasPrice(common.Big1)
+	vm.chain.GetTxPool().SetMinFee(common.Big0)
+
+	ethTxs := getValidEthTxs(key, 3, common.Big1)
+	for _, err := range vm.chain.GetTxPool().AddRemotesSync(ethTxs) {
+		assert.NoError(err)
+	}
+
+	// Gossip with a network that is not driven by the VM, so that the txs are
+	// only gossiped by the test.
+	var gossiped int
+	sender := &engCommon.SenderTest{T: t}
+	sender.SendAppGossipF = func([]byte) error {
+		gossiped++
+		return nil
+	}
+	n := newTestPushNetwork(t, func(n *pushNetwork) {
+		n.config.MaxPendingGossipBytes = int(ethTxs[0].Size() + ethTxs[1].Size())
+		n.appSender = sender
+		n.chain = vm.chain
+		n.peerVersions[ids.GenerateTestShortID()] = message.CurrentVersion
+		n.lastGossiped = time.Now()
+	})
+
+	// The buffer is not gossiped before the next gossip cycle while it holds
+	// at most [MaxPendingGossipBytes]
+	for _, tx := range ethTxs[:2] {
+		n.bufferEthTx(tx)
+	}
+	attempted, err := n.gossipEthTxs(false)
+	assert.NoError(err)
+	assert.Zero(attempted)
+	assert.Zero(gossiped)
+	assert.EqualValues(ethTxs[0].Size()+ethTxs[1].Size(), n.pendingGossipBytes.Value())
+
+	// Once the buffer exceeds [MaxPendingGossipBytes], it is gossiped early
+	n.bufferEthTx(ethTxs[2])
+	attempted, err = n.gossipEthTxs(false)
+	assert.NoError(err)
+	assert.Equal(len(ethTxs), attempted)
+	assert.Equal(1, gossiped)
+	assert.Empty(n.ethTxsToGossip)
+	assert.Zero(n.pendingGossipBytes.Value())
+}
+
+// show that a single eth tx is gossiped right away with one message, and that
+// recently gossiped txs are not gossiped again
+func TestMempoolEthTxGossipSingle(t *testing.T) {
+	assert := assert.New(t)
+
+	key, err := crypto.GenerateKey()
+	assert.NoError(err)
+
+	cfgJson, err := fundAddressByGenesis([]common.Address{crypto.PubkeyToAddress(key.PublicKey)})
+	assert.NoError(err)
+
+	_, vm, _, _, vmSender := GenesisVM(t, true, cfgJson, "", "")
+	defer func() {
+		err := vm.Shutdown()
+		assert.NoError(err)
+	}()
+	vmSender.CantSendAppGossip = false
+	vm.chain.GetTxPool().SetGasPrice(common.Big1)
+	vm.chain.GetTxPool().SetMinFee(common.Big0)
+
+	ethTxs := getValidEthTxs(key, 2, common.Big1)
+	for _, err := range vm.chain.GetTxPool().AddRemotesSync(ethTxs) {
+		assert.NoError(err)
+	}
+
+	// Gossip with a network that is not driven by the VM, so that the txs are
+	// only gossiped by the test.
+	var gossiped [][]common.Hash
+	sender := &engCommon.SenderTest{T: t}
+	sender.SendAppGossipF = func(msgBytes []byte) error {
+		msg, err := message.Parse(msgBytes)
+		assert.NoError(err)
+		ethTxsMsg, ok := msg.(*message.EthTxs)
+		assert.True(ok)
+
+		txs := make([]*types.Transaction, 0)
+		assert.NoError(rlp.DecodeBytes(ethTxsMsg.Txs, &txs))
+		hashes := make([]common.Hash, 0, len(txs))
+		for _, tx := range txs {
+			hashes = append(hashes, tx.Hash())
+		}
+		gossiped = append(gossiped, hashes)
+		return nil
+	}
+	n := newTestPushNetwork(t, func(n *pushNetwork) {
+		n.appSender = sender
+		n.chain = vm.chain
+		n.peerVersions[ids.GenerateTestShortID()] = message.CurrentVersion
+	})
+
+	assert.NoError(n.GossipEthTx(ethTxs[0]))
+	assert.Equal([][]common.Hash{{ethTxs[0].Hash()}}, gossiped)
+
+	// The tx was recently gossiped, so it is not gossiped again
+	assert.NoError(n.GossipEthTx(ethTxs[0]))
+	assert.Len(gossiped, 1)
+
+	// Txs recently gossiped in a batch are not gossiped again either
+	n.recentEthTxs.Put(ethTxs[1].Hash(), &recentEthTx{gossiped: true})
+	assert.NoError(n.GossipEthTx(ethTxs[1]))
+	assert.Len(gossiped, 1)
+}
+
+// show that a peer announcing eth tx hashes is requested the txs that are not
+// in the tx pool, and that the txs it responds with are added to the tx pool
+func TestMempoolEthTxHashesAnnouncement(t *testing.T) {
+	assert := assert.New(t)
+
+	key, err := crypto.GenerateKey()
+	assert.NoError(err)
+
+	cfgJson, err := fundAddressByGenesis([]common.Address{crypto.PubkeyToAddress(key.PublicKey)})
+	assert.NoError(err)
+
+	_, announcer, _, _, announcerSender := GenesisVM(t, true, cfgJson, `{"eth-tx-announcements-enabled": true}`, "")
+	defer func() {
+		err := announcer.Shutdown()
+		assert.NoError(err)
+	}()
+	_, receiver, _, _, receiverSender := GenesisVM(t, true, cfgJson, "", "")
+	defer func() {
+		err := receiver.Shutdown()
+		assert.NoError(err)
+	}()
+	for _, vm := range []*VM{announcer, receiver} {
+		vm.chain.GetTxPool().SetGasPrice(common.Big1)
+		vm.chain.GetTxPool().SetMinFee(common.Big0)
+	}
+
+	// The announcer only gossips the hashes of its txs
+	var (
+		wg        sync.WaitGroup
+		announced bool
+	)
+	wg.Add(1)
+	announcerSender.SendAppGossipF = func(msgBytes []byte) error {
+		if announced {
+			return nil
+		}
+		announced = true
+		defer wg.Done()
+
+		msg, err := message.Parse(msgBytes)
+		assert.NoError(err)
+		_, ok := msg.(*message.EthTxHashes)
+		assert.True(ok, "expected the tx hashes to be gossiped")
+		return nil
+	}
+	ethTxs := getValidEthTxs(key, 3, common.Big1)
+	for _, err := range announcer.chain.GetTxPool().AddRemotesSync(ethTxs) {
+		assert.NoError(err)
+	}
+	attemptAwait(t, &wg, 5*time.Second)
+
+	// The receiver already has the first tx, so it only requests the others
+	receiverSender.CantSendAppGossip = false
+	for _, err := range receiver.chain.GetTxPool().AddRemotesSync(ethTxs[:1]) {
+		assert.NoError(err)
+	}
+	var (
+		requestID    uint32
+		requestBytes []byte
+	)
+	receiverSender.SendAppRequestF = func(nodeIDs ids.ShortSet, reqID uint32, msgBytes []byte) error {
+		assert.True(nodeIDs.Contains(testPeerID))
+		requestID = reqID
+		requestBytes = msgBytes
+		return nil
+	}
+	hashes := []common.Hash{ethTxs[0].Hash(), ethTxs[1].Hash(), ethTxs[2].Hash()}
+	msgBytes, err := message.BuildWithVersion(&message.EthTxHashes{Hashes: hashes}, message.Version1)
+	assert.NoError(err)
+	assert.NoError(receiver.AppGossip(testPeerID, msgBytes))
+
+	msg, err := message.Parse(requestBytes)
+	assert.NoError(err)
+	request, ok := msg.(*message.EthTxsRequest)
+	assert.True(ok)
+	assert.Equal(hashes[1:], request.Hashes)
+
+	// The announcer responds with the requested txs
+	var responseBytes []byte
+	announcerSender.SendAppResponseF = func(nodeID ids.ShortID, reqID uint32, msgBytes []byte) error {
+		assert.Equal(testPeerID, nodeID)
+		assert.Equal(requestID, reqID)
+		responseBytes = msgBytes
+		return nil
+	}
+	assert.NoError(announcer.AppRequest(testPeerID, requestID, time.Now().Add(time.Minute), requestBytes))
+
+	assert.NoError(receiver.AppResponse(testPeerID, requestID, responseBytes))
+	for _, tx := range ethTxs {
+		assert.True(receiver.chain.GetTxPool().Has(tx.Hash()), "tx should be in the tx pool")
+	}
+}
+
+// sending eth txs should be retried when the app sender fails, and txs that
+// could not be sent should be queued to be gossiped again
+func TestSendEthTxsRetries(t *testing.T) {
+	assert := assert.New(t)
+
+	sender := &engCommon.SenderTest{T: t}
+	n := newTestPushNetwork(t, func(n *pushNetwork) {
+		n.appSender = sender
+	})
+	n.peerVersions[ids.GenerateTestShortID()] = message.Version1
+
+	txs := []*types.Transaction{
+		types.NewTransaction(0, common.Address{1}, common.Big1, params.TxGas, common.Big1, nil),
+		types.NewTransaction(1, common.Address{1}, common.Big1, params.TxGas, common.Big1, nil),
+	}
+	for _, tx := range txs {
+		n.recentEthTxs.Put(tx.Hash(), nil)
+	}
+
+	// The first attempt fails, but the retry succeeds
+	var (
+		attempts int
+		sent     []common.Hash
+	)
+	errSend := errors.New("send failed")
+	sender.SendAppGossipF = func(msgBytes []byte) error {
+		attempts++
+		if attempts == 1 {
+			return errSend
+		}
+		msg, err := message.Parse(msgBytes)
+		assert.NoError(err)
+		ethTxsMsg, ok := msg.(*message.EthTxs)
+		if !assert.True(ok) {
+			return nil
+		}
+		sentTxs := make([]*types.Transaction, 0)
+		assert.NoError(rlp.DecodeBytes(ethTxsMsg.Txs, &sentTxs))
+		for _, tx := range sentTxs {
+			sent = append(sent, tx.Hash())
+		}
+		return nil
+	}
+	assert.NoError(n.sendEthTxs(txs))
+	assert.Equal(2, attempts)
+	assert.Equal([]common.Hash{txs[0].Hash(), txs[1].Hash()}, sent)
+	assert.Empty(n.pendingEthTxs)
+
+	// Every attempt fails, so the txs are queued and forgotten
+	attempts = 0
+	sender.SendAppGossipF = func([]byte) error {
+		attempts++
+		return errSend
+	}
+	assert.ErrorIs(n.sendEthTxs(txs), errSend)
+	assert.Equal(ethTxsSendRetries+1, attempts)
+	assert.Len(n.pendingEthTxs, 2)
+	assert.Zero(n.recentEthTxs.Len())
+
+	// The queued txs are buffered to be retried on the next gossip tick
+	n.bufferPendingTxs()
+	assert.Empty(n.pendingEthTxs)
+	assert.Len(n.ethTxsToGossip, 2)
+}
+
+// eth txs should be gossiped with the sender of the channel that they are
+// routed to, and with the VM's sender if they are not routed to a channel
+func TestSendEthTxsGossipChannels(t *testing.T) {
+	assert := assert.New(t)
+
+	// recordSender returns a sender that records the txs that it gossips
+	recordSender := func(sent *[]common.Hash) *engCommon.SenderTest {
+		sender := &engCommon.SenderTest{T: t}
+		sender.SendAppGossipF = func(msgBytes []byte) error {
+			msg, err := message.Parse(msgBytes)
+			assert.NoError(err)
+			ethTxsMsg, ok := msg.(*message.EthTxs)
+			if !assert.True(ok) {
+				return nil
+			}
+			txs := make([]*types.Transaction, 0)
+			assert.NoError(rlp.DecodeBytes(ethTxsMsg.Txs, &txs))
+			for _, tx := range txs {
+				*sent = append(*sent, tx.Hash())
+			}
+			return nil
+		}
+		return sender
+	}
+	var sentA, sentB, sentDefault []common.Hash
+	n := newTestPushNetwork(t, func(n *pushNetwork) {
+		n.appSender = recordSender(&sentDefault)
+	})
+	n.peerVersions[ids.GenerateTestShortID()] = message.Version1
+
+	// Txs are routed by their recipient
+	channelA, channelB := ids.ID{'a'}, ids.ID{'b'}
+	addrA, addrB, addrDefault := common.Address{1}, common.Address{2}, common.Address{3}
+	n.SetGossipChannels(
+		map[ids.ID]engCommon.AppSender{
+			channelA: recordSender(&sentA),
+			channelB: recordSender(&sentB),
+		},
+		func(tx *types.Transaction) ids.ID {
+			switch *tx.To() {
+			case addrA:
+				return channelA
+			case addrB:
+				return channelB
+			default:
+				return ids.Empty
+			}
+		},
+	)
+
+	txs := []*types.Transaction{
+		types.NewTransaction(0, addrA, common.Big1, params.TxGas, common.Big1, nil),
+		types.NewTransaction(1, addrB, common.Big1, params.TxGas, common.Big1, nil),
+		types.NewTransaction(2, addrA, common.Big1, params.TxGas, common.Big1, nil),
+		types.NewTransaction(3, addrDefault, common.Big1, params.TxGas, common.Big1, nil),
+	}
+	assert.NoError(n.sendEthTxs(txs))
+	assert.Equal([]common.Hash{txs[0].Hash(), txs[2].Hash()}, sentA)
+	assert.Equal([]common.Hash{txs[1].Hash()}, sentB)
+	assert.Equal([]common.Hash{txs[3].Hash()}, sentDefault)
+	assert.Empty(n.pendingEthTxs)
+
+	// A failing channel does not prevent the other txs from being gossiped,
+	// and only the txs of the failing channel are requeued
+	sentA, sentDefault = nil, nil
+	errSend := errors.New("send failed")
+	failingSender := &engCommon.SenderTest{T: t}
+	failingSender.SendAppGossipF = func([]byte) error {
+		return errSend
+	}
+	n.SetGossipChannels(
+		map[ids.ID]engCommon.AppSender{
+			channelA: recordSender(&sentA),
+			channelB: failingSender,
+		},
+		n.ethTxChannel,
+	)
+	assert.ErrorIs(n.sendEthTxs(txs), errSend)
+	assert.Equal([]common.Hash{txs[0].Hash(), txs[2].Hash()}, sentA)
+	assert.Equal([]common.Hash{txs[3].Hash()}, sentDefault)
+	assert.Equal([]common.Hash{txs[1].Hash()}, n.PendingGossipQueue())
+	n.removePendingEthTxs([]common.Hash{txs[1].Hash()})
+
+	// Without channels, every tx is gossiped with the VM's sender
+	sentA, sentB, sentDefault = nil, nil, nil
+	n.SetGossipChannels(nil, nil)
+	assert.NoError(n.sendEthTxs(txs))
+	assert.Empty(sentA)
+	assert.Empty(sentB)
+	assert.Len(sentDefault, len(txs))
+}
+
+// eth txs should be gossiped according to the configured gossip mode
+func TestSendEthTxsGossipMode(t *testing.T) {
+	assert := assert.New(t)
+
+	// The network built by the VM implements the configured gossip mode
+	for config, expected := range map[string]Network{
+		"":                                       &pushNetwork{},
+		`{"gossip-mode": "push"}`:                &pushNetwork{},
+		`{"gossip-mode": "pull"}`:                &pullNetwork{},
+		`{"gossip-mode": "hybrid"}`:              &hybridNetwork{},
+		`{"eth-tx-announcements-enabled": true}`: &pullNetwork{},
+	} {
+		_, vm, _, _, _ := GenesisVM(t, true, genesisJSONApricotPhase4, config, "")
+		assert.IsType(expected, vm.network, config)
+		assert.NoError(vm.Shutdown())
+	}
+
+	txs := []*types.Transaction{
+		types.NewTransaction(0, common.Address{1}, common.Big1, params.TxGas, common.Big1, nil),
+	}
+	// sent records the peers that each type of message is sent to, where
+	// the empty set is every peer
+	newNetwork := func(mode GossipMode, sent map[string]ids.ShortSet) *pushNetwork {
+		record := func(nodeIDs ids.ShortSet, msgBytes []byte) {
+			msg, err := message.Parse(msgBytes)
+			assert.NoError(err)
+			sent[reflect.TypeOf(msg).Elem().Name()] = nodeIDs
+		}
+		sender := &engCommon.SenderTest{T: t}
+		sender.SendAppGossipF = func(msgBytes []byte) error {
+			record(ids.NewShortSet(0), msgBytes)
+			return nil
+		}
+		sender.SendAppGossipSpecificF = func(nodeIDs ids.ShortSet, msgBytes []byte) error {
+			record(nodeIDs, msgBytes)
+			return nil
+		}
+		n := newTestPushNetwork(t, func(n *pushNetwork) {
+			n.appSender = sender
+		})
+		for i := 0; i < 4; i++ {
+			n.peerVersions[ids.GenerateTestShortID()] = message.Version1
+		}
+		withGossipMode(n, mode)
+		return n
+	}
+
+	sent := make(map[string]ids.ShortSet)
+	assert.NoError(newNetwork(GossipModePush, sent).sendEthTxs(txs))
+	assert.Equal(map[string]ids.ShortSet{"EthTxs": ids.NewShortSet(0)}, sent)
+
+	sent = make(map[string]ids.ShortSet)
+	assert.NoError(newNetwork(GossipModePull, sent).sendEthTxs(txs))
+	assert.Equal(map[string]ids.ShortSet{"EthTxHashes": ids.NewShortSet(0)}, sent)
+
+	// The txs are pushed to the square root of the peers, and the other peers
+	// are sent their hashes
+	sent = make(map[string]ids.ShortSet)
+	n := newNetwork(GossipModeHybrid, sent)
+	assert.NoError(n.sendEthTxs(txs))
+	assert.Len(sent, 2)
+	assert.Equal(2, sent["EthTxs"].Len())
+	assert.Equal(2, sent["EthTxHashes"].Len())
+	for nodeID := range n.peerVersions {
+		assert.True(sent["EthTxs"].Contains(nodeID) != sent["EthTxHashes"].Contains(nodeID))
+	}
+
+	// Peers that can't request txs are always pushed the txs
+	sent = make(map[string]ids.ShortSet)
+	n = newNetwork(GossipModePull, sent)
+	n.peerVersions[ids.GenerateTestShortID()] = message.Version0
+	assert.NoError(n.sendEthTxs(txs))
+	assert.Equal(map[string]ids.ShortSet{"EthTxs": ids.NewShortSet(0)}, sent)
+}
+
+// eth txs buffered until the next gossip interval should be gossiped when the
+// VM is shut down
+func TestShutdownFlushesBufferedEthTxs(t *testing.T) {
+	assert := assert.New(t)
+
+	key, err := crypto.GenerateKey()
+	assert.NoError(err)
+
+	addr := crypto.PubkeyToAddress(key.PublicKey)
+
+	cfgJson, err := fundAddressByGenesis([]common.Address{addr})
+	assert.NoError(err)
+
+	_, vm, _, _, sender := GenesisVM(t, true, cfgJson, "", "")
+
+	var (
+		lock     sync.Mutex
+		gossiped = make(map[common.Hash]bool)
+	)
+	sender.CantSendAppGossip = false
+	sender.SendAppGossipF = func(msgBytes []byte) error {
+		msg, err := message.Parse(msgBytes)
+		assert.NoError(err)
+		ethTxsMsg, ok := msg.(*message.EthTxs)
+		if !ok {
+			return nil
+		}
+		txs := make([]*types.Transaction, 0)
+		assert.NoError(rlp.DecodeBytes(ethTxsMsg.Txs, &txs))
+
+		lock.Lock()
+		defer lock.Unlock()
+		for _, tx := range txs {
+			gossiped[tx.Hash()] = true
+		}
+		return nil
+	}
+	isGossiped := func(hash common.Hash) bool {
+		lock.Lock()
+		defer lock.Unlock()
+		return gossiped[hash]
+	}
+
+	txPool := vm.chain.GetTxPool()
+	ethTxs := getValidEthTxs(key, 2, initialBaseFee)
+
+	// The first tx is gossiped right away, so the second tx is buffered until
+	// the next gossip interval
+	errs := txPool.AddRemotesSync(ethTxs[:1])
+	assert.NoError(errs[0])
+	assert.Eventually(func() bool {
+		return isGossiped(ethTxs[0].Hash())
+	}, 5*time.Second, 10*time.Millisecond)
+	errs = txPool.AddRemotesSync(ethTxs[1:])
+	assert.NoError(errs[0])
+	// The tx pool notifies the network of the tx asynchronously, so make sure
+	// that it is buffered before shutting down
+	assert.NoError(vm.network.GossipEthTxs(ethTxs[1:]))
+
+	assert.NoError(vm.Shutdown())
+	assert.True(isGossiped(ethTxs[1].Hash()))
+}
+
+// eth txs received from a peer should not be gossiped back to it
+func TestMempoolEthTxsNotGossipedToSource(t *testing.T) {
+	assert := assert.New(t)
+
+	key, err := crypto.GenerateKey()
+	assert.NoError(err)
+
+	addr := crypto.PubkeyToAddress(key.PublicKey)
+
+	cfgJson, err := fundAddressByGenesis([]common.Address{addr})
+	assert.NoError(err)
+
+	_, vm, _, _, sender := GenesisVM(t, true, cfgJson, "", "")
+	defer func() {
+		err := vm.Shutdown()
+		assert.NoError(err)
+	}()
+	vm.chain.GetTxPool().SetGasPrice(common.Big1)
+	vm.chain.GetTxPool().SetMinFee(common.Big0)
+
+	sourcePeer, otherPeer := ids.GenerateTestShortID(), ids.GenerateTestShortID()
+	for _, nodeID := range []ids.ShortID{sourcePeer, otherPeer} {
+		assert.NoError(vm.Connected(nodeID, version.NewDefaultApplication(constants.PlatformName, 1, 7, 5)))
+	}
+
+	var (
+		lock sync.Mutex
+		sent = make(map[ids.ShortID][]common.Hash)
+	)
+	sender.CantSendAppGossip = false
+	sender.SendAppGossipSpecificF = func(nodeIDs ids.ShortSet, msgBytes []byte) error {
+		msg, err := message.Parse(msgBytes)
+		assert.NoError(err)
+		ethTxsMsg, ok := msg.(*message.EthTxs)
+		if !ok {
+			return nil
+		}
+		txs := make([]*types.Transaction, 0)
+		assert.NoError(rlp.DecodeBytes(ethTxsMsg.Txs, &txs))
+
+		lock.Lock()
+		defer lock.Unlock()
+		for nodeID := range nodeIDs {
+			for _, tx := range txs {
+				sent[nodeID] = append(sent[nodeID], tx.Hash())
+			}
+		}
+		return nil
+	}
+
+	ethTxs := getValidEthTxs(key, 1, common.Big1)
+	txHash := ethTxs[0].Hash()
+	txBytes, err := rlp.EncodeToBytes(ethTxs)
+	assert.NoError(err)
+	msgBytes, err := message.Build(&message.EthTxs{Txs: txBytes})
+	assert.NoError(err)
+	assert.NoError(vm.AppGossip(sourcePeer, msgBytes))
+	assert.True(vm.chain.GetTxPool().Has(txHash))
+
+	assert.Eventually(func() bool {
+		lock.Lock()
+		defer lock.Unlock()
+		return len(sent[otherPeer]) > 0
+	}, 5*time.Second, 10*time.Millisecond)
+
+	lock.Lock()
+	defer lock.Unlock()
+	assert.Equal([]common.Hash{txHash}, sent[otherPeer])
+	assert.Empty(sent[sourcePeer])
+	assert.Equal(sourcePeer, vm.network.(*pushNetwork).ethTxSource(txHash))
+}
+
+// show that gossiped eth txs from a single sender beyond
+// [MaxGossipTxsPerSender] are not added to the mempool
+func TestMempoolEthTxsGossipMaxTxsPerSender(t *testing.T) {
+	assert := assert.New(t)
+
+	key, err := crypto.GenerateKey()
+	assert.NoError(err)
+
+	addr := crypto.PubkeyToAddress(key.PublicKey)
+
+	cfgJson, err := fundAddressByGenesis([]common.Address{addr})
+	assert.NoError(err)
+
+	_, vm, _, _, _ := GenesisVM(t, true, cfgJson, `{"max-gossip-txs-per-sender": 16}`, "")
+	defer func() {
+		err := vm.Shutdown()
+		assert.NoError(err)
+	}()
+	txPool := vm.chain.GetTxPool()
+	txPool.SetGasPrice(common.Big1)
+	txPool.SetMinFee(common.Big0)
+
+	ethTxs := getValidEthTxs(key, 100, common.Big1)
+	txBytes, err := rlp.EncodeToBytes(ethTxs)
+	assert.NoError(err)
+	msgBytes, err := message.Build(&message.EthTxs{Txs: txBytes})
+	assert.NoError(err)
+
+	assert.NoError(vm.AppGossip(ids.GenerateTestShortID(), msgBytes))
+	for i, tx := range ethTxs {
+		assert.Equal(i < 16, txPool.Has(tx.Hash()), "unexpected presence of tx %d in the mempool", i)
+	}
+}
+
+func TestMempoolEthTxsGossipMaxTxsPerMessage(t *testing.T) {
+	assert := assert.New(t)
+
+	key, err := crypto.GenerateKey()
+	assert.NoError(err)
+
+	addr := crypto.PubkeyToAddress(key.PublicKey)
+
+	cfgJson, err := fundAddressByGenesis([]common.Address{addr})
+	assert.NoError(err)
+
+	_, vm, _, _, _ := GenesisVM(t, true, cfgJson, "", "")
+	defer func() {
+		err := vm.Shutdown()
+		assert.NoError(err)
+	}()
+	txPool := vm.chain.GetTxPool()
+	txPool.SetGasPrice(common.Big1)
+	txPool.SetMinFee(common.Big0)
+	net, ok := vm.network.(*pushNetwork)
+	assert.True(ok)
+
+	ethTxs := getValidEthTxs(key, maxEthTxsPerMessage+1, common.Big1)
+	gossip := func(txs []*types.Transaction) {
+		txBytes, err := rlp.EncodeToBytes(txs)
+		assert.NoError(err)
+		msgBytes, err := message.Build(&message.EthTxs{Txs: txBytes})
+		assert.NoError(err)
+		assert.NoError(vm.AppGossip(ids.GenerateTestShortID(), msgBytes))
+	}
+
+	// A message over the cap is dropped entirely
+	dropped := net.tooManyTxsMsgs.Count()
+	gossip(ethTxs)
+	assert.Equal(dropped+1, net.tooManyTxsMsgs.Count())
+	for i, tx := range ethTxs {
+		assert.False(txPool.Has(tx.Hash()), "tx %d from an oversized message should not be in the mempool", i)
+	}
+
+	// A message at the cap is handled
+	gossip(ethTxs[:maxEthTxsPerMessage])
+	assert.Equal(dropped+1, net.tooManyTxsMsgs.Count())
+	for i, tx := range ethTxs[:maxEthTxsPerMessage] {
+		assert.True(txPool.Has(tx.Hash()), "tx %d should be in the mempool", i)
+	}
+}
+
+func TestHandleEthTxsBoundsPayload(t *testing.T) {
+	assert := assert.New(t)
+
+	n := newTestPushNetwork(t)
+	var invalidTxPeers []ids.ShortID
+	handler := &GossipHandler{
+		net: n,
+		OnInvalidTx: func(nodeID ids.ShortID, _ error) {
+			invalidTxPeers = append(invalidTxPeers, nodeID)
+		},
+	}
+	nodeID := ids.GenerateTestShortID()
+
+	// A payload larger than an honest peer could send is dropped before it is
+	// decoded
+	oversized := make([]byte, maxEthTxsMsgSize+1)
+	assert.NoError(handler.HandleEthTxs(nodeID, 0, &message.EthTxs{Txs: oversized}))
+	assert.EqualValues(1, n.oversizedMsgs.Count())
+	assert.Empty(invalidTxPeers)
+
+	// A payload of tiny txs fits in the size limit, but decodes into too many
+	// txs
+	txs := make([]*types.Transaction, maxEthTxsPerMessage+1)
+	for i := range txs {
+		txs[i] = types.NewTransaction(uint64(i), common.Address{}, common.Big0, 0, common.Big0, nil)
+	}
+	txBytes, err := rlp.EncodeToBytes(txs)
+	assert.NoError(err)
+	assert.LessOrEqual(len(txBytes), maxEthTxsMsgSize)
+	assert.NoError(handler.HandleEthTxs(nodeID, 0, &message.EthTxs{Txs: txBytes}))
+	assert.EqualValues(1, n.oversizedMsgs.Count())
+	assert.EqualValues(1, n.tooManyTxsMsgs.Count())
+	assert.Empty(invalidTxPeers)
+}
+
+// BenchmarkGossipEthTxs measures selecting and gossiping a large batch of eth
+// txs, whose statuses are looked up in the tx pool with a single call.
+func BenchmarkGossipEthTxs(b *testing.B) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		b.Fatal(err)
+	}
+	addr := crypto.PubkeyToAddress(key.PublicKey)
+	cfgJson, err := fundAddressByGenesis([]common.Address{addr})
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	_, vm, _, _, _ := GenesisVM(b, true, cfgJson, "", "")
+	defer func() {
+		if err := vm.Shutdown(); err != nil {
+			b.Fatal(err)
+		}
+	}()
+	vm.chain.GetTxPool().SetGasPrice(common.Big1)
+	vm.chain.GetTxPool().SetMinFee(common.Big0)
+
+	ethTxs := getValidEthTxs(key, 1000, common.Big1)
+	for _, err := range vm.chain.GetTxPool().AddRemotesSync(ethTxs) {
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+
+	// Gossip with a network that is not driven by the VM, so that the txs are
+	// only gossiped by the benchmark.
+	sender := &engCommon.SenderTest{}
+	sender.SendAppGossipF = func([]byte) error { return nil }
+	n := newTestPushNetwork(b, func(n *pushNetwork) {
+		n.appSender = sender
+		n.chain = vm.chain
+		n.peerVersions[ids.GenerateTestShortID()] = message.CurrentVersion
+	})
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, tx := range ethTxs {
+			n.bufferEthTx(tx)
+		}
+		gossiped, err := n.gossipEthTxs(true)
+		if err != nil {
+			b.Fatal(err)
+		}
+		if gossiped != len(ethTxs) {
+			b.Fatalf("Expected %d txs to be gossiped, but found %d", len(ethTxs), gossiped)
+		}
+	}
+}
@@ -0,0 +1,925 @@
+// (c) 2019-2021, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package evm
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/snow"
+	engCommon "github.com/ava-labs/avalanchego/snow/engine/common"
+	"github.com/ava-labs/avalanchego/snow/validators"
+	"github.com/ava-labs/avalanchego/utils/constants"
+	"github.com/ava-labs/avalanchego/version"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ava-labs/coreth/core/types"
+	"github.com/ava-labs/coreth/params"
+	"github.com/ava-labs/coreth/plugin/evm/message"
+)
+
+// newTestPushNetwork returns a pushNetwork built as by [NewNetwork], without
+// a chain, mempool, or AppSender, that gossips with every peer immediately.
+// Its metrics are not registered, so that each test observes only its own
+// counts. [opts] are applied to the network before it is returned. Goroutines
+// started by the test are stopped when the test completes.
+func newTestPushNetwork(t testing.TB, opts ...func(n *pushNetwork)) *pushNetwork {
+	vm := &VM{
+		ctx:          snow.DefaultContextTest(),
+		shutdownChan: make(chan struct{}),
+	}
+	n := vm.newPushNetwork(time.Time{}, Config{}, nil, nil, nil)
+	n.unknownVersionMsgs = metrics.NewCounterForced()
+	n.notAllowedMsgs = metrics.NewCounterForced()
+	n.tooManyTxsMsgs = metrics.NewCounterForced()
+	n.nonValidatorMsgs = metrics.NewCounterForced()
+	n.oversizedMsgs = metrics.NewCounterForced()
+	n.inboundQueueFullMsgs = metrics.NewCounterForced()
+	n.pendingGossipTxs = metrics.NewGaugeForced()
+	n.pendingGossipBytes = metrics.NewGaugeForced()
+	n.pendingQueueFullTxs = metrics.NewCounterForced()
+	n.oversizedTxs = metrics.NewCounterForced()
+	n.lowGasPriceTxs = metrics.NewCounterForced()
+	n.notActivatedTxs = metrics.NewCounterForced()
+	n.senderLimitedTxs = metrics.NewCounterForced()
+	n.mempoolFullTxs = metrics.NewCounterForced()
+	for _, opt := range opts {
+		opt(n)
+	}
+
+	t.Cleanup(func() {
+		close(vm.shutdownChan)
+		vm.shutdownWg.Wait()
+	})
+	return n
+}
+
+func TestNetworkStats(t *testing.T) {
+	assert := assert.New(t)
+
+	_, vm, _, sharedMemory, sender := GenesisVM(t, true, genesisJSONApricotPhase4, "", "")
+	defer func() {
+		assert.NoError(vm.Shutdown())
+	}()
+	sender.CantSendAppGossip = false
+
+	stats := vm.network.NetworkStats()
+	assert.Zero(stats.RecentAtomicTxs)
+	assert.Zero(stats.RecentEthTxs)
+	assert.Empty(stats.MessagesHandled)
+	assert.True(stats.LastAtomicTxsGossip.IsZero())
+	assert.True(stats.LastEthTxsGossip.IsZero())
+
+	importTxs := createImportTxOptions(t, vm, sharedMemory)
+	tx, conflictingTx := importTxs[0], importTxs[1]
+
+	// Locally issued txs are gossiped
+	start := time.Now()
+	assert.NoError(vm.issueTx(tx, true /*=local*/))
+	time.Sleep(waitBlockTime * 3)
+
+	stats = vm.network.NetworkStats()
+	assert.Equal(1, stats.RecentAtomicTxs)
+	assert.False(stats.LastAtomicTxsGossip.Before(start))
+
+	// Inbound gossip is counted by message type
+	msgBytes, err := message.Build(&message.AtomicTx{Tx: conflictingTx.Bytes()})
+	assert.NoError(err)
+	assert.NoError(vm.AppGossip(ids.GenerateTestShortID(), msgBytes))
+	assert.NoError(vm.AppGossip(ids.GenerateTestShortID(), []byte("not a message")))
+
+	stats = vm.network.NetworkStats()
+	assert.Equal(map[string]uint64{"AtomicTx": 1}, stats.MessagesHandled)
+}
+
+func TestNetworkStatsEthTxs(t *testing.T) {
+	assert := assert.New(t)
+
+	key, err := crypto.GenerateKey()
+	assert.NoError(err)
+
+	addr := crypto.PubkeyToAddress(key.PublicKey)
+
+	cfgJson, err := fundAddressByGenesis([]common.Address{addr})
+	assert.NoError(err)
+
+	_, vm, _, _, sender := GenesisVM(t, true, cfgJson, "", "")
+	defer func() {
+		assert.NoError(vm.Shutdown())
+	}()
+	vm.chain.GetTxPool().SetGasPrice(common.Big1)
+	vm.chain.GetTxPool().SetMinFee(common.Big0)
+
+	gossiped := make(chan struct{}, 1)
+	sender.CantSendAppGossip = false
+	sender.SendAppGossipF = func([]byte) error {
+		select {
+		case gossiped <- struct{}{}:
+		default:
+		}
+		return nil
+	}
+
+	start := time.Now()
+	ethTxs := getValidEthTxs(key, 2, common.Big1)
+	for _, err := range vm.chain.GetTxPool().AddRemotesSync(ethTxs) {
+		assert.NoError(err, "failed adding coreth tx to mempool")
+	}
+
+	select {
+	case <-gossiped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for eth txs to be gossiped")
+	}
+
+	// The stats are updated after the gossip message is sent
+	assert.Eventually(func() bool {
+		return !vm.network.NetworkStats().LastEthTxsGossip.IsZero()
+	}, 5*time.Second, 10*time.Millisecond)
+
+	stats := vm.network.NetworkStats()
+	assert.Equal(2, stats.RecentEthTxs)
+	assert.False(stats.LastEthTxsGossip.Before(start))
+	assert.Zero(stats.RecentAtomicTxs)
+	assert.True(stats.LastAtomicTxsGossip.IsZero())
+}
+
+func TestNetworkGossipVersion(t *testing.T) {
+	assert := assert.New(t)
+
+	n := newTestPushNetwork(t, func(n *pushNetwork) {
+		n.gossipHandler = message.NoopHandler{}
+	})
+	// With no connected peers, the current version is used
+	assert.Equal(message.CurrentVersion, n.gossipVersion())
+
+	oldPeer, newPeer := ids.GenerateTestShortID(), ids.GenerateTestShortID()
+	assert.NoError(n.Connected(newPeer, version.NewDefaultApplication("avalanche", 1, 7, 5)))
+	assert.Equal(message.Version1, n.gossipVersion())
+
+	// Gossip is downgraded while a peer that only supports Version0 is connected
+	assert.NoError(n.Connected(oldPeer, version.NewDefaultApplication("avalanche", 1, 7, 4)))
+	assert.Equal(message.Version0, n.gossipVersion())
+	assert.NoError(n.Disconnected(oldPeer))
+	assert.Equal(message.Version1, n.gossipVersion())
+
+	// Receiving a Version1 message from a peer shows that it supports Version1
+	assert.NoError(n.Connected(oldPeer, version.NewDefaultApplication("avalanche", 1, 7, 4)))
+	msgBytes, err := message.BuildWithVersion(&message.AtomicTx{Tx: []byte("blah")}, message.Version1)
+	assert.NoError(err)
+	assert.NoError(n.AppGossip(oldPeer, msgBytes))
+	assert.Equal(message.Version1, n.gossipVersion())
+
+	// Messages with an unknown version are dropped
+	msgBytes[1] = byte(message.CurrentVersion + 1)
+	assert.NoError(n.AppGossip(oldPeer, msgBytes))
+	assert.EqualValues(1, n.unknownVersionMsgs.Count())
+	assert.Equal(map[string]uint64{"AtomicTx": 1}, n.messagesHandled)
+}
+
+func TestGossipMessageCounts(t *testing.T) {
+	assert := assert.New(t)
+
+	n := newTestPushNetwork(t, func(n *pushNetwork) {
+		n.gossipHandler = message.NoopHandler{}
+	})
+	assert.Empty(n.GossipMessageCounts())
+
+	start := time.Now()
+	nodeID := ids.GenerateTestShortID()
+	atomicMsgBytes, err := message.Build(&message.AtomicTx{Tx: []byte("blah")})
+	assert.NoError(err)
+	ethMsgBytes, err := message.Build(&message.EthTxs{Txs: []byte("blah")})
+	assert.NoError(err)
+	assert.NoError(n.AppGossip(nodeID, atomicMsgBytes))
+	assert.NoError(n.AppGossip(nodeID, ethMsgBytes))
+	assert.NoError(n.AppGossip(nodeID, ethMsgBytes))
+
+	counts := n.GossipMessageCounts()
+	assert.Len(counts, 2)
+	assert.EqualValues(1, counts["AtomicTx"].Received)
+	assert.EqualValues(2, counts["EthTxs"].Received)
+	for _, count := range counts {
+		assert.False(count.LastReceived.Before(start))
+	}
+
+	// Unparsable messages are not counted
+	assert.NoError(n.AppGossip(nodeID, []byte("blah")))
+	assert.Equal(counts, n.GossipMessageCounts())
+}
+
+// blockingGossipHandler records the atomic txs it handles, and blocks handling
+// the first one until [release] is closed.
+type blockingGossipHandler struct {
+	message.NoopHandler
+
+	started chan struct{}
+	release chan struct{}
+
+	lock    sync.Mutex
+	handled []string
+}
+
+func (h *blockingGossipHandler) HandleAtomicTx(nodeID ids.ShortID, requestID uint32, msg *message.AtomicTx) error {
+	h.lock.Lock()
+	h.handled = append(h.handled, string(msg.Tx))
+	first := len(h.handled) == 1
+	h.lock.Unlock()
+
+	if first {
+		close(h.started)
+		<-h.release
+	}
+	return nil
+}
+
+func TestInboundGossipWorkers(t *testing.T) {
+	assert := assert.New(t)
+
+	handler := &blockingGossipHandler{
+		started: make(chan struct{}),
+		release: make(chan struct{}),
+	}
+	n := newTestPushNetwork(t, func(n *pushNetwork) {
+		n.config.InboundGossipQueueSize = 2
+		n.gossipHandler = handler
+	})
+	n.startGossipWorkers(1)
+
+	nodeID := ids.GenerateTestShortID()
+	gossip := func(tx string) {
+		msgBytes, err := message.Build(&message.AtomicTx{Tx: []byte(tx)})
+		assert.NoError(err)
+		assert.NoError(n.AppGossip(nodeID, msgBytes))
+	}
+
+	gossip("0")
+	<-handler.started
+
+	// The worker is blocked, so flooding gossip must neither block the caller
+	// nor grow the queue beyond its size
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 1; i < 10; i++ {
+			gossip(string(rune('0' + i)))
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("AppGossip blocked on a busy worker")
+	}
+	assert.EqualValues(7, n.inboundQueueFullMsgs.Count())
+
+	close(handler.release)
+	assert.Eventually(func() bool {
+		handler.lock.Lock()
+		defer handler.lock.Unlock()
+		return len(handler.handled) == 3
+	}, 5*time.Second, 10*time.Millisecond)
+
+	handler.lock.Lock()
+	assert.Equal([]string{"0", "8", "9"}, handler.handled)
+	handler.lock.Unlock()
+}
+
+func TestGossipActivationJitter(t *testing.T) {
+	assert := assert.New(t)
+
+	window := 10 * time.Second
+	nodeID0 := ids.ShortID{1, 2, 3, 4, 5, 6, 7, 8}
+	nodeID1 := ids.ShortID{8, 7, 6, 5, 4, 3, 2, 1}
+
+	jitter0 := gossipActivationJitter(nodeID0, window)
+	jitter1 := gossipActivationJitter(nodeID1, window)
+	assert.NotEqual(jitter0, jitter1)
+	for _, jitter := range []time.Duration{jitter0, jitter1} {
+		assert.GreaterOrEqual(jitter, time.Duration(0))
+		assert.Less(jitter, window)
+	}
+
+	// The jitter is deterministic per node ID
+	assert.Equal(jitter0, gossipActivationJitter(nodeID0, window))
+
+	// Without a window, gossip activates at the protocol activation time
+	assert.Zero(gossipActivationJitter(nodeID0, 0))
+}
+
+func TestNetworkDropReasons(t *testing.T) {
+	assert := assert.New(t)
+
+	// Capture the reason of every dropped message or tx
+	var (
+		reasonsLock sync.Mutex
+		reasons     []string
+	)
+	oldHandler := log.Root().GetHandler()
+	defer log.Root().SetHandler(oldHandler)
+	log.Root().SetHandler(log.FuncHandler(func(r *log.Record) error {
+		for i := 0; i+1 < len(r.Ctx); i += 2 {
+			if r.Ctx[i] == "reason" {
+				reasonsLock.Lock()
+				reasons = append(reasons, r.Ctx[i+1].(string))
+				reasonsLock.Unlock()
+			}
+		}
+		return nil
+	}))
+	assertReason := func(expected string) {
+		reasonsLock.Lock()
+		defer reasonsLock.Unlock()
+
+		assert.Equal([]string{expected}, reasons)
+		reasons = nil
+	}
+	buildMsg := func(msg message.Message) []byte {
+		msgBytes, err := message.BuildWithVersion(msg, message.Version1)
+		assert.NoError(err)
+		return msgBytes
+	}
+
+	nodeID := ids.GenerateTestShortID()
+	n := newTestPushNetwork(t, func(n *pushNetwork) {
+		n.gossipActivationTime = time.Now().Add(time.Hour)
+	})
+
+	assert.NoError(n.AppGossip(nodeID, buildMsg(&message.AtomicTx{})))
+	assertReason(dropReasonBeforeActivation)
+	assert.NoError(n.GossipEthTxs(nil))
+	assertReason(dropReasonBeforeActivation)
+	n.gossipActivationTime = time.Time{}
+
+	unknownVersionMsg := buildMsg(&message.AtomicTx{Tx: []byte("blah")})
+	unknownVersionMsg[1] = byte(message.CurrentVersion + 1)
+	assert.NoError(n.AppGossip(nodeID, unknownVersionMsg))
+	assertReason(dropReasonUnknownVersion)
+
+	assert.NoError(n.AppGossip(nodeID, []byte{0, 0, 0xff, 0xff, 0xff, 0xff}))
+	assertReason(dropReasonParseFailed)
+
+	assert.NoError(n.AppGossip(nodeID, buildMsg(&message.AtomicTx{})))
+	assertReason(dropReasonEmptyMsg)
+	assert.NoError(n.AppGossip(nodeID, buildMsg(&message.AtomicTx{Tx: []byte("blah")})))
+	assertReason(dropReasonParseFailed)
+
+	assert.NoError(n.AppGossip(nodeID, buildMsg(&message.EthTxs{})))
+	assertReason(dropReasonEmptyMsg)
+	assert.NoError(n.AppGossip(nodeID, buildMsg(&message.EthTxs{Txs: []byte("blah")})))
+	assertReason(dropReasonParseFailed)
+
+	assert.NoError(n.AppGossip(nodeID, buildMsg(&message.MempoolBloom{Bloom: make([]byte, mempoolBloomSize)})))
+	assertReason(dropReasonBloomDisabled)
+	n.config.MempoolBloomEnabled = true
+	assert.NoError(n.AppGossip(nodeID, buildMsg(&message.MempoolBloom{Bloom: []byte("blah")})))
+	assertReason(dropReasonInvalidBloom)
+
+	tx := newTestAtomicTx(t)
+	n.recentAtomicTxs.Put(tx.ID(), nil)
+	assert.NoError(n.gossipAtomicTx(tx))
+	assertReason(dropReasonRecentDupe)
+}
+
+func TestRecentCacheByteBudget(t *testing.T) {
+	assert := assert.New(t)
+
+	entrySize := recentEntrySize(common.Hash{}, nil)
+	c := newRecentCache(recentCacheSize, 3*entrySize)
+
+	hashes := []common.Hash{{1}, {2}, {3}, {4}}
+	for _, hash := range hashes[:3] {
+		c.Put(hash, nil)
+	}
+	assert.Equal(3, c.Len())
+	assert.Equal(3*entrySize, c.Bytes())
+
+	// Exceeding the byte budget evicts the least recently used entry, even
+	// though the cache holds far fewer than [recentCacheSize] entries.
+	_, has := c.Get(hashes[0])
+	assert.True(has)
+	c.Put(hashes[3], nil)
+	assert.Equal(3, c.Len())
+	assert.Equal(3*entrySize, c.Bytes())
+	_, has = c.Get(hashes[1])
+	assert.False(has)
+	for _, hash := range []common.Hash{hashes[0], hashes[2], hashes[3]} {
+		_, has := c.Get(hash)
+		assert.True(has)
+	}
+
+	// Larger entries take up more of the budget
+	c.Put(hashes[1], make([]byte, entrySize))
+	assert.Equal(2, c.Len())
+	assert.Equal(3*entrySize, c.Bytes())
+}
+
+func TestRecentCacheTTL(t *testing.T) {
+	assert := assert.New(t)
+
+	ttl := time.Minute
+	c := newRecentCacheWithTTL(1, 0, ttl)
+	now := time.Unix(1000, 0)
+	c.clock.Set(now)
+
+	hashes := []common.Hash{{1}, {2}}
+	c.Put(hashes[0], nil)
+	c.Put(hashes[1], nil)
+	assert.Equal(1, c.Len())
+
+	// Within the TTL, the evicted hash is still reported as recently gossiped
+	c.clock.Set(now.Add(ttl - time.Second))
+	_, has := c.Get(hashes[0])
+	assert.True(has)
+
+	// Past the TTL, the evicted hash may be gossiped again
+	c.clock.Set(now.Add(ttl))
+	_, has = c.Get(hashes[0])
+	assert.False(has)
+
+	// Explicitly evicted hashes may be gossiped again within the TTL
+	c.Put(hashes[0], nil)
+	c.Evict(hashes[0])
+	_, has = c.Get(hashes[0])
+	assert.False(has)
+
+	// Without a TTL, evicted hashes may be gossiped again right away
+	c = newRecentCache(1, 0)
+	c.Put(hashes[0], nil)
+	c.Put(hashes[1], nil)
+	_, has = c.Get(hashes[0])
+	assert.False(has)
+}
+
+func TestGossipEnabled(t *testing.T) {
+	assert := assert.New(t)
+
+	// Without an ApricotPhase4 activation time, the VM uses [noopNetwork]
+	_, vm, _, _, _ := GenesisVM(t, true, genesisJSONApricotPhase3, "", "")
+	assert.IsType(&noopNetwork{}, vm.network)
+	assert.False(vm.network.GossipEnabled())
+	assert.NoError(vm.Shutdown())
+
+	_, vm, _, _, _ = GenesisVM(t, true, genesisJSONApricotPhase4, "", "")
+	assert.IsType(&pushNetwork{}, vm.network)
+	assert.True(vm.network.GossipEnabled())
+	assert.NoError(vm.Shutdown())
+}
+
+func TestDevModeGossipAlwaysActive(t *testing.T) {
+	assert := assert.New(t)
+
+	configJSON := `{"dev-mode-gossip-always-active": true}`
+	_, vm, _, _, _ := GenesisVM(t, true, genesisJSONApricotPhase4, configJSON, "")
+	defer func() {
+		assert.NoError(vm.Shutdown())
+	}()
+
+	// In dev mode, txs are gossiped immediately even if the gossip activation
+	// time is in the future
+	mempool := newFakeMempool()
+	sender := &engCommon.SenderTest{T: t}
+	gossiped := 0
+	sender.SendAppGossipF = func([]byte) error {
+		gossiped++
+		return nil
+	}
+	n := vm.newPushNetwork(time.Now().Add(time.Hour), vm.config, sender, vm.chain, mempool)
+	assert.NoError(n.Connected(ids.GenerateTestShortID(), version.NewDefaultApplication(constants.PlatformName, 1, 7, 5)))
+
+	tx := newTestAtomicTx(t)
+	mempool.txs[tx.ID()] = tx
+	assert.NoError(n.GossipAtomicTxs([]*Tx{tx}))
+	assert.Equal(1, gossiped)
+
+	// Dev mode may not be enabled on mainnet
+	ctx, dbManager, genesisBytes, issuer, _ := setupGenesis(t, genesisJSONApricotPhase4)
+	ctx.NetworkID = constants.MainnetID
+	err := (&VM{}).Initialize(ctx, dbManager, genesisBytes, nil, []byte(configJSON), issuer, nil, &engCommon.SenderTest{})
+	assert.ErrorIs(err, errDevModeGossipOnMainnet)
+}
+
+func TestMaxOutstandingAppRequests(t *testing.T) {
+	assert := assert.New(t)
+
+	sender := &engCommon.SenderTest{T: t}
+	sent := 0
+	sender.SendAppRequestF = func(ids.ShortSet, uint32, []byte) error {
+		sent++
+		return nil
+	}
+	n := newTestPushNetwork(t, func(n *pushNetwork) {
+		n.config.MaxOutstandingAppRequests = 2
+		n.appSender = sender
+	})
+
+	nodeID := ids.GenerateTestShortID()
+	requestID, err := n.sendAppRequest(nodeID, nil, nil)
+	assert.NoError(err)
+	_, err = n.sendAppRequest(nodeID, nil, nil)
+	assert.NoError(err)
+
+	// The limit is reached, so the next request is refused
+	_, err = n.sendAppRequest(nodeID, nil, nil)
+	assert.ErrorIs(err, errTooManyOutstandingAppRequests)
+	assert.Equal(2, sent)
+
+	// A response from a different peer does not free the request
+	assert.NoError(n.AppResponse(ids.GenerateTestShortID(), requestID, nil))
+	_, err = n.sendAppRequest(nodeID, nil, nil)
+	assert.ErrorIs(err, errTooManyOutstandingAppRequests)
+
+	// A response frees the request
+	assert.NoError(n.AppResponse(nodeID, requestID, nil))
+	_, err = n.sendAppRequest(nodeID, nil, nil)
+	assert.NoError(err)
+	assert.Equal(3, sent)
+}
+
+func TestAppRequestTimeout(t *testing.T) {
+	assert := assert.New(t)
+
+	sender := &engCommon.SenderTest{T: t}
+	sender.SendAppRequestF = func(ids.ShortSet, uint32, []byte) error { return nil }
+	n := newTestPushNetwork(t, func(n *pushNetwork) {
+		n.config.AppRequestTimeout = Duration{10 * time.Millisecond}
+		n.appSender = sender
+	})
+	nodeID := ids.GenerateTestShortID()
+
+	// A request that is never responded to fails once it times out
+	failed := make(chan struct{})
+	_, err := n.sendAppRequest(nodeID, nil, func() { close(failed) })
+	assert.NoError(err)
+	select {
+	case <-failed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the AppRequest to time out")
+	}
+	n.requestsLock.Lock()
+	assert.Empty(n.outstandingRequests)
+	assert.Empty(n.onRequestFailed)
+	assert.Empty(n.requestTimers)
+	n.requestsLock.Unlock()
+
+	// A request that is responded to does not time out
+	requestID, err := n.sendAppRequest(nodeID, nil, func() {
+		t.Error("AppRequest should not have failed after a response")
+	})
+	assert.NoError(err)
+	assert.NoError(n.AppResponse(nodeID, requestID, nil))
+	time.Sleep(50 * time.Millisecond)
+	n.requestsLock.Lock()
+	assert.Empty(n.outstandingRequests)
+	assert.Empty(n.requestTimers)
+	n.requestsLock.Unlock()
+}
+
+// The network should gossip txs when metrics are enabled but the context has
+// no metrics registerer.
+func TestGossipWithoutMetricsRegisterer(t *testing.T) {
+	assert := assert.New(t)
+
+	metrics.Enabled = true
+	defer func() { metrics.Enabled = false }()
+
+	vm := &VM{}
+	ctx, dbManager, genesisBytes, issuer, _ := setupGenesis(t, genesisJSONApricotPhase4)
+	ctx.Metrics = nil
+	sender := &engCommon.SenderTest{T: t}
+	gossiped := 0
+	sender.SendAppGossipF = func([]byte) error {
+		gossiped++
+		return nil
+	}
+	assert.NoError(vm.Initialize(ctx, dbManager, genesisBytes, nil, []byte(`{"metrics-enabled": true}`), issuer, nil, sender))
+	defer func() {
+		assert.NoError(vm.Shutdown())
+	}()
+	assert.NoError(vm.Bootstrapping())
+	assert.NoError(vm.Bootstrapped())
+
+	n := vm.network.(*pushNetwork)
+	n.gossipActivationTime = time.Time{}
+	mempool := newFakeMempool()
+	n.mempool = mempool
+	assert.NoError(vm.Connected(ids.GenerateTestShortID(), version.NewDefaultApplication(constants.PlatformName, 1, 7, 5)))
+
+	tx := newTestAtomicTx(t)
+	mempool.txs[tx.ID()] = tx
+	assert.NoError(n.GossipAtomicTxs([]*Tx{tx}))
+	assert.Equal(1, gossiped)
+}
+
+func TestPendingGossipQueue(t *testing.T) {
+	assert := assert.New(t)
+
+	mempool := newFakeMempool()
+	n := newTestPushNetwork(t, func(n *pushNetwork) {
+		n.mempool = mempool
+	})
+	assert.Empty(n.PendingGossipQueue())
+	assert.Empty(n.PendingAtomicGossipQueue())
+
+	// With no peers connected, the txs are queued rather than sent
+	tx := newTestAtomicTx(t)
+	mempool.txs[tx.ID()] = tx
+	assert.NoError(n.GossipAtomicTxs([]*Tx{tx}))
+
+	key, err := crypto.GenerateKey()
+	assert.NoError(err)
+	ethTxs := getValidEthTxs(key, 2, common.Big1)
+	assert.NoError(n.sendEthTxs(ethTxs))
+
+	assert.Equal([]ids.ID{tx.ID()}, n.PendingAtomicGossipQueue())
+	assert.ElementsMatch([]common.Hash{ethTxs[0].Hash(), ethTxs[1].Hash()}, n.PendingGossipQueue())
+
+	// Txs dropped from the tx pool are no longer queued
+	n.removePendingEthTxs([]common.Hash{ethTxs[0].Hash()})
+	assert.Equal([]common.Hash{ethTxs[1].Hash()}, n.PendingGossipQueue())
+	assert.EqualValues(2, n.pendingGossipTxs.Value())
+
+	// At most [maxPendingGossipTxs] eth txs are queued
+	fillerTxs := make([]*types.Transaction, maxPendingGossipTxs)
+	for i := range fillerTxs {
+		fillerTxs[i] = types.NewTransaction(uint64(i), common.Address{1}, common.Big1, params.TxGas, common.Big1, nil)
+	}
+	n.queuePendingEthTxs(fillerTxs)
+	assert.Len(n.PendingGossipQueue(), maxPendingGossipTxs)
+	assert.EqualValues(1, n.pendingQueueFullTxs.Count())
+}
+
+// gossip should only be exchanged with the peers allowed by the gossip peer
+// allowlist and denylist
+func TestGossipPeerAllowlistDenylist(t *testing.T) {
+	peers := []ids.ShortID{
+		ids.GenerateTestShortID(),
+		ids.GenerateTestShortID(),
+		ids.GenerateTestShortID(),
+	}
+	tests := map[string]struct {
+		allowlist []ids.ShortID
+		denylist  []ids.ShortID
+		allowed   []bool
+	}{
+		"default": {
+			allowed: []bool{true, true, true},
+		},
+		"allowlist": {
+			allowlist: []ids.ShortID{peers[0], peers[1]},
+			allowed:   []bool{true, true, false},
+		},
+		"denylist": {
+			denylist: []ids.ShortID{peers[1]},
+			allowed:  []bool{true, false, true},
+		},
+		"denylist overrides allowlist": {
+			allowlist: []ids.ShortID{peers[0], peers[1]},
+			denylist:  []ids.ShortID{peers[1]},
+			allowed:   []bool{true, false, false},
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			sender := &engCommon.SenderTest{T: t}
+			n := newTestPushNetwork(t, func(n *pushNetwork) {
+				n.appSender = sender
+				n.peerAllowlist = ids.NewShortSet(len(test.allowlist))
+				n.peerDenylist = ids.NewShortSet(len(test.denylist))
+			})
+			n.peerAllowlist.Add(test.allowlist...)
+			n.peerDenylist.Add(test.denylist...)
+			for _, nodeID := range peers {
+				n.peerVersions[nodeID] = message.Version1
+			}
+
+			// Inbound gossip from peers that are not allowed is dropped
+			msgBytes, err := message.Build(&message.EthTxs{})
+			assert.NoError(err)
+			expectedHandled, expectedDropped := 0, 0
+			for i, nodeID := range peers {
+				assert.NoError(n.AppGossip(nodeID, msgBytes))
+				if test.allowed[i] {
+					expectedHandled++
+				} else {
+					expectedDropped++
+				}
+			}
+			assert.EqualValues(expectedHandled, n.messagesHandled["EthTxs"])
+			assert.EqualValues(expectedDropped, n.notAllowedMsgs.Count())
+
+			// Outbound gossip is only sent to the allowed peers, and is left to
+			// the engine if every peer is allowed
+			var (
+				gossiped  bool
+				sentPeers ids.ShortSet
+			)
+			sender.SendAppGossipF = func([]byte) error {
+				gossiped = true
+				return nil
+			}
+			sender.SendAppGossipSpecificF = func(nodeIDs ids.ShortSet, _ []byte) error {
+				sentPeers = nodeIDs
+				return nil
+			}
+			assert.NoError(n.sendTxsGossip(n.appSender, msgBytes))
+			if len(test.allowlist) == 0 && len(test.denylist) == 0 {
+				assert.True(gossiped)
+				assert.Nil(sentPeers)
+				return
+			}
+			assert.False(gossiped)
+			for i, nodeID := range peers {
+				assert.Equal(test.allowed[i], sentPeers.Contains(nodeID))
+			}
+		})
+	}
+}
+
+// lowPrioritySenderTest is an AppSender that supports low priority gossip
+type lowPrioritySenderTest struct {
+	*engCommon.SenderTest
+	lowPriorityMsgs int
+}
+
+func (s *lowPrioritySenderTest) SendAppGossipLowPriority([]byte) error {
+	s.lowPriorityMsgs++
+	return nil
+}
+
+func (s *lowPrioritySenderTest) SendAppGossipSpecificLowPriority(ids.ShortSet, []byte) error {
+	s.lowPriorityMsgs++
+	return nil
+}
+
+func TestLowPriorityEthGossip(t *testing.T) {
+	tests := map[string]struct {
+		enabled             bool
+		supportsLowPriority bool
+		expectLowPriority   bool
+	}{
+		"disabled": {
+			supportsLowPriority: true,
+		},
+		"enabled": {
+			enabled:             true,
+			supportsLowPriority: true,
+			expectLowPriority:   true,
+		},
+		"enabled without sender support": {
+			enabled: true,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			senderTest := &engCommon.SenderTest{T: t}
+			var (
+				sender   engCommon.AppSender = senderTest
+				lpSender *lowPrioritySenderTest
+				gossiped int
+			)
+			if test.supportsLowPriority {
+				lpSender = &lowPrioritySenderTest{SenderTest: senderTest}
+				sender = lpSender
+			}
+			senderTest.SendAppGossipF = func([]byte) error {
+				gossiped++
+				return nil
+			}
+			n := newTestPushNetwork(t, func(n *pushNetwork) {
+				n.config.LowPriorityEthGossip = test.enabled
+				n.appSender = sender
+			})
+
+			// Eth txs are gossiped with a low priority if enabled and supported
+			assert.NoError(n.sendTxsGossip(n.ethTxsSender(), nil))
+			// Atomic txs are always gossiped with the default priority
+			assert.NoError(n.sendTxsGossip(n.appSender, nil))
+
+			if test.expectLowPriority {
+				assert.Equal(1, lpSender.lowPriorityMsgs)
+				assert.Equal(1, gossiped)
+				return
+			}
+			if lpSender != nil {
+				assert.Zero(lpSender.lowPriorityMsgs)
+			}
+			assert.Equal(2, gossiped)
+		})
+	}
+}
+
+func TestGossipFromValidatorsOnly(t *testing.T) {
+	assert := assert.New(t)
+
+	validator := ids.GenerateTestShortID()
+	nonValidator := ids.GenerateTestShortID()
+	subnetID := ids.GenerateTestID()
+	fetches := 0
+	state := &validators.TestState{
+		T: t,
+		GetCurrentHeightF: func() (uint64, error) {
+			return 10, nil
+		},
+		GetValidatorSetF: func(height uint64, requestedSubnetID ids.ID) (map[ids.ShortID]uint64, error) {
+			fetches++
+			assert.EqualValues(10, height)
+			assert.Equal(subnetID, requestedSubnetID)
+			return map[ids.ShortID]uint64{validator: 1}, nil
+		},
+	}
+	n := newTestPushNetwork(t, func(n *pushNetwork) {
+		n.validators = newValidatorSet(state, subnetID)
+	})
+
+	msgBytes, err := message.Build(&message.EthTxs{})
+	assert.NoError(err)
+	for i := 0; i < 3; i++ {
+		assert.NoError(n.AppGossip(validator, msgBytes))
+		assert.NoError(n.AppGossip(nonValidator, msgBytes))
+	}
+	assert.EqualValues(3, n.messagesHandled["EthTxs"])
+	assert.EqualValues(3, n.nonValidatorMsgs.Count())
+	// The validator set is fetched once and then cached
+	assert.Equal(1, fetches)
+}
+
+func TestBlockAnnouncementResyncHint(t *testing.T) {
+	assert := assert.New(t)
+
+	_, vm, _, _, _ := GenesisVM(t, true, genesisJSONApricotPhase4, "", "")
+	defer func() {
+		assert.NoError(vm.Shutdown())
+	}()
+	net, ok := vm.network.(*pushNetwork)
+	assert.True(ok)
+
+	var hints []uint64
+	net.resyncHint = func(_ ids.ShortID, _ common.Hash, height uint64) {
+		hints = append(hints, height)
+	}
+
+	lastAcceptedHeight := vm.chain.LastAcceptedBlock().NumberU64()
+	for _, height := range []uint64{lastAcceptedHeight, lastAcceptedHeight + 1} {
+		msgBytes, err := message.Build(&message.BlockAnnouncement{Hash: common.Hash{1}, Height: height})
+		assert.NoError(err)
+		assert.NoError(vm.AppGossip(testPeerID, msgBytes))
+	}
+	// Only the announcement above the last accepted block triggers a hint
+	assert.Equal([]uint64{lastAcceptedHeight + 1}, hints)
+
+	// The VM reports how far behind the announcing peers it is
+	net.resyncHint = vm.resyncHint
+	msgBytes, err := message.Build(&message.BlockAnnouncement{Hash: common.Hash{1}, Height: lastAcceptedHeight + 3})
+	assert.NoError(err)
+	assert.NoError(vm.AppGossip(testPeerID, msgBytes))
+	details, err := vm.HealthCheck()
+	assert.NoError(err)
+	assert.Equal("3", details.(map[string]string)["blocksBehindPeers"])
+	assert.EqualValues(3, vm.blocksBehindPeers.Value())
+}
+
+// gossip backpressure should be reported while too few peers are connected or
+// too many AppRequests are awaiting a response
+func TestGossipBackpressure(t *testing.T) {
+	assert := assert.New(t)
+
+	_, vm, _, _, sender := GenesisVM(t, true, genesisJSONApricotPhase4, `{"max-outstanding-app-requests": 1}`, "")
+	defer func() {
+		assert.NoError(vm.Shutdown())
+	}()
+	sender.CantSendAppRequest = false
+
+	n := vm.network.(*pushNetwork)
+	assert.False(n.GossipBackpressure())
+	details, err := vm.HealthCheck()
+	assert.NoError(err)
+	assert.Equal(map[string]string{"gossipBackpressure": "false", "blocksBehindPeers": "0"}, details)
+
+	requestID, err := n.sendAppRequest(testPeerID, nil, nil)
+	assert.NoError(err)
+	assert.True(n.GossipBackpressure())
+	details, err = vm.HealthCheck()
+	assert.NoError(err)
+	assert.Equal(map[string]string{"gossipBackpressure": "true", "blocksBehindPeers": "0"}, details)
+
+	assert.NoError(n.AppResponse(testPeerID, requestID, nil))
+	assert.False(n.GossipBackpressure())
+
+	assert.NoError(vm.Disconnected(testPeerID))
+	assert.True(n.GossipBackpressure())
+
+	assert.False((&noopNetwork{}).GossipBackpressure())
+}
@@ -12,6 +12,7 @@ import (
 	"strings"
 
 	"github.com/ava-labs/avalanchego/api"
+	"github.com/ava-labs/avalanchego/database"
 	"github.com/ava-labs/avalanchego/ids"
 	"github.com/ava-labs/avalanchego/utils/constants"
 	"github.com/ava-labs/avalanchego/utils/crypto"
@@ -30,12 +31,16 @@ const (
 
 	// Max number of addresses that can be passed in as argument to GetUTXOs
 	maxGetUTXOsAddrs = 1024
+
+	// Max number of heights that can be queried at once by GetAtomicTxsByHeight
+	maxGetAtomicTxsByHeightRange = 1024
 )
 
 var (
 	errNoAddresses   = errors.New("no addresses provided")
 	errNoSourceChain = errors.New("no source chain provided")
 	errNilTxID       = errors.New("nil transaction ID")
+	errInvalidRange  = errors.New("end height is less than start height")
 
 	initialBaseFee = big.NewInt(params.ApricotPhase3InitialBaseFee)
 )
@@ -506,3 +511,58 @@ func (service *AvaxAPI) GetAtomicTx(r *http.Request, args *api.GetTxArgs, reply
 	}
 	return nil
 }
+
+// GetAtomicTxsByHeightArgs are the arguments for GetAtomicTxsByHeight
+type GetAtomicTxsByHeightArgs struct {
+	StartHeight json.Uint64 `json:"startHeight"`
+	EndHeight   json.Uint64 `json:"endHeight"`
+}
+
+// AtomicTxsAtHeight is the list of atomic txs accepted at a height
+type AtomicTxsAtHeight struct {
+	Height json.Uint64 `json:"height"`
+	TxIDs  []ids.ID    `json:"txIDs"`
+}
+
+// GetAtomicTxsByHeightReply defines the GetAtomicTxsByHeight replies returned
+// from the API
+type GetAtomicTxsByHeightReply struct {
+	Heights []AtomicTxsAtHeight `json:"heights"`
+}
+
+// GetAtomicTxsByHeight returns the IDs of the atomic txs accepted at each
+// height from [StartHeight] to [EndHeight], inclusive. Heights without any
+// atomic txs are omitted.
+func (service *AvaxAPI) GetAtomicTxsByHeight(r *http.Request, args *GetAtomicTxsByHeightArgs, reply *GetAtomicTxsByHeightReply) error {
+	log.Info("EVM: GetAtomicTxsByHeight called", "startHeight", args.StartHeight, "endHeight", args.EndHeight)
+
+	startHeight, endHeight := uint64(args.StartHeight), uint64(args.EndHeight)
+	if endHeight < startHeight {
+		return errInvalidRange
+	}
+	if endHeight-startHeight >= maxGetAtomicTxsByHeightRange {
+		return fmt.Errorf("range can include at most %d heights", maxGetAtomicTxsByHeightRange)
+	}
+
+	reply.Heights = []AtomicTxsAtHeight{}
+	// Iterate by offset so that [endHeight] can be the max uint64
+	for offset := uint64(0); offset <= endHeight-startHeight; offset++ {
+		height := startHeight + offset
+		txs, err := service.vm.atomicTxRepository.GetByHeight(height)
+		if err == database.ErrNotFound {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to get atomic txs at height %d: %w", height, err)
+		}
+		txIDs := make([]ids.ID, len(txs))
+		for i, tx := range txs {
+			txIDs[i] = tx.ID()
+		}
+		reply.Heights = append(reply.Heights, AtomicTxsAtHeight{
+			Height: json.Uint64(height),
+			TxIDs:  txIDs,
+		})
+	}
+	return nil
+}
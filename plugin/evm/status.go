@@ -18,13 +18,15 @@ type Status uint32
 // List of possible status values
 // [Unknown] Zero value, means the status is not known
 // [Dropped] means the transaction was in the mempool, but was dropped because it failed verification
-// [Processing] means the transaction is in the mempool
+// [Processing] means the transaction has been issued into a block that has not been accepted yet
 // [Accepted] means the transaction was accepted
+// [Pending] means the transaction is in the mempool waiting to be issued into a block
 const (
 	Unknown Status = iota
 	Dropped
 	Processing
 	Accepted
+	Pending
 )
 
 // MarshalJSON ...
@@ -50,6 +52,8 @@ func (s *Status) UnmarshalJSON(b []byte) error {
 		*s = Processing
 	case `"Accepted"`:
 		*s = Accepted
+	case `"Pending"`:
+		*s = Pending
 	default:
 		return errUnknownStatus
 	}
@@ -59,7 +63,7 @@ func (s *Status) UnmarshalJSON(b []byte) error {
 // Valid returns nil if the status is a valid status.
 func (s Status) Valid() error {
 	switch s {
-	case Unknown, Dropped, Processing, Accepted:
+	case Unknown, Dropped, Processing, Accepted, Pending:
 		return nil
 	default:
 		return errUnknownStatus
@@ -76,6 +80,8 @@ func (s Status) String() string {
 		return "Processing"
 	case Accepted:
 		return "Accepted"
+	case Pending:
+		return "Pending"
 	default:
 		return "Invalid status"
 	}
@@ -25,6 +25,7 @@ type TestTx struct {
 	AcceptRequestsV             *atomic.Requests `serialize:"true"`
 	VerifyV                     error
 	IDV                         ids.ID `serialize:"true" json:"id"`
+	TypeV                       AtomicTxType
 	BurnedV                     uint64 `serialize:"true"`
 	UnsignedBytesV              []byte
 	BytesV                      []byte
@@ -36,7 +37,9 @@ type TestTx struct {
 var _ UnsignedAtomicTx = &TestTx{}
 
 // GasUsed implements the UnsignedAtomicTx interface
-func (t *TestTx) GasUsed(fixedFee bool) (uint64, error) { return t.GasUsedV, nil }
+func (t *TestTx) GasUsed(fees params.AtomicTxFeeConfig, fixedFee bool) (uint64, error) {
+	return t.GasUsedV, nil
+}
 
 // Verify implements the UnsignedAtomicTx interface
 func (t *TestTx) Verify(ctx *snow.Context, rules params.Rules) error { return t.VerifyV }
@@ -61,6 +64,9 @@ func (t *TestTx) UnsignedBytes() []byte { return t.UnsignedBytesV }
 // Bytes implements the UnsignedAtomicTx interface
 func (t *TestTx) Bytes() []byte { return t.BytesV }
 
+// Type implements the UnsignedAtomicTx interface
+func (t *TestTx) Type() AtomicTxType { return t.TypeV }
+
 // InputUTXOs implements the UnsignedAtomicTx interface
 func (t *TestTx) InputUTXOs() ids.Set { return t.InputUTXOsV }
 
@@ -69,6 +75,11 @@ func (t *TestTx) SemanticVerify(vm *VM, stx *Tx, parent *Block, baseFee *big.Int
 	return t.SemanticVerifyV
 }
 
+// SemanticVerifyWithOptions implements the UnsignedAtomicTx interface
+func (t *TestTx) SemanticVerifyWithOptions(vm *VM, stx *Tx, parent *Block, baseFee *big.Int, rules params.Rules, opts SemanticVerifyOptions) error {
+	return t.SemanticVerifyV
+}
+
 // EVMStateTransfer implements the UnsignedAtomicTx interface
 func (t *TestTx) EVMStateTransfer(ctx *snow.Context, state *state.StateDB) error {
 	return t.EVMStateTransferV
@@ -9,8 +9,10 @@ import (
 	"fmt"
 	"math/big"
 	"sort"
+	"sync"
 
 	"github.com/ethereum/go-ethereum/common"
+	ethcrypto "github.com/ethereum/go-ethereum/crypto"
 
 	"github.com/ava-labs/coreth/core/state"
 	"github.com/ava-labs/coreth/params"
@@ -22,13 +24,16 @@ import (
 	"github.com/ava-labs/avalanchego/utils"
 	"github.com/ava-labs/avalanchego/utils/crypto"
 	"github.com/ava-labs/avalanchego/utils/hashing"
+	"github.com/ava-labs/avalanchego/utils/math"
 	"github.com/ava-labs/avalanchego/utils/wrappers"
+	"github.com/ava-labs/avalanchego/vms/components/avax"
 	"github.com/ava-labs/avalanchego/vms/components/verify"
 	"github.com/ava-labs/avalanchego/vms/secp256k1fx"
 )
 
 var (
 	errWrongBlockchainID = errors.New("wrong blockchain ID provided")
+	errEmptyBlockchainID = errors.New("blockchain ID is not initialized")
 	errWrongNetworkID    = errors.New("tx was issued with a different network ID")
 	errNilTx             = errors.New("tx is nil")
 	errNoValueOutput     = errors.New("output has no value")
@@ -37,16 +42,40 @@ var (
 	errNilInput          = errors.New("nil input")
 	errEmptyAssetID      = errors.New("empty asset ID is not valid")
 	errNilBaseFee        = errors.New("cannot calculate dynamic fee with nil baseFee")
+	errNegativeBaseFee   = errors.New("cannot calculate dynamic fee with negative baseFee")
 	errFeeOverflow       = errors.New("overflow occurred while calculating the fee")
+	errTxGasTooHigh      = errors.New("atomic tx gas exceeds the maximum atomic tx gas")
+	errTooManyOwnerAddrs = errors.New("output owner has too many addresses")
+	errHighSSignature    = errors.New("signature has a high S value and is malleable")
 )
 
-// Constants for calculating the gas consumed by atomic transactions
+// secp256k1HalfN is half of the order of the secp256k1 curve. A signature
+// with an S value above this is the malleated form of a canonical signature.
+var secp256k1HalfN = new(big.Int).Rsh(ethcrypto.S256().Params().N, 1)
+
+// maxAtomicTxGas is the maximum amount of gas that a single atomic tx may
+// consume as of ApricotPhase5. A tx consuming more than this could never be
+// included in a block.
+var maxAtomicTxGas = params.AtomicGasLimit.Uint64()
+
+// Constants for calculating the gas consumed by atomic transactions under
+// [params.DefaultAtomicTxFeeConfig]
 var (
-	TxBytesGas   uint64 = 1
-	EVMOutputGas uint64 = (common.AddressLength + wrappers.LongLen + hashing.HashLen) * TxBytesGas
-	EVMInputGas  uint64 = (common.AddressLength+wrappers.LongLen+hashing.HashLen+wrappers.LongLen)*TxBytesGas + secp256k1fx.CostPerSignature
+	TxBytesGas   uint64 = params.DefaultAtomicTxFeeConfig.TxBytesGas
+	EVMOutputGas uint64 = evmOutputGas(params.DefaultAtomicTxFeeConfig)
+	EVMInputGas  uint64 = evmInputGas(params.DefaultAtomicTxFeeConfig)
 )
 
+// evmOutputGas returns the gas consumed by an EVMOutput under [fees].
+func evmOutputGas(fees params.AtomicTxFeeConfig) uint64 {
+	return (common.AddressLength + wrappers.LongLen + hashing.HashLen) * fees.TxBytesGas
+}
+
+// evmInputGas returns the gas consumed by a signed EVMInput under [fees].
+func evmInputGas(fees params.AtomicTxFeeConfig) uint64 {
+	return (common.AddressLength+wrappers.LongLen+hashing.HashLen+wrappers.LongLen)*fees.TxBytesGas + secp256k1fx.CostPerSignature
+}
+
 // EVMOutput defines an output that is added to the EVM state created by import transactions
 type EVMOutput struct {
 	Address common.Address `serialize:"true" json:"address"`
@@ -88,26 +117,125 @@ func (in *EVMInput) Verify() error {
 	return nil
 }
 
+// verifyAtomicTxGas returns an error if [tx] consumes more than
+// [maxAtomicTxGas] as of ApricotPhase5.
+func verifyAtomicTxGas(tx UnsignedTx, rules params.Rules) error {
+	if !rules.IsApricotPhase5 {
+		return nil
+	}
+	gasUsed, err := tx.GasUsed(rules.AtomicTxFeeConfig, true)
+	if err != nil {
+		return err
+	}
+	if gasUsed > maxAtomicTxGas {
+		return fmt.Errorf("%w: %d > %d", errTxGasTooHigh, gasUsed, maxAtomicTxGas)
+	}
+	return nil
+}
+
+// maxOutputOwnerAddrs returns the maximum number of addresses that an output
+// owner may list as of ApricotPhase5 under [fees]. Each address adds at least
+// [ids.ShortIDLen] bytes to the tx, so an owner with more addresses than this
+// would push the tx over [maxAtomicTxGas] by itself.
+func maxOutputOwnerAddrs(fees params.AtomicTxFeeConfig) int {
+	return int(maxAtomicTxGas / (ids.ShortIDLen * fees.TxBytesGas))
+}
+
+// verifyOutputOwnerAddrs returns an error if [out] lists more than
+// [maxOutputOwnerAddrs] addresses as of ApricotPhase5. This rejects only txs
+// that would fail [verifyAtomicTxGas], but does so without serializing them.
+func verifyOutputOwnerAddrs(out *avax.TransferableOutput, rules params.Rules) error {
+	if !rules.IsApricotPhase5 {
+		return nil
+	}
+	transferOut, ok := out.Out.(*secp256k1fx.TransferOutput)
+	if !ok {
+		return nil
+	}
+	if maxAddrs := maxOutputOwnerAddrs(rules.AtomicTxFeeConfig); len(transferOut.Addrs) > maxAddrs {
+		return fmt.Errorf("%w: %d > %d", errTooManyOwnerAddrs, len(transferOut.Addrs), maxAddrs)
+	}
+	return nil
+}
+
+// verifyLowS returns an error if [sig] does not have a canonical (low) S
+// value. Negating S yields a second valid signature over the same message,
+// which would give the same tx a different txID.
+//
+// Note: [crypto.FactorySECP256K1R] already refuses to recover a public key
+// from a high S signature, so this check does not change which txs are
+// valid and does not need to be gated on an upgrade. It exists so that the
+// rejection is explicit and reported with a descriptive error.
+func verifyLowS(sig [crypto.SECP256K1RSigLen]byte) error {
+	// Signatures are formatted as [r || s || v]
+	s := new(big.Int).SetBytes(sig[32:64])
+	if s.Cmp(secp256k1HalfN) > 0 {
+		return errHighSSignature
+	}
+	return nil
+}
+
 // UnsignedTx is an unsigned transaction
 type UnsignedTx interface {
 	Initialize(unsignedBytes, signedBytes []byte)
+	// ID returns the hash of the signed bytes, which is computed once by
+	// [Initialize] so that it is not recomputed on every call.
 	ID() ids.ID
-	GasUsed(fixedFee bool) (uint64, error)
+	GasUsed(fees params.AtomicTxFeeConfig, fixedFee bool) (uint64, error)
 	Burned(assetID ids.ID) (uint64, error)
 	UnsignedBytes() []byte
 	Bytes() []byte
 }
 
+// AtomicTxType identifies the kind of an [UnsignedAtomicTx] without requiring
+// a type switch on its concrete type.
+type AtomicTxType uint8
+
+const (
+	UnknownTxType AtomicTxType = iota
+	ImportTxType
+	ExportTxType
+)
+
+// String returns the label used for [t] in logs and metrics.
+func (t AtomicTxType) String() string {
+	switch t {
+	case ImportTxType:
+		return "import"
+	case ExportTxType:
+		return "export"
+	default:
+		return "unknown"
+	}
+}
+
+// SemanticVerifyOptions adjusts the checks performed by SemanticVerify. The
+// zero value performs every check. Blocks must be verified with options that
+// don't skip any checks.
+type SemanticVerifyOptions struct {
+	// SkipFlowCheck skips checking that the tx consumes enough funds to cover
+	// its outputs and fee. This allows simulating a tx against a future state.
+	SkipFlowCheck bool
+	// FeeCache, if non-nil, caches the fees calculated while verifying the tx,
+	// so that they are not calculated again for the other txs of the block.
+	FeeCache *atomicFeeCache
+}
+
 // UnsignedAtomicTx is an unsigned operation that can be atomically accepted
 type UnsignedAtomicTx interface {
 	UnsignedTx
 
+	// Type returns the kind of this tx
+	Type() AtomicTxType
 	// InputUTXOs returns the UTXOs this tx consumes
 	InputUTXOs() ids.Set
 	// Verify attempts to verify that the transaction is well formed
 	Verify(ctx *snow.Context, rules params.Rules) error
 	// Attempts to verify this transaction with the provided state.
 	SemanticVerify(vm *VM, stx *Tx, parent *Block, baseFee *big.Int, rules params.Rules) error
+	// SemanticVerifyWithOptions is SemanticVerify with the checks performed
+	// adjusted according to [opts].
+	SemanticVerifyWithOptions(vm *VM, stx *Tx, parent *Block, baseFee *big.Int, rules params.Rules, opts SemanticVerifyOptions) error
 	// AtomicOps returns the blockchainID and set of atomic requests that
 	// must be applied to shared memory for this transaction to be accepted.
 	// The set of atomic requests must be returned in a consistent order.
@@ -160,14 +288,14 @@ func (tx *Tx) Sign(c codec.Manager, signers [][]*crypto.PrivateKeySECP256K1R) er
 // for via this transaction denominated in [avaxAssetID] with [baseFee] used to calculate the
 // cost of this transaction. This function also returns the [gasUsed] by the
 // transaction for inclusion in the [baseFee] algorithm.
-func (tx *Tx) BlockFeeContribution(fixedFee bool, avaxAssetID ids.ID, baseFee *big.Int) (*big.Int, *big.Int, error) {
+func (tx *Tx) BlockFeeContribution(fees params.AtomicTxFeeConfig, fixedFee bool, avaxAssetID ids.ID, baseFee *big.Int) (*big.Int, *big.Int, error) {
 	if baseFee == nil {
 		return nil, nil, errNilBaseFee
 	}
 	if baseFee.Cmp(common.Big0) <= 0 {
 		return nil, nil, fmt.Errorf("cannot calculate tip with base fee %d <= 0", baseFee)
 	}
-	gasUsed, err := tx.GasUsed(fixedFee)
+	gasUsed, err := tx.GasUsed(fees, fixedFee)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -190,18 +318,33 @@ func (tx *Tx) BlockFeeContribution(fixedFee bool, avaxAssetID ids.ID, baseFee *b
 	return blockFeeContribution, new(big.Int).SetUint64(gasUsed), nil
 }
 
+// compareEVMInputKeys compares EVMInputs [a] and [b] by address and then by
+// assetID, which must be unique across the inputs of a tx.
+func compareEVMInputKeys(a, b *EVMInput) int {
+	if addrComp := bytes.Compare(a.Address.Bytes(), b.Address.Bytes()); addrComp != 0 {
+		return addrComp
+	}
+	return bytes.Compare(a.AssetID[:], b.AssetID[:])
+}
+
 // innerSortInputsAndSigners implements sort.Interface for EVMInput
 type innerSortInputsAndSigners struct {
 	inputs  []EVMInput
 	signers [][]*crypto.PrivateKeySECP256K1R
 }
 
+// Less orders inputs by address, then assetID, then nonce, then amount, so
+// that inputs sharing an address and assetID are still sorted the same way
+// by every node.
 func (ins *innerSortInputsAndSigners) Less(i, j int) bool {
-	addrComp := bytes.Compare(ins.inputs[i].Address.Bytes(), ins.inputs[j].Address.Bytes())
-	if addrComp != 0 {
-		return addrComp < 0
+	a, b := &ins.inputs[i], &ins.inputs[j]
+	if keyComp := compareEVMInputKeys(a, b); keyComp != 0 {
+		return keyComp < 0
+	}
+	if a.Nonce != b.Nonce {
+		return a.Nonce < b.Nonce
 	}
-	return bytes.Compare(ins.inputs[i].AssetID[:], ins.inputs[j].AssetID[:]) < 0
+	return a.Amount < b.Amount
 }
 
 func (ins *innerSortInputsAndSigners) Len() int { return len(ins.inputs) }
@@ -211,15 +354,32 @@ func (ins *innerSortInputsAndSigners) Swap(i, j int) {
 	ins.signers[j], ins.signers[i] = ins.signers[i], ins.signers[j]
 }
 
-// SortEVMInputsAndSigners sorts the list of EVMInputs based on the addresses and assetIDs
+// SortEVMInputsAndSigners sorts the list of EVMInputs based on the addresses,
+// assetIDs, nonces, and amounts, and permutes [signers] to match
 func SortEVMInputsAndSigners(inputs []EVMInput, signers [][]*crypto.PrivateKeySECP256K1R) {
 	sort.Sort(&innerSortInputsAndSigners{inputs: inputs, signers: signers})
 }
 
+// innerSortEVMInputKeys implements sort.Interface for EVMInput, comparing only
+// the addresses and assetIDs
+type innerSortEVMInputKeys struct {
+	inputs []EVMInput
+}
+
+func (ins *innerSortEVMInputKeys) Less(i, j int) bool {
+	return compareEVMInputKeys(&ins.inputs[i], &ins.inputs[j]) < 0
+}
+
+func (ins *innerSortEVMInputKeys) Len() int { return len(ins.inputs) }
+
+func (ins *innerSortEVMInputKeys) Swap(i, j int) {
+	ins.inputs[j], ins.inputs[i] = ins.inputs[i], ins.inputs[j]
+}
+
 // IsSortedAndUniqueEVMInputs returns true if the EVM Inputs are sorted and unique
-// based on the account addresses
+// based on the account addresses and assetIDs
 func IsSortedAndUniqueEVMInputs(inputs []EVMInput) bool {
-	return utils.IsSortedAndUnique(&innerSortInputsAndSigners{inputs: inputs})
+	return utils.IsSortedAndUnique(&innerSortEVMInputKeys{inputs: inputs})
 }
 
 // innerSortEVMOutputs implements sort.Interface for EVMOutput
@@ -261,10 +421,19 @@ func IsSortedAndUniqueEVMOutputs(outputs []EVMOutput) bool {
 
 // calculates the amount of AVAX that must be burned by an atomic transaction
 // that consumes [cost] at [baseFee].
+//
+// [baseFee] is denominated in wei, while the fee is denominated in nAVAX, so
+// the fee in wei is divided by [x2cRate]. Any remainder is always rounded up,
+// so that a tx never burns less than [cost] * [baseFee]. Only integer math is
+// used, so the result is the same on every node.
 func calculateDynamicFee(cost uint64, baseFee *big.Int) (uint64, error) {
 	if baseFee == nil {
 		return 0, errNilBaseFee
 	}
+	// A negative fee would be rounded towards negative infinity rather than up
+	if baseFee.Sign() < 0 {
+		return 0, errNegativeBaseFee
+	}
 	bigCost := new(big.Int).SetUint64(cost)
 	fee := new(big.Int).Mul(bigCost, baseFee)
 	feeToRoundUp := new(big.Int).Add(fee, x2cRateMinus1)
@@ -276,8 +445,121 @@ func calculateDynamicFee(cost uint64, baseFee *big.Int) (uint64, error) {
 	return feeInNAVAX.Uint64(), nil
 }
 
-func calcBytesCost(len int) uint64 {
-	return uint64(len) * TxBytesGas
+// assetFlowChecker is an [avax.FlowChecker] that also tracks the amount of
+// each asset that is consumed and produced, so that a failed flow check names
+// the asset that is produced beyond what is consumed.
+type assetFlowChecker struct {
+	*avax.FlowChecker
+
+	consumed, produced map[ids.ID]uint64
+	// [assetIDs] is every asset consumed or produced, in the order that they
+	// were first seen
+	assetIDs []ids.ID
+}
+
+func newAssetFlowChecker() *assetFlowChecker {
+	return &assetFlowChecker{
+		FlowChecker: avax.NewFlowChecker(),
+		consumed:    make(map[ids.ID]uint64),
+		produced:    make(map[ids.ID]uint64),
+	}
+}
+
+func (fc *assetFlowChecker) Consume(assetID ids.ID, amount uint64) {
+	fc.FlowChecker.Consume(assetID, amount)
+	fc.add(fc.consumed, assetID, amount)
+}
+
+func (fc *assetFlowChecker) Produce(assetID ids.ID, amount uint64) {
+	fc.FlowChecker.Produce(assetID, amount)
+	fc.add(fc.produced, assetID, amount)
+}
+
+// add adds [amount] of [assetID] to [amounts].
+func (fc *assetFlowChecker) add(amounts map[ids.ID]uint64, assetID ids.ID, amount uint64) {
+	if _, consumed := fc.consumed[assetID]; !consumed {
+		if _, produced := fc.produced[assetID]; !produced {
+			fc.assetIDs = append(fc.assetIDs, assetID)
+		}
+	}
+	total, err := math.Add64(amounts[assetID], amount)
+	if err != nil {
+		// The overflow is reported by the embedded [avax.FlowChecker]
+		return
+	}
+	amounts[assetID] = total
+}
+
+// Verify returns an error if more of any asset is produced than consumed. If
+// so, the error names the first such asset.
+func (fc *assetFlowChecker) Verify() error {
+	err := fc.FlowChecker.Verify()
+	if err == nil {
+		return nil
+	}
+	for _, assetID := range fc.assetIDs {
+		if consumed, produced := fc.consumed[assetID], fc.produced[assetID]; produced > consumed {
+			return fmt.Errorf("%w: asset %s is produced %d but consumed %d", err, assetID, produced, consumed)
+		}
+	}
+	return err
+}
+
+// atomicFeeCache caches the fees calculated by calculateDynamicFee while
+// verifying the atomic txs of a single block. Since every tx in a block is
+// verified with the same base fee, txs that use the same amount of gas pay the
+// same fee. It is safe for concurrent use.
+type atomicFeeCache struct {
+	lock sync.Mutex
+	fees map[atomicFeeKey]uint64
+	// [calculated] is the number of fees that were calculated rather than
+	// served from the cache
+	calculated int
+}
+
+type atomicFeeKey struct {
+	cost    uint64
+	baseFee string
+}
+
+func newAtomicFeeCache() *atomicFeeCache {
+	return &atomicFeeCache{
+		fees: make(map[atomicFeeKey]uint64),
+	}
+}
+
+// calculateDynamicFee returns calculateDynamicFee([cost], [baseFee]), only
+// calculating it if it's not already cached. If [c] is nil, the fee is always
+// calculated.
+func (c *atomicFeeCache) calculateDynamicFee(cost uint64, baseFee *big.Int) (uint64, error) {
+	// Invalid base fees are never cached, as [Bytes] drops the sign of the
+	// base fee
+	if c == nil || baseFee == nil || baseFee.Sign() < 0 {
+		return calculateDynamicFee(cost, baseFee)
+	}
+
+	key := atomicFeeKey{
+		cost:    cost,
+		baseFee: string(baseFee.Bytes()),
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if fee, ok := c.fees[key]; ok {
+		return fee, nil
+	}
+	fee, err := calculateDynamicFee(cost, baseFee)
+	if err != nil {
+		return 0, err
+	}
+	c.calculated++
+	c.fees[key] = fee
+	return fee, nil
+}
+
+func calcBytesCost(fees params.AtomicTxFeeConfig, len int) uint64 {
+	return uint64(len) * fees.TxBytesGas
 }
 
 // mergeAtomicOps merges atomic requests represented by [txs]
@@ -4,13 +4,22 @@
 package evm
 
 import (
+	"errors"
+	"math"
 	"math/big"
 	"strings"
 	"testing"
 
 	"github.com/ava-labs/avalanchego/chains/atomic"
+	"github.com/ava-labs/avalanchego/ids"
 	"github.com/ava-labs/avalanchego/snow"
+	"github.com/ava-labs/avalanchego/utils/crypto"
+	"github.com/ava-labs/avalanchego/utils/hashing"
+	"github.com/ava-labs/avalanchego/vms/components/avax"
+	"github.com/ava-labs/avalanchego/vms/secp256k1fx"
 	"github.com/ava-labs/coreth/params"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/metrics"
 )
 
 func TestCalculateDynamicFee(t *testing.T) {
@@ -31,6 +40,57 @@ func TestCalculateDynamicFee(t *testing.T) {
 			baseFee:       big.NewInt(25 * params.GWei),
 			expectedValue: 525000,
 		},
+		{
+			gas:           0,
+			baseFee:       big.NewInt(25 * params.GWei),
+			expectedValue: 0,
+		},
+		{
+			gas:           21000,
+			baseFee:       big.NewInt(0),
+			expectedValue: 0,
+		},
+		// Fees that are not a multiple of [x2cRate] are rounded up
+		{
+			gas:           1,
+			baseFee:       big.NewInt(1),
+			expectedValue: 1,
+		},
+		{
+			gas:           1,
+			baseFee:       new(big.Int).Sub(x2cRate, common.Big1),
+			expectedValue: 1,
+		},
+		{
+			gas:           1,
+			baseFee:       new(big.Int).Add(x2cRate, common.Big1),
+			expectedValue: 2,
+		},
+		{
+			gas:           3,
+			baseFee:       new(big.Int).Div(x2cRate, big.NewInt(3)),
+			expectedValue: 1,
+		},
+		{
+			gas:           math.MaxUint64,
+			baseFee:       new(big.Int).Set(x2cRate),
+			expectedValue: math.MaxUint64,
+		},
+		{
+			gas:         math.MaxUint64,
+			baseFee:     new(big.Int).Add(x2cRate, common.Big1),
+			expectedErr: errFeeOverflow,
+		},
+		{
+			gas:         21000,
+			baseFee:     nil,
+			expectedErr: errNilBaseFee,
+		},
+		{
+			gas:         21000,
+			baseFee:     big.NewInt(-1),
+			expectedErr: errNegativeBaseFee,
+		},
 	}
 
 	for _, test := range tests {
@@ -50,6 +110,110 @@ func TestCalculateDynamicFee(t *testing.T) {
 	}
 }
 
+func TestAtomicFeeCache(t *testing.T) {
+	cache := newAtomicFeeCache()
+	baseFee := big.NewInt(25 * params.GWei)
+	for i := 0; i < 3; i++ {
+		fee, err := cache.calculateDynamicFee(21000, baseFee)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fee != 525000 {
+			t.Fatalf("Expected fee: %d, found: %d", 525000, fee)
+		}
+	}
+	if cache.calculated != 1 {
+		t.Fatalf("Expected the fee to be calculated once, but it was calculated %d times", cache.calculated)
+	}
+
+	// A different amount of gas or base fee is calculated separately
+	if fee, err := cache.calculateDynamicFee(42000, baseFee); err != nil || fee != 1050000 {
+		t.Fatalf("Expected fee: %d, found: %d, err: %v", 1050000, fee, err)
+	}
+	if fee, err := cache.calculateDynamicFee(21000, big.NewInt(50*params.GWei)); err != nil || fee != 1050000 {
+		t.Fatalf("Expected fee: %d, found: %d, err: %v", 1050000, fee, err)
+	}
+	if cache.calculated != 3 {
+		t.Fatalf("Expected 3 fees to be calculated, but found %d", cache.calculated)
+	}
+
+	// Invalid base fees are never cached
+	if _, err := cache.calculateDynamicFee(21000, big.NewInt(-1)); err != errNegativeBaseFee {
+		t.Fatalf("Expected error: %s, found error: %v", errNegativeBaseFee, err)
+	}
+	if _, err := cache.calculateDynamicFee(21000, nil); err != errNilBaseFee {
+		t.Fatalf("Expected error: %s, found error: %v", errNilBaseFee, err)
+	}
+
+	// A nil cache always calculates the fee
+	var nilCache *atomicFeeCache
+	if fee, err := nilCache.calculateDynamicFee(21000, baseFee); err != nil || fee != 525000 {
+		t.Fatalf("Expected fee: %d, found: %d, err: %v", 525000, fee, err)
+	}
+}
+
+func TestVerifyAtomicTxGas(t *testing.T) {
+	ctx := NewContext()
+	// newExportTx returns an initialized export tx spending from [numIns]
+	// distinct addresses.
+	newExportTx := func(numIns int) *UnsignedExportTx {
+		tx := &UnsignedExportTx{
+			NetworkID:        ctx.NetworkID,
+			BlockchainID:     ctx.ChainID,
+			DestinationChain: ctx.XChainID,
+			ExportedOutputs: []*avax.TransferableOutput{{
+				Asset: avax.Asset{ID: ctx.AVAXAssetID},
+				Out: &secp256k1fx.TransferOutput{
+					Amt: uint64(numIns),
+					OutputOwners: secp256k1fx.OutputOwners{
+						Threshold: 1,
+						Addrs:     testShortIDAddrs[:1],
+					},
+				},
+			}},
+		}
+		for i := 0; i < numIns; i++ {
+			tx.Ins = append(tx.Ins, EVMInput{
+				Address: common.BigToAddress(big.NewInt(int64(i + 1))),
+				Amount:  1,
+				AssetID: ctx.AVAXAssetID,
+			})
+		}
+		var utx UnsignedAtomicTx = tx
+		unsignedBytes, err := Codec.Marshal(codecVersion, &utx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tx.Initialize(unsignedBytes, unsignedBytes)
+		return tx
+	}
+
+	// Find the largest number of inputs that does not exceed [maxAtomicTxGas]
+	numIns := 1
+	for {
+		gasUsed, err := newExportTx(numIns+1).GasUsed(params.DefaultAtomicTxFeeConfig, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if gasUsed > maxAtomicTxGas {
+			break
+		}
+		numIns++
+	}
+
+	if err := newExportTx(numIns).Verify(ctx, apricotRulesPhase5); err != nil {
+		t.Fatalf("Expected tx under the max atomic tx gas to pass verification, but failed due to: %s", err)
+	}
+	overTx := newExportTx(numIns + 1)
+	if err := overTx.Verify(ctx, apricotRulesPhase5); !errors.Is(err, errTxGasTooHigh) {
+		t.Fatalf("Expected tx over the max atomic tx gas to fail verification due to %s, but found: %v", errTxGasTooHigh, err)
+	}
+	// The max atomic tx gas is only enforced as of ApricotPhase5
+	if err := overTx.Verify(ctx, apricotRulesPhase4); err != nil {
+		t.Fatalf("Expected tx over the max atomic tx gas to pass verification prior to ApricotPhase5, but failed due to: %s", err)
+	}
+}
+
 type atomicTxVerifyTest struct {
 	ctx         *snow.Context
 	generate    func(t *testing.T) UnsignedAtomicTx
@@ -177,3 +341,109 @@ func executeTxTest(t *testing.T, test atomicTxTest) {
 		test.checkState(t, vm)
 	}
 }
+
+func TestAtomicTxType(t *testing.T) {
+	tests := []struct {
+		tx            UnsignedAtomicTx
+		expectedType  AtomicTxType
+		expectedLabel string
+	}{
+		{&UnsignedImportTx{}, ImportTxType, "import"},
+		{&UnsignedExportTx{}, ExportTxType, "export"},
+		{&TestTx{}, UnknownTxType, "unknown"},
+	}
+	for _, test := range tests {
+		if txType := test.tx.Type(); txType != test.expectedType {
+			t.Fatalf("expected %T to have type %s, but found %s", test.tx, test.expectedType, txType)
+		}
+		if label := test.tx.Type().String(); label != test.expectedLabel {
+			t.Fatalf("expected %T to have label %q, but found %q", test.tx, test.expectedLabel, label)
+		}
+	}
+
+	// The metric names are part of the node's external interface, so make
+	// sure deriving them from the tx type does not change them.
+	registry := metrics.NewRegistry()
+	newAtomicTxMetrics(registry)
+	for _, name := range []string{
+		"atomic/tx/semantic_verify_duration/import",
+		"atomic/tx/semantic_verify_duration/export",
+		"atomic/tx/sig_recovery_duration/import",
+		"atomic/tx/sig_recovery_duration/export",
+	} {
+		if registry.Get(name) == nil {
+			t.Fatalf("expected metric %q to be registered", name)
+		}
+	}
+}
+
+func TestAtomicTxIDReinitialized(t *testing.T) {
+	tx := newTestAtomicTx(t)
+	if expected := ids.ID(hashing.ComputeHash256Array(tx.Bytes())); tx.ID() != expected {
+		t.Fatalf("expected ID %s, but found %s", expected, tx.ID())
+	}
+
+	// Re-initializing the tx with different bytes must not return the
+	// previously computed ID
+	otherTx := newTestAtomicTx(t)
+	tx.Initialize(otherTx.UnsignedBytes(), otherTx.Bytes())
+	if tx.ID() != otherTx.ID() {
+		t.Fatalf("expected ID %s after re-initializing, but found %s", otherTx.ID(), tx.ID())
+	}
+}
+
+// BenchmarkAtomicTxID compares reading the ID of an initialized tx to
+// rehashing its bytes.
+func BenchmarkAtomicTxID(b *testing.B) {
+	tx := newTestAtomicTx(b)
+
+	b.Run("cached", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			_ = tx.ID()
+		}
+	})
+	b.Run("rehashed", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			_ = ids.ID(hashing.ComputeHash256Array(tx.Bytes()))
+		}
+	})
+}
+
+// Inputs that share an address and assetID are ordered by nonce and then by
+// amount, and the signers are kept aligned with their inputs.
+func TestSortEVMInputsAndSigners(t *testing.T) {
+	addr := common.Address{2}
+	assetID := ids.ID{1}
+	inputs := []EVMInput{
+		{Address: addr, AssetID: assetID, Nonce: 1, Amount: 5},
+		{Address: addr, AssetID: assetID, Nonce: 0, Amount: 7},
+		{Address: addr, AssetID: assetID, Nonce: 0, Amount: 3},
+		{Address: common.Address{1}, AssetID: assetID, Nonce: 2, Amount: 1},
+	}
+	signers := [][]*crypto.PrivateKeySECP256K1R{
+		{testKeys[0]},
+		{testKeys[1]},
+		{testKeys[2]},
+		{testKeys[0], testKeys[1]},
+	}
+	expectedInputs := []EVMInput{inputs[3], inputs[2], inputs[1], inputs[0]}
+	expectedSigners := [][]*crypto.PrivateKeySECP256K1R{signers[3], signers[2], signers[1], signers[0]}
+
+	SortEVMInputsAndSigners(inputs, signers)
+	for i := range inputs {
+		if inputs[i] != expectedInputs[i] {
+			t.Fatalf("expected input %d to be %+v, but found %+v", i, expectedInputs[i], inputs[i])
+		}
+		if len(signers[i]) != len(expectedSigners[i]) || signers[i][0] != expectedSigners[i][0] {
+			t.Fatalf("expected signers of input %d to be aligned with the input", i)
+		}
+	}
+
+	// Inputs sharing an address and assetID are still not unique
+	if IsSortedAndUniqueEVMInputs(inputs) {
+		t.Fatal("expected inputs sharing an address and assetID not to be unique")
+	}
+	if !IsSortedAndUniqueEVMInputs(inputs[:2]) {
+		t.Fatal("expected inputs with distinct addresses to be sorted and unique")
+	}
+}
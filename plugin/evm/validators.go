@@ -0,0 +1,69 @@
+// (c) 2021, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package evm
+
+import (
+	"sync"
+	"time"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/snow/validators"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// [validatorSetRefreshInterval] is how long the validator set fetched by
+// [validatorSet] is used before it is fetched again.
+const validatorSetRefreshInterval = 30 * time.Second
+
+// validatorSet caches the current validators of a subnet, so that checking
+// whether a peer is a validator does not query the P-chain for every message.
+type validatorSet struct {
+	state    validators.State
+	subnetID ids.ID
+
+	lock        sync.Mutex
+	validators  map[ids.ShortID]uint64
+	lastRefresh time.Time
+}
+
+func newValidatorSet(state validators.State, subnetID ids.ID) *validatorSet {
+	return &validatorSet{
+		state:    state,
+		subnetID: subnetID,
+	}
+}
+
+// contains returns true if [nodeID] is a current validator of the subnet. If
+// the validator set can't be fetched, the last fetched set is used, and if no
+// set was ever fetched, no node is considered a validator.
+func (v *validatorSet) contains(nodeID ids.ShortID) bool {
+	v.lock.Lock()
+	defer v.lock.Unlock()
+
+	if now := time.Now(); now.Sub(v.lastRefresh) >= validatorSetRefreshInterval {
+		if err := v.refresh(); err != nil {
+			log.Debug("failed to fetch the current validator set", "subnetID", v.subnetID, "err", err)
+		}
+		// Wait for the next interval before retrying, so that a failing
+		// P-chain is not queried for every message.
+		v.lastRefresh = now
+	}
+	_, ok := v.validators[nodeID]
+	return ok
+}
+
+// refresh fetches the current validator set.
+// Assumes [lock] is held.
+func (v *validatorSet) refresh() error {
+	height, err := v.state.GetCurrentHeight()
+	if err != nil {
+		return err
+	}
+	vdrs, err := v.state.GetValidatorSet(height, v.subnetID)
+	if err != nil {
+		return err
+	}
+	v.validators = vdrs
+	return nil
+}
@@ -91,11 +91,14 @@ var (
 const (
 	// Max time from current time allowed for blocks, before they're considered future blocks
 	// and fail verification
-	maxFutureBlockTime   = 10 * time.Second
-	maxUTXOsToFetch      = 1024
-	defaultMempoolSize   = 4096
-	codecVersion         = uint16(0)
-	secpFactoryCacheSize = 1024
+	maxFutureBlockTime = 10 * time.Second
+	maxUTXOsToFetch    = 1024
+	defaultMempoolSize = 4096
+	codecVersion       = uint16(0)
+
+	// maxExportFeeIterations is the number of times the fee of an export tx
+	// of an entire balance is recomputed before giving up.
+	maxExportFeeIterations = 8
 
 	decidedCacheSize    = 100
 	missingCacheSize    = 50
@@ -134,12 +137,21 @@ var (
 	errInputsNotSortedUnique          = errors.New("inputs not sorted and unique")
 	errPublicKeySignatureMismatch     = errors.New("signature doesn't match public key")
 	errWrongChainID                   = errors.New("tx has wrong chain ID")
+	errWrongAVAXAssetID               = errors.New("tx exports a non-AVAX asset to a chain that only supports AVAX")
 	errInsufficientFunds              = errors.New("insufficient funds")
 	errNoExportOutputs                = errors.New("tx has no export outputs")
+	errNoExportInputs                 = errors.New("tx has no export inputs")
 	errOutputsNotSorted               = errors.New("tx outputs not sorted")
 	errOutputsNotSortedUnique         = errors.New("outputs not sorted and unique")
 	errOverflowExport                 = errors.New("overflow when computing export amount + txFee")
+	errExportFeeNotConverged          = errors.New("export tx fee did not converge")
+	errOverflowExportedOutputs        = errors.New("overflow when summing exported outputs")
+	errLocktimeNotInFuture            = errors.New("locktime is not in the future")
+	errCancelNonPendingTx             = errors.New("only atomic txs pending in the mempool can be canceled")
 	errInvalidNonce                   = errors.New("invalid nonce")
+	errSignatureInputsMismatch        = errors.New("mismatched number of inputs/credentials")
+	errDevModeGossipOnMainnet         = errors.New("dev-mode-gossip-always-active may not be enabled on mainnet")
+	errTooManyOutstandingAppRequests  = errors.New("too many outstanding app requests")
 	errConflictingAtomicInputs        = errors.New("invalid block due to conflicting atomic inputs")
 	errUnclesUnsupported              = errors.New("uncles unsupported")
 	errTxHashMismatch                 = errors.New("txs hash does not match header")
@@ -158,6 +170,7 @@ var (
 	errConflictingAtomicTx            = errors.New("conflicting atomic tx present")
 	errTooManyAtomicTx                = errors.New("too many atomic tx")
 	errMissingAtomicTxs               = errors.New("cannot build a block with non-empty extra data and zero atomic transactions")
+	errImportAssetNotAllowed          = errors.New("asset is not allowed to be imported")
 )
 
 var originalStderr *os.File
@@ -215,18 +228,40 @@ type VM struct {
 	fx          secp256k1fx.Fx
 	secpFactory crypto.FactorySECP256K1R
 
+	atomicTxMetrics *atomicTxMetrics
+
+	// [allowedImportAssets] is the set of non-AVAX assets that import txs
+	// issued to the mempool may import. If empty, any asset may be imported.
+	allowedImportAssets ids.Set
+	// [gossipPeerAllowlist] and [gossipPeerDenylist] restrict the peers that
+	// the network gossips with.
+	gossipPeerAllowlist ids.ShortSet
+	gossipPeerDenylist  ids.ShortSet
+
 	// Continuous Profiler
 	profiler profiler.ContinuousProfiler
 
+	// [atomicTxAcceptedCallbacks] are invoked with each accepted atomic tx
+	// and the IDs of the UTXOs it consumed or produced in shared memory.
+	atomicTxAcceptedCallbacksLock sync.RWMutex
+	atomicTxAcceptedCallbacks     []func(txID ids.ID, utxoIDs []ids.ID)
+
+	// [peerAcceptedHeight] is the highest block height that a peer announced
+	// it accepted, which is reported by [HealthCheck] and [blocksBehindPeers]
+	// once it is above the last accepted block of this node.
+	peerAcceptedHeightLock sync.Mutex
+	peerAcceptedHeight     uint64
+	blocksBehindPeers      metrics.Gauge
+
 	bootstrapped bool
 }
 
 func (vm *VM) Connected(id ids.ShortID, nodeVersion version.Application) error {
-	return nil // noop
+	return vm.network.Connected(id, nodeVersion)
 }
 
 func (vm *VM) Disconnected(nodeID ids.ShortID) error {
-	return nil // noop
+	return vm.network.Disconnected(nodeID)
 }
 
 // Codec implements the secp256k1fx interface
@@ -279,6 +314,9 @@ func (vm *VM) Initialize(
 			return fmt.Errorf("failed to unmarshal config %s: %w", string(configBytes), err)
 		}
 	}
+	if vm.config.EthTxAnnouncementsEnabled && vm.config.GossipMode == GossipModePush {
+		vm.config.GossipMode = GossipModePull
+	}
 	if b, err := json.Marshal(vm.config); err == nil {
 		log.Info("Initializing Coreth VM", "Version", Version, "Config", string(b))
 	} else {
@@ -290,8 +328,52 @@ func (vm *VM) Initialize(
 		return errUnsupportedFXs
 	}
 
+	allowedImportAssets, err := vm.config.AllowedImportAssetIDs()
+	if err != nil {
+		return err
+	}
+	vm.allowedImportAssets = allowedImportAssets
+	if vm.gossipPeerAllowlist, err = vm.config.GossipPeerAllowlistIDs(); err != nil {
+		return err
+	}
+	if vm.gossipPeerDenylist, err = vm.config.GossipPeerDenylistIDs(); err != nil {
+		return err
+	}
+	if vm.config.AtomicTxVerifyWorkers < 1 {
+		return fmt.Errorf("atomic-tx-verify-workers must be at least 1, but found %d", vm.config.AtomicTxVerifyWorkers)
+	}
+	if vm.config.GossipFanout < 0 {
+		return fmt.Errorf("gossip-fanout must not be negative, but found %d", vm.config.GossipFanout)
+	}
+	if !vm.config.GossipMode.Valid() {
+		return fmt.Errorf("gossip-mode must be one of %q, %q, or %q, but found %q", GossipModePush, GossipModePull, GossipModeHybrid, vm.config.GossipMode)
+	}
+	if vm.config.InboundGossipWorkers < 0 {
+		return fmt.Errorf("inbound-gossip-workers must not be negative, but found %d", vm.config.InboundGossipWorkers)
+	}
+	if vm.config.InboundGossipWorkers > 0 && vm.config.InboundGossipQueueSize < 1 {
+		return fmt.Errorf("inbound-gossip-queue-size must be at least 1, but found %d", vm.config.InboundGossipQueueSize)
+	}
+	if vm.config.MinGossipPeers < 0 {
+		return fmt.Errorf("min-gossip-peers must not be negative, but found %d", vm.config.MinGossipPeers)
+	}
+	if vm.config.MinGossipPeersTimeout.Duration < 0 {
+		return fmt.Errorf("min-gossip-peers-timeout must not be negative, but found %s", vm.config.MinGossipPeersTimeout.Duration)
+	}
+	if vm.config.MaxOutstandingAppRequests < 0 {
+		return fmt.Errorf("max-outstanding-app-requests must not be negative, but found %d", vm.config.MaxOutstandingAppRequests)
+	}
+	if vm.config.MaxGossipTxsPerSender < 0 {
+		return fmt.Errorf("max-gossip-txs-per-sender must not be negative, but found %d", vm.config.MaxGossipTxsPerSender)
+	}
+	if vm.config.DevModeGossipAlwaysActive && ctx.NetworkID == constants.MainnetID {
+		return errDevModeGossipOnMainnet
+	}
+
 	metrics.Enabled = vm.config.MetricsEnabled
 	metrics.EnabledExpensive = vm.config.MetricsExpensiveEnabled
+	vm.atomicTxMetrics = newAtomicTxMetrics(metrics.DefaultRegistry)
+	vm.blocksBehindPeers = metrics.GetOrRegisterGauge("chain/blocks_behind_peers", nil)
 
 	vm.shutdownChan = make(chan struct{}, 1)
 	vm.ctx = ctx
@@ -351,8 +433,13 @@ func (vm *VM) Initialize(
 	ethConfig.SnapshotVerify = vm.config.SnapshotVerify
 
 	vm.chainConfig = g.Config
+	if err := vm.chainConfig.GetAtomicTxFeeConfig().Verify(); err != nil {
+		return fmt.Errorf("invalid atomic tx fee config: %w", err)
+	}
 	vm.networkID = ethConfig.NetworkId
-	vm.secpFactory = crypto.FactorySECP256K1R{Cache: cache.LRU{Size: secpFactoryCacheSize}}
+	// [secpFactory] caches the public keys recovered from atomic tx signatures,
+	// so that verifying the same tx multiple times only recovers them once.
+	vm.secpFactory = crypto.FactorySECP256K1R{Cache: cache.LRU{Size: vm.config.SecpCacheSize}}
 
 	nodecfg := node.Config{
 		CorethVersion:         Version,
@@ -364,7 +451,7 @@ func (vm *VM) Initialize(
 	vm.codec = Codec
 
 	// TODO: read size from settings
-	vm.mempool = NewMempool(ctx.AVAXAssetID, defaultMempoolSize)
+	vm.mempool = NewMempool(ctx.AVAXAssetID, defaultMempoolSize, vm.chainConfig.GetAtomicTxFeeConfig())
 
 	// Attempt to load last accepted block to determine if it is necessary to
 	// initialize state with the genesis block.
@@ -412,6 +499,9 @@ func (vm *VM) Initialize(
 	//
 	// NOTE: This network must be initialized after the atomic mempool.
 	vm.network = vm.NewNetwork(appSender)
+	if !vm.network.GossipEnabled() {
+		log.Info("tx gossip is disabled because ApricotPhase4 is not scheduled")
+	}
 
 	// start goroutines to manage block building
 	//
@@ -463,10 +553,13 @@ func (vm *VM) Initialize(
 	// 	return err
 	// }
 
-	// Only provide metrics if they are being populated.
+	// Only provide metrics if they are being populated. The metrics are still
+	// recorded if there is no registerer to provide them to, such as when the
+	// VM is embedded.
 	if metrics.Enabled {
-		gatherer := prometheus.Gatherer(metrics.DefaultRegistry)
-		if err := ctx.Metrics.Register(gatherer); err != nil {
+		if ctx.Metrics == nil {
+			log.Warn("not providing metrics as the context has no metrics registerer")
+		} else if err := ctx.Metrics.Register(prometheus.Gatherer(metrics.DefaultRegistry)); err != nil {
 			return err
 		}
 	}
@@ -492,7 +585,7 @@ func (vm *VM) preBatchOnFinalizeAndAssemble(header *types.Header, state *state.S
 		// Note: snapshot is taken inside the loop because you cannot revert to the same snapshot more than
 		// once.
 		snapshot := state.Snapshot()
-		rules := vm.chainConfig.AvalancheRules(header.Number, new(big.Int).SetUint64(header.Time))
+		rules := vm.rulesAt(header.Number, header.Time)
 		if err := vm.verifyTx(tx, header.ParentHash, header.BaseFee, state, rules); err != nil {
 			// Discard the transaction from the mempool on failed verification.
 			vm.mempool.DiscardCurrentTx(tx.ID())
@@ -509,7 +602,7 @@ func (vm *VM) preBatchOnFinalizeAndAssemble(header *types.Header, state *state.S
 		}
 		var contribution, gasUsed *big.Int
 		if rules.IsApricotPhase4 {
-			contribution, gasUsed, err = tx.BlockFeeContribution(rules.IsApricotPhase5, vm.ctx.AVAXAssetID, header.BaseFee)
+			contribution, gasUsed, err = tx.BlockFeeContribution(rules.AtomicTxFeeConfig, rules.IsApricotPhase5, vm.ctx.AVAXAssetID, header.BaseFee)
 			if err != nil {
 				return nil, nil, nil, err
 			}
@@ -532,7 +625,7 @@ func (vm *VM) postBatchOnFinalizeAndAssemble(header *types.Header, state *state.
 		batchAtomicUTXOs  ids.Set
 		batchContribution *big.Int = new(big.Int).Set(common.Big0)
 		batchGasUsed      *big.Int = new(big.Int).Set(common.Big0)
-		rules                      = vm.chainConfig.AvalancheRules(header.Number, new(big.Int).SetUint64(header.Time))
+		rules                      = vm.rulesAt(header.Number, header.Time)
 	)
 
 	for {
@@ -549,7 +642,7 @@ func (vm *VM) postBatchOnFinalizeAndAssemble(header *types.Header, state *state.
 		// Note: we do not need to check if we are in at least ApricotPhase4 here because
 		// we assume that this function will only be called when the block is in at least
 		// ApricotPhase5.
-		txContribution, txGasUsed, err = tx.BlockFeeContribution(true, vm.ctx.AVAXAssetID, header.BaseFee)
+		txContribution, txGasUsed, err = tx.BlockFeeContribution(rules.AtomicTxFeeConfig, true, vm.ctx.AVAXAssetID, header.BaseFee)
 		if err != nil {
 			return nil, nil, nil, err
 		}
@@ -646,7 +739,7 @@ func (vm *VM) onExtraStateChange(block *types.Block, state *state.StateDB) (*big
 		}
 		// If ApricotPhase4 is enabled, calculate the block fee contribution
 		if isApricotPhase4 {
-			contribution, gasUsed, err := tx.BlockFeeContribution(isApricotPhase5, vm.ctx.AVAXAssetID, block.BaseFee())
+			contribution, gasUsed, err := tx.BlockFeeContribution(vm.chainConfig.AtomicTxFeeConfigAt(timestamp), isApricotPhase5, vm.ctx.AVAXAssetID, block.BaseFee())
 			if err != nil {
 				return nil, nil, err
 			}
@@ -711,6 +804,9 @@ func (vm *VM) Shutdown() error {
 		return nil
 	}
 
+	if vm.network != nil {
+		vm.network.Shutdown()
+	}
 	close(vm.shutdownChan)
 	vm.chain.Stop()
 	vm.shutdownWg.Wait()
@@ -979,6 +1075,92 @@ func (vm *VM) conflicts(inputs ids.Set, ancestor *Block) error {
 	return nil
 }
 
+// OnAtomicTxAccepted registers [callback] to be called with the ID of every
+// atomic tx accepted by the VM and the IDs of the UTXOs that the tx removed
+// from or added to shared memory. The callback is called synchronously while
+// accepting the block, so it must return quickly and must not call back into
+// the VM.
+func (vm *VM) OnAtomicTxAccepted(callback func(txID ids.ID, utxoIDs []ids.ID)) {
+	vm.atomicTxAcceptedCallbacksLock.Lock()
+	defer vm.atomicTxAcceptedCallbacksLock.Unlock()
+
+	vm.atomicTxAcceptedCallbacks = append(vm.atomicTxAcceptedCallbacks, callback)
+}
+
+// resyncHint is called when the peer [nodeID] announces that it accepted the
+// block [hash] at [height], which is above the last accepted block of this
+// node. The consensus engine is responsible for catching up, so this only
+// surfaces how far behind this node is through [HealthCheck] and the
+// [blocksBehindPeers] metric.
+func (vm *VM) resyncHint(nodeID ids.ShortID, hash common.Hash, height uint64) {
+	vm.peerAcceptedHeightLock.Lock()
+	if height > vm.peerAcceptedHeight {
+		vm.peerAcceptedHeight = height
+	}
+	vm.peerAcceptedHeightLock.Unlock()
+
+	lastAcceptedHeight := vm.chain.LastAcceptedBlock().NumberU64()
+	vm.blocksBehindPeers.Update(int64(vm.blocksBehind(lastAcceptedHeight)))
+	log.Debug(
+		"peer announced a block above the last accepted block",
+		"peerID", nodeID,
+		"hash", hash,
+		"height", height,
+		"lastAcceptedHeight", lastAcceptedHeight,
+	)
+}
+
+// blocksBehind returns the number of blocks between [lastAcceptedHeight] and
+// the highest block height that a peer announced it accepted.
+func (vm *VM) blocksBehind(lastAcceptedHeight uint64) uint64 {
+	vm.peerAcceptedHeightLock.Lock()
+	defer vm.peerAcceptedHeightLock.Unlock()
+
+	if vm.peerAcceptedHeight <= lastAcceptedHeight {
+		return 0
+	}
+	return vm.peerAcceptedHeight - lastAcceptedHeight
+}
+
+// notifyAtomicTxsAccepted calls the callbacks registered with
+// [OnAtomicTxAccepted] for each tx in [txs].
+func (vm *VM) notifyAtomicTxsAccepted(txs []*Tx) {
+	vm.atomicTxAcceptedCallbacksLock.RLock()
+	defer vm.atomicTxAcceptedCallbacksLock.RUnlock()
+
+	if len(vm.atomicTxAcceptedCallbacks) == 0 {
+		return
+	}
+	for _, tx := range txs {
+		txID := tx.ID()
+		_, requests, err := tx.UnsignedAtomicTx.AtomicOps()
+		if err != nil {
+			log.Warn("failed to get the atomic ops of accepted atomic tx", "txID", txID, "err", err)
+			continue
+		}
+		utxoIDs := make([]ids.ID, 0, len(requests.RemoveRequests)+len(requests.PutRequests))
+		for _, key := range requests.RemoveRequests {
+			utxoID, err := ids.ToID(key)
+			if err != nil {
+				log.Warn("failed to parse UTXO ID of accepted atomic tx", "txID", txID, "err", err)
+				continue
+			}
+			utxoIDs = append(utxoIDs, utxoID)
+		}
+		for _, elem := range requests.PutRequests {
+			utxoID, err := ids.ToID(elem.Key)
+			if err != nil {
+				log.Warn("failed to parse UTXO ID of accepted atomic tx", "txID", txID, "err", err)
+				continue
+			}
+			utxoIDs = append(utxoIDs, utxoID)
+		}
+		for _, callback := range vm.atomicTxAcceptedCallbacks {
+			callback(txID, utxoIDs)
+		}
+	}
+}
+
 // getAtomicTx returns the requested transaction, status, and height.
 // If the status is Unknown, then the returned transaction will be nil.
 func (vm *VM) getAtomicTx(txID ids.ID) (*Tx, Status, uint64, error) {
@@ -987,6 +1169,9 @@ func (vm *VM) getAtomicTx(txID ids.ID) (*Tx, Status, uint64, error) {
 	} else if err != database.ErrNotFound {
 		return nil, Unknown, 0, err
 	}
+	if tx, pending := vm.mempool.GetPendingTx(txID); pending {
+		return tx, Pending, 0, nil
+	}
 	tx, dropped, found := vm.mempool.GetTx(txID)
 	switch {
 	case found && dropped:
@@ -998,6 +1183,26 @@ func (vm *VM) getAtomicTx(txID ids.ID) (*Tx, Status, uint64, error) {
 	}
 }
 
+// CancelAtomicTx removes the atomic tx [txID] from the mempool if it is still
+// waiting to be issued into a block. An error is returned if the tx is
+// accepted, is being issued into a block, or is not in the mempool.
+//
+// The tx may already have been gossiped, so it may still be accepted if
+// another node issues it into a block.
+func (vm *VM) CancelAtomicTx(txID ids.ID) error {
+	_, status, _, err := vm.getAtomicTx(txID)
+	if err != nil {
+		return err
+	}
+	// The tx may be issued into a block after its status was fetched, in
+	// which case it is no longer removed.
+	if status != Pending || !vm.mempool.RemovePendingTx(txID) {
+		return fmt.Errorf("%w: tx %s is %s", errCancelNonPendingTx, txID, status)
+	}
+	log.Debug("canceled atomic tx", "txID", txID)
+	return nil
+}
+
 // ParseAddress takes in an address and produces the ID of the chain it's for
 // the ID of the address
 func (vm *VM) ParseAddress(addrStr string) (ids.ID, ids.ShortID, error) {
@@ -1028,6 +1233,12 @@ func (vm *VM) ParseAddress(addrStr string) (ids.ID, ids.ShortID, error) {
 // and then issues [tx] into the mempool if valid.
 func (vm *VM) issueTx(tx *Tx, local bool) error {
 	if err := vm.verifyTxAtTip(tx); err != nil {
+		if local && vm.isFutureNonceTx(tx, err) {
+			// The tx may become valid once the nonces of its inputs are
+			// reached, so it is queued rather than rejected. Remote txs are
+			// never queued, so that peers can't fill the queue.
+			return vm.mempool.AddFutureTx(tx)
+		}
 		if !local {
 			// unlike local txs, invalid remote txs are recorded as discarded
 			// so that they won't be requested again
@@ -1061,8 +1272,60 @@ func (vm *VM) issueTx(tx *Tx, local bool) error {
 	return nil
 }
 
+// isFutureNonceTx returns true if [tx] failed to be verified at the preferred
+// block with [err] because the nonce of one of its EVM inputs is ahead of its
+// account, and [AllowFutureNonceAtomicInputs] is enabled.
+func (vm *VM) isFutureNonceTx(tx *Tx, err error) bool {
+	if !vm.config.AllowFutureNonceAtomicInputs || !errors.Is(err, errInvalidNonce) {
+		return false
+	}
+	exportTx, ok := tx.UnsignedAtomicTx.(*UnsignedExportTx)
+	if !ok {
+		return false
+	}
+	preferredState, err := vm.chain.BlockState(vm.chain.CurrentBlock())
+	if err != nil {
+		return false
+	}
+	return hasFutureEVMInputNonces(preferredState, exportTx.Ins)
+}
+
+// issueFutureTxs issues the txs queued because the nonces of their EVM inputs
+// were ahead of their accounts. The txs whose nonces are still ahead are
+// queued again without being verified.
+func (vm *VM) issueFutureTxs() {
+	txs := vm.mempool.PopFutureTxs()
+	if len(txs) == 0 {
+		return
+	}
+	preferredState, err := vm.chain.BlockState(vm.chain.CurrentBlock())
+	if err != nil {
+		log.Debug("failed to retrieve block state at tip to issue txs with future nonces", "err", err)
+		for _, tx := range txs {
+			_ = vm.mempool.AddFutureTx(tx)
+		}
+		return
+	}
+	for _, tx := range txs {
+		if exportTx, ok := tx.UnsignedAtomicTx.(*UnsignedExportTx); ok && hasFutureEVMInputNonces(preferredState, exportTx.Ins) {
+			_ = vm.mempool.AddFutureTx(tx)
+			continue
+		}
+		if err := vm.issueTx(tx, true); err != nil {
+			log.Debug("failed to issue tx with future nonce",
+				"txID", tx.ID(),
+				"err", err,
+			)
+		}
+	}
+}
+
 // verifyTxAtTip verifies that [tx] is valid to be issued on top of the currently preferred block
 func (vm *VM) verifyTxAtTip(tx *Tx) error {
+	if err := vm.verifyImportAssets(tx); err != nil {
+		return err
+	}
+
 	preferredBlock := vm.chain.CurrentBlock()
 	preferredState, err := vm.chain.BlockState(preferredBlock)
 	if err != nil {
@@ -1104,6 +1367,77 @@ func (vm *VM) verifyTx(tx *Tx, parentHash common.Hash, baseFee *big.Int, state *
 	return tx.UnsignedAtomicTx.EVMStateTransfer(vm.ctx, state)
 }
 
+// VerifyAtomicTxs semantically verifies [txs] on top of [parent] using up to
+// [AtomicTxVerifyWorkers] goroutines, so that the signatures of different txs
+// are recovered concurrently. The returned error is always that of the first
+// invalid tx in [txs], regardless of the order in which txs are verified.
+// The fees of [txs] are cached, so that txs using the same amount of gas
+// don't each calculate their fee.
+// Note: this does not check for conflicts between the inputs of [txs].
+func (vm *VM) VerifyAtomicTxs(txs []*Tx, parent *Block, baseFee *big.Int, rules params.Rules) error {
+	numWorkers := vm.config.AtomicTxVerifyWorkers
+	if len(txs) < numWorkers {
+		numWorkers = len(txs)
+	}
+
+	indices := make(chan int, len(txs))
+	for i := range txs {
+		indices <- i
+	}
+	close(indices)
+
+	var (
+		opts = SemanticVerifyOptions{FeeCache: newAtomicFeeCache()}
+		errs = make([]error, len(txs))
+		wg   sync.WaitGroup
+	)
+	wg.Add(numWorkers)
+	for w := 0; w < numWorkers; w++ {
+		go func() {
+			defer wg.Done()
+			for i := range indices {
+				tx := txs[i]
+				errs[i] = tx.UnsignedAtomicTx.SemanticVerifyWithOptions(vm, tx, parent, baseFee, rules, opts)
+			}
+		}()
+	}
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			return fmt.Errorf("atomic tx %s at index %d: %w", txs[i].ID(), i, err)
+		}
+	}
+	return nil
+}
+
+// isAllowedImportAsset returns true if [assetID] may be imported into this
+// chain. AVAX may always be imported.
+func (vm *VM) isAllowedImportAsset(assetID ids.ID) bool {
+	if assetID == vm.ctx.AVAXAssetID || vm.allowedImportAssets.Len() == 0 {
+		return true
+	}
+	return vm.allowedImportAssets.Contains(assetID)
+}
+
+// verifyImportAssets returns an error if [tx] imports an asset that is not
+// allowed by [vm.allowedImportAssets].
+// Note: this is a node-local mempool policy and is intentionally not part of
+// SemanticVerify, so that it never causes blocks accepted by the rest of the
+// network to be rejected.
+func (vm *VM) verifyImportAssets(tx *Tx) error {
+	importTx, ok := tx.UnsignedAtomicTx.(*UnsignedImportTx)
+	if !ok {
+		return nil
+	}
+	for _, in := range importTx.ImportedInputs {
+		if assetID := in.AssetID(); !vm.isAllowedImportAsset(assetID) {
+			return fmt.Errorf("%w: %s", errImportAssetNotAllowed, assetID)
+		}
+	}
+	return nil
+}
+
 // GetAtomicUTXOs returns the utxos that at least one of the provided addresses is
 // referenced in.
 func (vm *VM) GetAtomicUTXOs(
@@ -1181,7 +1515,7 @@ func (vm *VM) GetSpendableFunds(
 		if assetID == vm.ctx.AVAXAssetID {
 			// If the asset is AVAX, we divide by the x2cRate to convert back to the correct
 			// denomination of AVAX that can be exported.
-			balance = new(big.Int).Div(state.GetBalance(addr), x2cRate).Uint64()
+			balance = weiToExportableAVAX(state.GetBalance(addr))
 		} else {
 			balance = state.GetBalanceMultiCoin(addr, common.Hash(assetID)).Uint64()
 		}
@@ -1212,6 +1546,68 @@ func (vm *VM) GetSpendableFunds(
 	return inputs, signers, nil
 }
 
+// weiToExportableAVAX returns the amount of AVAX, in nAVAX, that can be
+// exported from a balance of [wei]. The division by [x2cRate] rounds down:
+// the remainder of less than 1 nAVAX is not exported and stays in the
+// account, as exports only subtract whole nAVAX from the balance.
+func weiToExportableAVAX(wei *big.Int) uint64 {
+	return new(big.Int).Div(wei, x2cRate).Uint64()
+}
+
+// GetSpendableFundsLargestFirst returns a list of EVMInputs and keys (in
+// corresponding order) to total [amount] of [assetID] owned by [keys], as
+// GetSpendableFunds does, but spends from the keys with the largest balances
+// first to minimize the number of inputs.
+func (vm *VM) GetSpendableFundsLargestFirst(
+	keys []*crypto.PrivateKeySECP256K1R,
+	assetID ids.ID,
+	amount uint64,
+) ([]EVMInput, [][]*crypto.PrivateKeySECP256K1R, error) {
+	sortedKeys, err := vm.keysByBalanceDescending(keys, assetID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return vm.GetSpendableFunds(sortedKeys, assetID, amount)
+}
+
+// keysByBalanceDescending returns a copy of [keys] ordered by their balance of
+// [assetID] in the current state, largest first. Keys with equal balances keep
+// their order in [keys].
+func (vm *VM) keysByBalanceDescending(keys []*crypto.PrivateKeySECP256K1R, assetID ids.ID) ([]*crypto.PrivateKeySECP256K1R, error) {
+	state, err := vm.chain.CurrentState()
+	if err != nil {
+		return nil, err
+	}
+	balances := make([]*big.Int, len(keys))
+	sortedKeys := make([]*crypto.PrivateKeySECP256K1R, len(keys))
+	for i, key := range keys {
+		addr := GetEthAddress(key)
+		if assetID == vm.ctx.AVAXAssetID {
+			balances[i] = state.GetBalance(addr)
+		} else {
+			balances[i] = state.GetBalanceMultiCoin(addr, common.Hash(assetID))
+		}
+		sortedKeys[i] = key
+	}
+	sort.Stable(&keysByBalance{keys: sortedKeys, balances: balances})
+	return sortedKeys, nil
+}
+
+// keysByBalance sorts keys by their balances, largest first.
+type keysByBalance struct {
+	keys     []*crypto.PrivateKeySECP256K1R
+	balances []*big.Int
+}
+
+func (k *keysByBalance) Len() int { return len(k.keys) }
+
+func (k *keysByBalance) Less(i, j int) bool { return k.balances[i].Cmp(k.balances[j]) > 0 }
+
+func (k *keysByBalance) Swap(i, j int) {
+	k.keys[i], k.keys[j] = k.keys[j], k.keys[i]
+	k.balances[i], k.balances[j] = k.balances[j], k.balances[i]
+}
+
 // GetSpendableAVAXWithFee returns a list of EVMInputs and keys (in corresponding
 // order) to total [amount] + [fee] of [AVAX] owned by [keys].
 // This function accounts for the added cost of the additional inputs needed to
@@ -1257,7 +1653,7 @@ func (vm *VM) GetSpendableAVAXWithFee(
 			return nil, nil, err
 		}
 
-		newCost := cost + EVMInputGas
+		newCost := cost + evmInputGas(vm.chainConfig.GetAtomicTxFeeConfig())
 		newFee, err := calculateDynamicFee(newCost, baseFee)
 		if err != nil {
 			return nil, nil, err
@@ -1268,7 +1664,7 @@ func (vm *VM) GetSpendableAVAXWithFee(
 		addr := GetEthAddress(key)
 		// Since the asset is AVAX, we divide by the x2cRate to convert back to
 		// the correct denomination of AVAX that can be exported.
-		balance := new(big.Int).Div(state.GetBalance(addr), x2cRate).Uint64()
+		balance := weiToExportableAVAX(state.GetBalance(addr))
 		// If the balance for [addr] is insufficient to cover the additional cost
 		// of adding an input to the transaction, skip adding the input altogether
 		if balance <= additionalFee {
@@ -1326,7 +1722,15 @@ func (vm *VM) GetCurrentNonce(address common.Address) (uint64, error) {
 // currentRules returns the chain rules for the current block.
 func (vm *VM) currentRules() params.Rules {
 	header := vm.chain.APIBackend().CurrentHeader()
-	return vm.chainConfig.AvalancheRules(header.Number, big.NewInt(int64(header.Time)))
+	return vm.rulesAt(header.Number, header.Time)
+}
+
+// rulesAt returns the chain rules for a block at height [number] with
+// timestamp [timestamp]. Txs that are verified again after being accepted,
+// such as when reprocessing blocks, must be verified with the rules of their
+// block rather than with [currentRules].
+func (vm *VM) rulesAt(number *big.Int, timestamp uint64) params.Rules {
+	return vm.chainConfig.AvalancheRules(number, new(big.Int).SetUint64(timestamp))
 }
 
 // getBlockValidator returns the block validator that should be used for a block that
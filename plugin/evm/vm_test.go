@@ -24,8 +24,10 @@ import (
 
 	"github.com/stretchr/testify/assert"
 
+	"github.com/ava-labs/avalanchego/api"
 	"github.com/ava-labs/avalanchego/api/keystore"
 	"github.com/ava-labs/avalanchego/chains/atomic"
+	"github.com/ava-labs/avalanchego/database"
 	"github.com/ava-labs/avalanchego/database/manager"
 	"github.com/ava-labs/avalanchego/database/memdb"
 	"github.com/ava-labs/avalanchego/database/prefixdb"
@@ -37,6 +39,7 @@ import (
 	"github.com/ava-labs/avalanchego/utils/crypto"
 	"github.com/ava-labs/avalanchego/utils/formatting"
 	"github.com/ava-labs/avalanchego/utils/hashing"
+	avalancheJSON "github.com/ava-labs/avalanchego/utils/json"
 	"github.com/ava-labs/avalanchego/utils/logging"
 	"github.com/ava-labs/avalanchego/utils/units"
 	"github.com/ava-labs/avalanchego/version"
@@ -61,6 +64,7 @@ var (
 	testCChainID            = ids.ID{'c', 'c', 'h', 'a', 'i', 'n', 't', 'e', 's', 't'}
 	testXChainID            = ids.ID{'t', 'e', 's', 't', 'x'}
 	nonExistentID           = ids.ID{'F'}
+	testPeerID              = ids.ShortID{'p', 'e', 'e', 'r'}
 	testKeys         []*crypto.PrivateKeySECP256K1R
 	testEthAddrs     []common.Address // testEthAddrs[i] corresponds to testKeys[i]
 	testShortIDAddrs []ids.ShortID
@@ -76,12 +80,12 @@ var (
 	genesisJSONApricotPhase4 = "{\"config\":{\"chainId\":43111,\"homesteadBlock\":0,\"daoForkBlock\":0,\"daoForkSupport\":true,\"eip150Block\":0,\"eip150Hash\":\"0x2086799aeebeae135c246c65021c82b4e15a2c451340993aacfd2751886514f0\",\"eip155Block\":0,\"eip158Block\":0,\"byzantiumBlock\":0,\"constantinopleBlock\":0,\"petersburgBlock\":0,\"istanbulBlock\":0,\"muirGlacierBlock\":0,\"apricotPhase1BlockTimestamp\":0,\"apricotPhase2BlockTimestamp\":0,\"apricotPhase3BlockTimestamp\":0,\"apricotPhase4BlockTimestamp\":0},\"nonce\":\"0x0\",\"timestamp\":\"0x0\",\"extraData\":\"0x00\",\"gasLimit\":\"0x5f5e100\",\"difficulty\":\"0x0\",\"mixHash\":\"0x0000000000000000000000000000000000000000000000000000000000000000\",\"coinbase\":\"0x0000000000000000000000000000000000000000\",\"alloc\":{\"0100000000000000000000000000000000000000\":{\"code\":\"0x7300000000000000000000000000000000000000003014608060405260043610603d5760003560e01c80631e010439146042578063b6510bb314606e575b600080fd5b605c60048036036020811015605657600080fd5b503560b1565b60408051918252519081900360200190f35b818015607957600080fd5b5060af60048036036080811015608e57600080fd5b506001600160a01b03813516906020810135906040810135906060013560b6565b005b30cd90565b836001600160a01b031681836108fc8690811502906040516000604051808303818888878c8acf9550505050505015801560f4573d6000803e3d6000fd5b505050505056fea26469706673582212201eebce970fe3f5cb96bf8ac6ba5f5c133fc2908ae3dcd51082cfee8f583429d064736f6c634300060a0033\",\"balance\":\"0x0\"}},\"number\":\"0x0\",\"gasUsed\":\"0x0\",\"parentHash\":\"0x0000000000000000000000000000000000000000000000000000000000000000\"}"
 	genesisJSONApricotPhase5 = "{\"config\":{\"chainId\":43111,\"homesteadBlock\":0,\"daoForkBlock\":0,\"daoForkSupport\":true,\"eip150Block\":0,\"eip150Hash\":\"0x2086799aeebeae135c246c65021c82b4e15a2c451340993aacfd2751886514f0\",\"eip155Block\":0,\"eip158Block\":0,\"byzantiumBlock\":0,\"constantinopleBlock\":0,\"petersburgBlock\":0,\"istanbulBlock\":0,\"muirGlacierBlock\":0,\"apricotPhase1BlockTimestamp\":0,\"apricotPhase2BlockTimestamp\":0,\"apricotPhase3BlockTimestamp\":0,\"apricotPhase4BlockTimestamp\":0, \"apricotPhase5BlockTimestamp\":0},\"nonce\":\"0x0\",\"timestamp\":\"0x0\",\"extraData\":\"0x00\",\"gasLimit\":\"0x5f5e100\",\"difficulty\":\"0x0\",\"mixHash\":\"0x0000000000000000000000000000000000000000000000000000000000000000\",\"coinbase\":\"0x0000000000000000000000000000000000000000\",\"alloc\":{\"0100000000000000000000000000000000000000\":{\"code\":\"0x7300000000000000000000000000000000000000003014608060405260043610603d5760003560e01c80631e010439146042578063b6510bb314606e575b600080fd5b605c60048036036020811015605657600080fd5b503560b1565b60408051918252519081900360200190f35b818015607957600080fd5b5060af60048036036080811015608e57600080fd5b506001600160a01b03813516906020810135906040810135906060013560b6565b005b30cd90565b836001600160a01b031681836108fc8690811502906040516000604051808303818888878c8acf9550505050505015801560f4573d6000803e3d6000fd5b505050505056fea26469706673582212201eebce970fe3f5cb96bf8ac6ba5f5c133fc2908ae3dcd51082cfee8f583429d064736f6c634300060a0033\",\"balance\":\"0x0\"}},\"number\":\"0x0\",\"gasUsed\":\"0x0\",\"parentHash\":\"0x0000000000000000000000000000000000000000000000000000000000000000\"}"
 
-	apricotRulesPhase0 = params.Rules{}
-	apricotRulesPhase1 = params.Rules{IsApricotPhase1: true}
-	apricotRulesPhase2 = params.Rules{IsApricotPhase1: true, IsApricotPhase2: true}
-	apricotRulesPhase3 = params.Rules{IsApricotPhase1: true, IsApricotPhase2: true, IsApricotPhase3: true}
-	apricotRulesPhase4 = params.Rules{IsApricotPhase1: true, IsApricotPhase2: true, IsApricotPhase3: true, IsApricotPhase4: true}
-	apricotRulesPhase5 = params.Rules{IsApricotPhase1: true, IsApricotPhase2: true, IsApricotPhase3: true, IsApricotPhase4: true, IsApricotPhase5: true}
+	apricotRulesPhase0 = params.Rules{AtomicTxFeeConfig: params.DefaultAtomicTxFeeConfig}
+	apricotRulesPhase1 = params.Rules{IsApricotPhase1: true, AtomicTxFeeConfig: params.DefaultAtomicTxFeeConfig}
+	apricotRulesPhase2 = params.Rules{IsApricotPhase1: true, IsApricotPhase2: true, AtomicTxFeeConfig: params.DefaultAtomicTxFeeConfig}
+	apricotRulesPhase3 = params.Rules{IsApricotPhase1: true, IsApricotPhase2: true, IsApricotPhase3: true, AtomicTxFeeConfig: params.DefaultAtomicTxFeeConfig}
+	apricotRulesPhase4 = params.Rules{IsApricotPhase1: true, IsApricotPhase2: true, IsApricotPhase3: true, IsApricotPhase4: true, AtomicTxFeeConfig: params.DefaultAtomicTxFeeConfig}
+	apricotRulesPhase5 = params.Rules{IsApricotPhase1: true, IsApricotPhase2: true, IsApricotPhase3: true, IsApricotPhase4: true, IsApricotPhase5: true, AtomicTxFeeConfig: params.DefaultAtomicTxFeeConfig}
 )
 
 func init() {
@@ -107,7 +111,7 @@ func init() {
 }
 
 // BuildGenesisTest returns the genesis bytes for Coreth VM to be used in testing
-func BuildGenesisTest(t *testing.T, genesisJSON string) []byte {
+func BuildGenesisTest(t testing.TB, genesisJSON string) []byte {
 	ss := StaticService{}
 
 	genesis := &core.Genesis{}
@@ -158,7 +162,7 @@ func (sn *snLookup) SubnetID(chainID ids.ID) (ids.ID, error) {
 	return subnetID, nil
 }
 
-func setupGenesis(t *testing.T,
+func setupGenesis(t testing.TB,
 	genesisJSON string,
 ) (*snow.Context,
 	manager.Manager,
@@ -191,7 +195,7 @@ func setupGenesis(t *testing.T,
 
 // GenesisVM creates a VM instance with the genesis test bytes and returns
 // the channel use to send messages to the engine, the vm, and atomic memory
-func GenesisVM(t *testing.T,
+func GenesisVM(t testing.TB,
 	finishBootstrapping bool,
 	genesisJSON string,
 	configJSON string,
@@ -223,6 +227,10 @@ func GenesisVM(t *testing.T,
 		assert.NoError(t, vm.Bootstrapped())
 	}
 
+	// Connect a peer so that txs are gossiped rather than queued until a peer
+	// connects.
+	assert.NoError(t, vm.Connected(testPeerID, version.NewDefaultApplication(constants.PlatformName, 1, 7, 5)))
+
 	return issuer, vm, dbManager, m, appSender
 }
 
@@ -533,6 +541,272 @@ func TestIssueAtomicTxs(t *testing.T) {
 	assert.Equal(t, indexedExportTx.ID(), exportTx.ID(), "expected ID of indexed import tx to match original txID")
 }
 
+// Test that the status reported for an atomic tx follows it from the mempool
+// into a block and through acceptance, and that a discarded tx is reported as
+// dropped.
+func TestGetAtomicTxStatus(t *testing.T) {
+	importAmount := uint64(50000000)
+	issuer, vm, _, _, _ := GenesisVMWithUTXOs(t, true, genesisJSONApricotPhase2, "", "", map[ids.ShortID]uint64{
+		testShortIDAddrs[0]: importAmount,
+	})
+
+	defer func() {
+		if err := vm.Shutdown(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	service := &AvaxAPI{vm}
+	checkStatus := func(txID ids.ID, expectedStatus Status, expectedHeight *avalancheJSON.Uint64) {
+		t.Helper()
+
+		reply := &GetAtomicTxStatusReply{}
+		err := service.GetAtomicTxStatus(nil, &api.JSONTxID{TxID: txID}, reply)
+		assert.NoError(t, err)
+		assert.Equal(t, expectedStatus, reply.Status)
+		assert.Equal(t, expectedHeight, reply.BlockHeight)
+	}
+
+	importTx, err := vm.newImportTx(vm.ctx.XChainID, testEthAddrs[0], initialBaseFee, []*crypto.PrivateKeySECP256K1R{testKeys[0]})
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkStatus(importTx.ID(), Unknown, nil)
+
+	if err := vm.issueTx(importTx, true /*=local*/); err != nil {
+		t.Fatal(err)
+	}
+	checkStatus(importTx.ID(), Pending, nil)
+
+	<-issuer
+
+	blk, err := vm.BuildBlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkStatus(importTx.ID(), Processing, nil)
+
+	if err := blk.Verify(); err != nil {
+		t.Fatal(err)
+	}
+	if err := vm.SetPreference(blk.ID()); err != nil {
+		t.Fatal(err)
+	}
+	if err := blk.Accept(); err != nil {
+		t.Fatal(err)
+	}
+	height := avalancheJSON.Uint64(1)
+	checkStatus(importTx.ID(), Accepted, &height)
+
+	exportTx, err := vm.newExportTx(vm.ctx.AVAXAssetID, importAmount-(2*params.AvalancheAtomicTxFee), vm.ctx.XChainID, testShortIDAddrs[0], initialBaseFee, []*crypto.PrivateKeySECP256K1R{testKeys[0]})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := vm.mempool.AddTx(exportTx); err != nil {
+		t.Fatal(err)
+	}
+	checkStatus(exportTx.ID(), Pending, nil)
+
+	vm.mempool.NextTx()
+	vm.mempool.DiscardCurrentTx(exportTx.ID())
+	checkStatus(exportTx.ID(), Dropped, nil)
+}
+
+// Test that GetAtomicTxsByHeight returns the atomic txs accepted within the
+// requested range of heights and rejects invalid ranges.
+func TestGetAtomicTxsByHeight(t *testing.T) {
+	importAmount := uint64(50000000)
+	issuer, vm, _, _, _ := GenesisVMWithUTXOs(t, true, genesisJSONApricotPhase2, "", "", map[ids.ShortID]uint64{
+		testShortIDAddrs[0]: importAmount,
+	})
+
+	defer func() {
+		if err := vm.Shutdown(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	acceptTx := func(tx *Tx) {
+		if err := vm.issueTx(tx, true /*=local*/); err != nil {
+			t.Fatal(err)
+		}
+
+		<-issuer
+
+		blk, err := vm.BuildBlock()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := blk.Verify(); err != nil {
+			t.Fatal(err)
+		}
+		if err := vm.SetPreference(blk.ID()); err != nil {
+			t.Fatal(err)
+		}
+		if err := blk.Accept(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	importTx, err := vm.newImportTx(vm.ctx.XChainID, testEthAddrs[0], initialBaseFee, []*crypto.PrivateKeySECP256K1R{testKeys[0]})
+	if err != nil {
+		t.Fatal(err)
+	}
+	acceptTx(importTx)
+
+	exportTx, err := vm.newExportTx(vm.ctx.AVAXAssetID, importAmount-(2*params.AvalancheAtomicTxFee), vm.ctx.XChainID, testShortIDAddrs[0], initialBaseFee, []*crypto.PrivateKeySECP256K1R{testKeys[0]})
+	if err != nil {
+		t.Fatal(err)
+	}
+	acceptTx(exportTx)
+
+	service := &AvaxAPI{vm}
+
+	reply := &GetAtomicTxsByHeightReply{}
+	err = service.GetAtomicTxsByHeight(nil, &GetAtomicTxsByHeightArgs{StartHeight: 0, EndHeight: 3}, reply)
+	assert.NoError(t, err)
+	assert.Equal(t, []AtomicTxsAtHeight{
+		{Height: 1, TxIDs: []ids.ID{importTx.ID()}},
+		{Height: 2, TxIDs: []ids.ID{exportTx.ID()}},
+	}, reply.Heights)
+
+	reply = &GetAtomicTxsByHeightReply{}
+	err = service.GetAtomicTxsByHeight(nil, &GetAtomicTxsByHeightArgs{StartHeight: 2, EndHeight: 3}, reply)
+	assert.NoError(t, err)
+	assert.Equal(t, []AtomicTxsAtHeight{
+		{Height: 2, TxIDs: []ids.ID{exportTx.ID()}},
+	}, reply.Heights)
+
+	err = service.GetAtomicTxsByHeight(nil, &GetAtomicTxsByHeightArgs{StartHeight: 2, EndHeight: 1}, &GetAtomicTxsByHeightReply{})
+	assert.ErrorIs(t, err, errInvalidRange)
+
+	err = service.GetAtomicTxsByHeight(nil, &GetAtomicTxsByHeightArgs{StartHeight: 0, EndHeight: maxGetAtomicTxsByHeightRange}, &GetAtomicTxsByHeightReply{})
+	assert.Error(t, err, "expected range larger than the limit to fail")
+}
+
+// Test that the callbacks registered with OnAtomicTxAccepted are called with
+// the accepted atomic tx and the UTXOs it consumed.
+func TestOnAtomicTxAccepted(t *testing.T) {
+	importAmount := uint64(50000000)
+	issuer, vm, _, _, _ := GenesisVMWithUTXOs(t, true, genesisJSONApricotPhase2, "", "", map[ids.ShortID]uint64{
+		testShortIDAddrs[0]: importAmount,
+	})
+
+	defer func() {
+		if err := vm.Shutdown(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	acceptedTxIDs := []ids.ID{}
+	acceptedUTXOIDs := []ids.ID{}
+	vm.OnAtomicTxAccepted(func(txID ids.ID, utxoIDs []ids.ID) {
+		acceptedTxIDs = append(acceptedTxIDs, txID)
+		acceptedUTXOIDs = append(acceptedUTXOIDs, utxoIDs...)
+	})
+
+	importTx, err := vm.newImportTx(vm.ctx.XChainID, testEthAddrs[0], initialBaseFee, []*crypto.PrivateKeySECP256K1R{testKeys[0]})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := vm.issueTx(importTx, true /*=local*/); err != nil {
+		t.Fatal(err)
+	}
+
+	<-issuer
+
+	blk, err := vm.BuildBlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := blk.Verify(); err != nil {
+		t.Fatal(err)
+	}
+	if err := vm.SetPreference(blk.ID()); err != nil {
+		t.Fatal(err)
+	}
+	assert.Empty(t, acceptedTxIDs, "expected no callback before the block is accepted")
+
+	if err := blk.Accept(); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []ids.ID{importTx.ID()}, acceptedTxIDs)
+	assert.ElementsMatch(t, importTx.InputUTXOs().List(), acceptedUTXOIDs)
+}
+
+// failingSharedMemory wraps a SharedMemory and fails every call to Apply.
+type failingSharedMemory struct {
+	atomic.SharedMemory
+	err error
+}
+
+func (m *failingSharedMemory) Apply(map[ids.ID]*atomic.Requests, ...database.Batch) error {
+	return m.err
+}
+
+// Regression test to ensure that if applying the atomic operations of an
+// accepted block to shared memory fails, none of the changes made while
+// accepting the block are written to the database and the block's atomic txs
+// are not removed from the mempool.
+func TestAcceptSharedMemoryApplyFailure(t *testing.T) {
+	importAmount := uint64(50000000)
+	issuer, vm, _, _, _ := GenesisVMWithUTXOs(t, true, genesisJSONApricotPhase2, "", "", map[ids.ShortID]uint64{
+		testShortIDAddrs[0]: importAmount,
+	})
+
+	defer func() {
+		if err := vm.Shutdown(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	importTx, err := vm.newImportTx(vm.ctx.XChainID, testEthAddrs[0], initialBaseFee, []*crypto.PrivateKeySECP256K1R{testKeys[0]})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := vm.issueTx(importTx, true /*=local*/); err != nil {
+		t.Fatal(err)
+	}
+
+	<-issuer
+
+	blk, err := vm.BuildBlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := blk.Verify(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := vm.SetPreference(blk.ID()); err != nil {
+		t.Fatal(err)
+	}
+
+	lastAcceptedBytes, err := vm.acceptedBlockDB.Get(lastAcceptedKey)
+	if err == nil {
+		t.Fatalf("expected last accepted key to be unset before accepting the first block, but found %x", lastAcceptedBytes)
+	}
+
+	errApply := errors.New("test apply failure")
+	vm.ctx.SharedMemory = &failingSharedMemory{
+		SharedMemory: vm.ctx.SharedMemory,
+		err:          errApply,
+	}
+	err = blk.Accept()
+	assert.ErrorIs(t, err, errApply)
+	assert.Contains(t, err.Error(), vm.ctx.XChainID.String(), "expected error to identify the destination chain")
+
+	// Neither the last accepted block nor the atomic tx index should have been
+	// written to the database.
+	_, err = vm.acceptedBlockDB.Get(lastAcceptedKey)
+	assert.ErrorIs(t, err, database.ErrNotFound)
+	_, _, err = vm.atomicTxRepository.GetByTxID(importTx.ID())
+	assert.ErrorIs(t, err, database.ErrNotFound)
+	assert.True(t, vm.mempool.has(importTx.ID()), "expected import tx to remain in the mempool")
+}
+
 func TestBuildEthTxBlock(t *testing.T) {
 	importAmount := uint64(20000000)
 	issuer, vm, dbManager, _, _ := GenesisVMWithUTXOs(t, true, genesisJSONApricotPhase2, "{\"pruning-enabled\":true}", "", map[ids.ShortID]uint64{